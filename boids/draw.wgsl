@@ -1,15 +1,29 @@
+struct RenderParams {
+    // how far the frame is between the previous and the current step
+    alpha : f32,
+};
+
+@group(0) @binding(0) var<uniform> params : RenderParams;
+
 @vertex
 fn main_vs(
     @location(0) particle_pos: vec2<f32>,
     @location(1) particle_vel: vec2<f32>,
     @location(2) position: vec2<f32>,
+    @location(3) prev_pos: vec2<f32>,
+    @location(4) prev_vel: vec2<f32>,
 ) -> @builtin(position) vec4<f32> {
-    let angle = -atan2(particle_vel.x, particle_vel.y);
+    // don't interpolate across the screen when a boid wraps around
+    let wrapped = any(abs(particle_pos - prev_pos) > vec2<f32>(1.0));
+    let p = select(mix(prev_pos, particle_pos, params.alpha), particle_pos, wrapped);
+    let v = mix(prev_vel, particle_vel, params.alpha);
+
+    let angle = -atan2(v.x, v.y);
     let pos = vec2<f32>(
         position.x * cos(angle) - position.y * sin(angle),
         position.x * sin(angle) + position.y * cos(angle)
     );
-    return vec4<f32>(pos + particle_pos, 0.0, 1.0);
+    return vec4<f32>(pos + p, 0.0, 1.0);
 }
 
 @fragment
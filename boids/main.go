@@ -1,46 +1,30 @@
 package main
 
 import (
-	"fmt"
 	"math"
-	"math/rand"
-	"os"
-	"runtime"
 	"strconv"
-	"strings"
-
-	"github.com/go-gl/glfw/v3.3/glfw"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/staging"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/timestep"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 
 	_ "embed"
 )
 
-var forceFallbackAdapter = os.Getenv("WGPU_FORCE_FALLBACK_ADAPTER") == "1"
-
-func init() {
-	runtime.LockOSThread()
-
-	switch os.Getenv("WGPU_LOG_LEVEL") {
-	case "OFF":
-		wgpu.SetLogLevel(wgpu.LogLevel_Off)
-	case "ERROR":
-		wgpu.SetLogLevel(wgpu.LogLevel_Error)
-	case "WARN":
-		wgpu.SetLogLevel(wgpu.LogLevel_Warn)
-	case "INFO":
-		wgpu.SetLogLevel(wgpu.LogLevel_Info)
-	case "DEBUG":
-		wgpu.SetLogLevel(wgpu.LogLevel_Debug)
-	case "TRACE":
-		wgpu.SetLogLevel(wgpu.LogLevel_Trace)
-	}
-}
-
 const (
 	// number of boid particles to simulate
 	NumParticles = 1500
 	// number of single-particle calculations (invocations) in each gpu work group
 	ParticlesPerGroup = 64
+	// simulated time of one compute dispatch, deltaT is the distance per step
+	SimulationStep = time.Second / 60
+	// size of the staging belt's chunks, a frame uploads 16 bytes
+	BeltChunkSize = 4 << 10
 )
 
 //go:embed compute.wgsl
@@ -49,198 +33,223 @@ var compute string
 //go:embed draw.wgsl
 var draw string
 
+// SimParams mirrors SimParams in compute.wgsl.
+//
+//go:generate go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgslgen -type SimParams -space uniform -check compute.wgsl
+type SimParams struct {
+	deltaT        float32 // per SimulationStep
+	rule1Distance float32
+	rule2Distance float32
+	rule3Distance float32
+	rule1Scale    float32
+	rule2Scale    float32
+	rule3Scale    float32
+}
+
 type State struct {
-	surface            *wgpu.Surface
-	swapChain          *wgpu.SwapChain
-	device             *wgpu.Device
-	queue              *wgpu.Queue
-	config             *wgpu.SwapChainDescriptor
-	renderPipeline     *wgpu.RenderPipeline
-	computePipeline    *wgpu.ComputePipeline
-	vertexBuffer       *wgpu.Buffer
-	particleBindGroups []*wgpu.BindGroup
-	particleBuffers    []*wgpu.Buffer
+	resources          *resource.Registry
+	computeShader      *resource.Handle[*wgpu.ShaderModule]
+	drawShader         *resource.Handle[*wgpu.ShaderModule]
+	simParamBuffer     *resource.Handle[*wgpu.Buffer]
+	renderPipeline     *resource.Handle[*wgpu.RenderPipeline]
+	renderParamBuffer  *resource.Handle[*wgpu.Buffer]
+	renderBindGroup    *resource.Handle[*wgpu.BindGroup]
+	computePipeline    *resource.Handle[*wgpu.ComputePipeline]
+	vertexBuffer       *resource.Handle[*wgpu.Buffer]
+	particleBindGroups []*resource.Handle[*wgpu.BindGroup]
+	particleBuffers    []*resource.Handle[*wgpu.Buffer]
 	frameNum           uint64
 	workGroupCount     uint32
+	accumulator        *timestep.Accumulator
+	steps              int
+	// belt uploads the render params in the frame's encoder
+	belt *staging.Belt
+	// loadOp loads the swap chain textures, which wgpu hands out cleared,
+	// and clears the headless target, which would keep the previous frame
+	loadOp wgpu.LoadOp
 }
 
-func InitState(window *glfw.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
-
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adapter, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		ForceFallbackAdapter: forceFallbackAdapter,
-		CompatibleSurface:    s.surface,
-	})
-	if err != nil {
-		return s, err
+// Init creates everything through ctx.Resources, which reports the objects
+// Destroy misses with WGPU_TRACK_LEAKS=1 and recreates them after a device
+// loss. The particles restart from their initial positions then.
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.resources = ctx.Resources
+	s.loadOp = wgpu.LoadOp_Load
+	if ctx.Surface == nil {
+		s.loadOp = wgpu.LoadOp_Clear
 	}
-	defer adapter.Drop()
 
-	s.device, err = adapter.RequestDevice(nil)
+	s.computeShader, err = ctx.Shaders.ShaderModule("compute.wgsl", compute)
 	if err != nil {
-		return s, err
-	}
-	s.queue = s.device.GetQueue()
-
-	width, height := window.GetSize()
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adapter),
-		Width:       uint32(width),
-		Height:      uint32(height),
-		PresentMode: wgpu.PresentMode_Fifo,
-	}
-
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-	if err != nil {
-		return s, err
+		return err
 	}
 
-	computeShader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "compute.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: compute,
-		},
-	})
+	s.drawShader, err = ctx.Shaders.ShaderModule("draw.wgsl", draw)
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer computeShader.Drop()
 
-	drawShader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "draw.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: draw,
-		},
-	})
-	if err != nil {
-		return s, err
-	}
-	defer drawShader.Drop()
-
-	simParamData := [...]float32{
-		0.04,  // deltaT
-		0.1,   // rule1Distance
-		0.025, // rule2Distance
-		0.025, // rule3Distance
-		0.02,  // rule1Scale
-		0.05,  // rule2Scale
-		0.005, // rule3Scale
-	}
+	simParamData := [...]SimParams{{
+		deltaT:        0.04,
+		rule1Distance: 0.1,
+		rule2Distance: 0.025,
+		rule3Distance: 0.025,
+		rule1Scale:    0.02,
+		rule2Scale:    0.05,
+		rule3Scale:    0.005,
+	}}
 
-	simParamBuffer, err := s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.simParamBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Simulation Param Buffer",
 		Contents: wgpu.ToBytes(simParamData[:]),
 		Usage:    wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer simParamBuffer.Drop()
 
-	s.renderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Vertex: wgpu.VertexState{
-			Module:     drawShader,
-			EntryPoint: "main_vs",
-			Buffers: []wgpu.VertexBufferLayout{
-				{
-					ArrayStride: 4 * 4,
-					StepMode:    wgpu.VertexStepMode_Instance,
-					Attributes: []wgpu.VertexAttribute{
-						{
-							Format:         wgpu.VertexFormat_Float32x2,
-							Offset:         0,
-							ShaderLocation: 0,
+	format := ctx.Config.Format
+	s.renderPipeline, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Vertex: wgpu.VertexState{
+				Module:     s.drawShader.Get(),
+				EntryPoint: "main_vs",
+				Buffers: []wgpu.VertexBufferLayout{
+					{
+						ArrayStride: 4 * 4,
+						StepMode:    wgpu.VertexStepMode_Instance,
+						Attributes: []wgpu.VertexAttribute{
+							{
+								Format:         wgpu.VertexFormat_Float32x2,
+								Offset:         0,
+								ShaderLocation: 0,
+							},
+							{
+								Format:         wgpu.VertexFormat_Float32x2,
+								Offset:         0 + wgpu.VertexFormat_Float32x2.Size(),
+								ShaderLocation: 1,
+							},
 						},
-						{
-							Format:         wgpu.VertexFormat_Float32x2,
-							Offset:         0 + wgpu.VertexFormat_Float32x2.Size(),
-							ShaderLocation: 1,
+					},
+					{
+						ArrayStride: 2 * 4,
+						StepMode:    wgpu.VertexStepMode_Vertex,
+						Attributes: []wgpu.VertexAttribute{
+							{
+								Format:         wgpu.VertexFormat_Float32x2,
+								Offset:         0,
+								ShaderLocation: 2,
+							},
 						},
 					},
-				},
-				{
-					ArrayStride: 2 * 4,
-					StepMode:    wgpu.VertexStepMode_Vertex,
-					Attributes: []wgpu.VertexAttribute{
-						{
-							Format:         wgpu.VertexFormat_Float32x2,
-							Offset:         0,
-							ShaderLocation: 2,
+					// the particles of the previous step, to interpolate from
+					{
+						ArrayStride: 4 * 4,
+						StepMode:    wgpu.VertexStepMode_Instance,
+						Attributes: []wgpu.VertexAttribute{
+							{
+								Format:         wgpu.VertexFormat_Float32x2,
+								Offset:         0,
+								ShaderLocation: 3,
+							},
+							{
+								Format:         wgpu.VertexFormat_Float32x2,
+								Offset:         0 + wgpu.VertexFormat_Float32x2.Size(),
+								ShaderLocation: 4,
+							},
 						},
 					},
 				},
 			},
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     drawShader,
-			EntryPoint: "main_fs",
-			Targets: []wgpu.ColorTargetState{
+			Fragment: &wgpu.FragmentState{
+				Module:     s.drawShader.Get(),
+				EntryPoint: "main_fs",
+				Targets: []wgpu.ColorTargetState{
+					{
+						Format:    format,
+						Blend:     nil,
+						WriteMask: wgpu.ColorWriteMask_All,
+					},
+				},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.renderParamBuffer, err = s.resources.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Render Param Buffer",
+		Size:  4 * 4,
+		Usage: wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.renderBindGroup, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		layout := s.renderPipeline.Get().GetBindGroupLayout(0)
+		defer leak.Track(s.resources.Leaks, layout).Drop()
+
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout: layout,
+			Entries: []wgpu.BindGroupEntry{
 				{
-					Format:    s.config.Format,
-					Blend:     nil,
-					WriteMask: wgpu.ColorWriteMask_All,
+					Binding: 0,
+					Buffer:  s.renderParamBuffer.Get(),
+					Size:    wgpu.WholeSize,
 				},
 			},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.computePipeline, err = s.device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
-		Label: "Compute pipeline",
-		Compute: wgpu.ProgrammableStageDescriptor{
-			Module:     computeShader,
-			EntryPoint: "main",
-		},
+	s.computePipeline, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.ComputePipeline, error) {
+		return device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
+			Label: "Compute pipeline",
+			Compute: wgpu.ProgrammableStageDescriptor{
+				Module:     s.computeShader.Get(),
+				EntryPoint: "main",
+			},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	vertexBufferData := [...]float32{-0.01, -0.02, 0.01, -0.02, 0.00, 0.02}
-	s.vertexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.vertexBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
 		Contents: wgpu.ToBytes(vertexBufferData[:]),
 		Usage:    wgpu.BufferUsage_Vertex | wgpu.BufferUsage_CopyDst,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	var initialParticleData [4 * NumParticles]float32
-	rng := rand.NewSource(42)
+	sampler := glm.NewSampler[float32](42)
 
 	for i := 0; i < len(initialParticleData); i += 4 {
-		initialParticleData[i+0] = float32(rng.Int63())/math.MaxInt64*2 - 1
-		initialParticleData[i+1] = float32(rng.Int63())/math.MaxInt64*2 - 1
-		initialParticleData[i+2] = (float32(rng.Int63())/math.MaxInt64*2 - 1) * 0.1
-		initialParticleData[i+3] = (float32(rng.Int63())/math.MaxInt64*2 - 1) * 0.1
+		initialParticleData[i+0] = sampler.Range(-1, 1)
+		initialParticleData[i+1] = sampler.Range(-1, 1)
+		initialParticleData[i+2] = sampler.Range(-1, 1) * 0.1
+		initialParticleData[i+3] = sampler.Range(-1, 1) * 0.1
 	}
 
 	for i := 0; i < 2; i++ {
-		particleBuffer, err := s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		particleBuffer, err := s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 			Label:    "Particle Buffer " + strconv.Itoa(i),
 			Contents: wgpu.ToBytes(initialParticleData[:]),
 			Usage: wgpu.BufferUsage_Vertex |
@@ -248,38 +257,41 @@ func InitState(window *glfw.Window) (s *State, err error) {
 				wgpu.BufferUsage_CopyDst,
 		})
 		if err != nil {
-			return s, err
+			return err
 		}
 
 		s.particleBuffers = append(s.particleBuffers, particleBuffer)
 	}
 
-	computeBindGroupLayout := s.computePipeline.GetBindGroupLayout(0)
-	defer computeBindGroupLayout.Drop()
-
 	for i := 0; i < 2; i++ {
-		particleBindGroup, err := s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-			Layout: computeBindGroupLayout,
-			Entries: []wgpu.BindGroupEntry{
-				{
-					Binding: 0,
-					Buffer:  simParamBuffer,
-					Size:    wgpu.WholeSize,
-				},
-				{
-					Binding: 1,
-					Buffer:  s.particleBuffers[i],
-					Size:    wgpu.WholeSize,
-				},
-				{
-					Binding: 2,
-					Buffer:  s.particleBuffers[(i+1)%2],
-					Size:    wgpu.WholeSize,
+		i := i
+		particleBindGroup, err := resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+			layout := s.computePipeline.Get().GetBindGroupLayout(0)
+			defer leak.Track(s.resources.Leaks, layout).Drop()
+
+			return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+				Layout: layout,
+				Entries: []wgpu.BindGroupEntry{
+					{
+						Binding: 0,
+						Buffer:  s.simParamBuffer.Get(),
+						Size:    wgpu.WholeSize,
+					},
+					{
+						Binding: 1,
+						Buffer:  s.particleBuffers[i].Get(),
+						Size:    wgpu.WholeSize,
+					},
+					{
+						Binding: 2,
+						Buffer:  s.particleBuffers[(i+1)%2].Get(),
+						Size:    wgpu.WholeSize,
+					},
 				},
-			},
+			})
 		})
 		if err != nil {
-			return s, err
+			return err
 		}
 
 		s.particleBindGroups = append(s.particleBindGroups, particleBindGroup)
@@ -287,152 +299,101 @@ func InitState(window *glfw.Window) (s *State, err error) {
 
 	s.workGroupCount = uint32(math.Ceil(float64(NumParticles) / float64(ParticlesPerGroup)))
 	s.frameNum = uint64(0)
+	s.accumulator = timestep.NewAccumulator(SimulationStep)
+	s.belt = s.newBelt()
 
-	return s, nil
+	return nil
 }
 
-func (s *State) Resize(width, height int) {
-	if width > 0 && height > 0 {
-		s.config.Width = uint32(width)
-		s.config.Height = uint32(height)
+// newBelt creates a belt on the current device, tracking its chunks like
+// the objects of Resources.
+func (s *State) newBelt() *staging.Belt {
+	belt := staging.NewBelt(s.resources.Device(), BeltChunkSize)
+	belt.Leaks = s.resources.Leaks
+	return belt
+}
 
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-	}
+// Update decides how many simulation steps Render runs, so that the boids
+// move at the same speed at any frame rate.
+func (s *State) Update(dt time.Duration) {
+	s.steps = s.accumulator.Advance(dt)
 }
 
-func (s *State) Render() error {
-	nextTexture, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer nextTexture.Drop()
+func (s *State) Resize(width, height uint32) {}
 
-	commandEncoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
+// DeviceRecovered replaces the belt, its chunks belong to the lost device.
+func (s *State) DeviceRecovered() {
+	s.belt.Drop()
+	s.belt = s.newBelt()
+}
+
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
+	params := [4]float32{float32(s.accumulator.Alpha())}
+	if err := s.belt.WriteBuffer(encoder, s.renderParamBuffer.Get(), 0, wgpu.ToBytes(params[:])); err != nil {
+		panic(err)
 	}
 
-	computePass := commandEncoder.BeginComputePass(nil)
-	computePass.SetPipeline(s.computePipeline)
-	computePass.SetBindGroup(0, s.particleBindGroups[s.frameNum%2], nil)
-	computePass.DispatchWorkgroups(s.workGroupCount, 1, 1)
-	computePass.End()
+	for i := 0; i < s.steps; i++ {
+		computePass := encoder.BeginComputePass(nil)
+		computePass.SetPipeline(s.computePipeline.Get())
+		computePass.SetBindGroup(0, s.particleBindGroups[s.frameNum%2].Get(), nil)
+		computePass.DispatchWorkgroups(s.workGroupCount, 1, 1)
+		computePass.End()
+
+		s.frameNum += 1
+	}
+	s.steps = 0
 
-	renderPass := commandEncoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
+	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{
 			{
-				View:    nextTexture,
-				LoadOp:  wgpu.LoadOp_Load,
-				StoreOp: wgpu.StoreOp_Store,
+				View:       view,
+				LoadOp:     s.loadOp,
+				StoreOp:    wgpu.StoreOp_Store,
+				ClearValue: wgpu.Color{A: 1},
 			},
 		},
 	})
-	renderPass.SetPipeline(s.renderPipeline)
-	renderPass.SetVertexBuffer(0, s.particleBuffers[(s.frameNum+1)%2], 0, wgpu.WholeSize)
-	renderPass.SetVertexBuffer(1, s.vertexBuffer, 0, wgpu.WholeSize)
+	renderPass.SetPipeline(s.renderPipeline.Get())
+	renderPass.SetBindGroup(0, s.renderBindGroup.Get(), nil)
+	renderPass.SetVertexBuffer(0, s.particleBuffers[s.frameNum%2].Get(), 0, wgpu.WholeSize)
+	renderPass.SetVertexBuffer(1, s.vertexBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetVertexBuffer(2, s.particleBuffers[(s.frameNum+1)%2].Get(), 0, wgpu.WholeSize)
 	renderPass.Draw(3, NumParticles, 0, 0)
 	renderPass.End()
 
-	s.frameNum += 1
-
-	s.queue.Submit(commandEncoder.Finish(nil))
-	s.swapChain.Present()
+	s.belt.Finish()
+}
 
-	return nil
+// Submitted hands the frame's staging chunks back to the belt, which
+// reuses them once the GPU is done with the frame.
+func (s *State) Submitted(index wgpu.SubmissionIndex) {
+	s.belt.Fence(index)
 }
 
 func (s *State) Destroy() {
-	if s.particleBindGroups != nil {
-		for _, bg := range s.particleBindGroups {
-			bg.Drop()
-		}
-		s.particleBindGroups = nil
-	}
-	if s.particleBuffers != nil {
-		for _, buffers := range s.particleBuffers {
-			buffers.Drop()
-		}
-		s.particleBuffers = nil
-	}
-	if s.vertexBuffer != nil {
-		s.vertexBuffer.Drop()
-		s.vertexBuffer = nil
-	}
-	if s.computePipeline != nil {
-		s.computePipeline.Drop()
-		s.computePipeline = nil
-	}
-	if s.renderPipeline != nil {
-		s.renderPipeline.Drop()
-		s.renderPipeline = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
+	if s.belt != nil {
+		s.belt.Drop()
+		s.belt = nil
+	}
+	for _, bindGroup := range s.particleBindGroups {
+		bindGroup.Drop()
+	}
+	for _, buffer := range s.particleBuffers {
+		buffer.Drop()
+	}
+	s.vertexBuffer.Drop()
+	s.computePipeline.Drop()
+	s.renderBindGroup.Drop()
+	s.renderParamBuffer.Drop()
+	s.renderPipeline.Drop()
+	s.simParamBuffer.Drop()
+	s.drawShader.Drop()
+	s.computeShader.Drop()
 }
 
 func main() {
-	if err := glfw.Init(); err != nil {
+	if err := framework.Run(&State{}, framework.Options{}); err != nil {
 		panic(err)
 	}
-	defer glfw.Terminate()
-
-	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
-	window, err := glfw.CreateWindow(640, 480, "go-webgpu with glfw", nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	defer window.Destroy()
-
-	s, err := InitState(window)
-	if err != nil {
-		panic(err)
-	}
-	defer s.Destroy()
-
-	window.SetSizeCallback(func(w *glfw.Window, width, height int) {
-		s.Resize(width, height)
-	})
-
-	for !window.ShouldClose() {
-		glfw.PollEvents()
-
-		err := s.Render()
-		if err != nil {
-			fmt.Println(err)
-
-			errstr := err.Error()
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(window.GetSize())
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(window.GetSize())
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
-}
\ No newline at end of file
+}
@@ -1,64 +1,55 @@
 package main
 
 import (
-	"image"
-	"image/png"
+	"flag"
 	"os"
-	"unsafe"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/capture"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/config"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgpulog"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-var forceFallbackAdapter = os.Getenv("WGPU_FORCE_FALLBACK_ADAPTER") == "1"
+var cfg = config.FromEnv()
 
 func init() {
-	switch os.Getenv("WGPU_LOG_LEVEL") {
-	case "OFF":
-		wgpu.SetLogLevel(wgpu.LogLevel_Off)
-	case "ERROR":
-		wgpu.SetLogLevel(wgpu.LogLevel_Error)
-	case "WARN":
-		wgpu.SetLogLevel(wgpu.LogLevel_Warn)
-	case "INFO":
-		wgpu.SetLogLevel(wgpu.LogLevel_Info)
-	case "DEBUG":
-		wgpu.SetLogLevel(wgpu.LogLevel_Debug)
-	case "TRACE":
-		wgpu.SetLogLevel(wgpu.LogLevel_Trace)
-	}
-}
-
-type BufferDimensions struct {
-	width               uint64
-	height              uint64
-	unpaddedBytesPerRow uint64
-	paddedBytesPerRow   uint64
-}
-
-func newBufferDimensions(width uint64, height uint64) BufferDimensions {
-	const bytesPerPixel = unsafe.Sizeof(uint32(0))
-	unpaddedBytesPerRow := width * uint64(bytesPerPixel)
-	align := uint64(wgpu.CopyBytesPerRowAlignment)
-	paddedBytesPerRowPadding := (align - unpaddedBytesPerRow%align) % align
-	paddedBytesPerRow := unpaddedBytesPerRow + uint64(paddedBytesPerRowPadding)
-	return BufferDimensions{
-		width,
-		height,
-		unpaddedBytesPerRow,
-		paddedBytesPerRow,
-	}
+	cfg.RegisterFlags(flag.CommandLine)
 }
 
 func main() {
 	width := 100
 	height := 200
 
-	instance := wgpu.CreateInstance(nil)
+	flag.Parse()
+	cfg.Apply()
+	defer wgpulog.Flush()
+	if cfg.ListAdapters {
+		if err := cfg.PrintAdapters(os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if err := cfg.SingleSample(); err != nil {
+		panic(err)
+	}
+	if cfg.Width > 0 {
+		width = cfg.Width
+	}
+	if cfg.Height > 0 {
+		height = cfg.Height
+	}
+
+	tracer := cfg.NewTracer()
+	defer func() {
+		if err := cfg.WriteTrace(tracer); err != nil {
+			panic(err)
+		}
+	}()
+
+	instance := wgpu.CreateInstance(cfg.InstanceDescriptor())
 	defer instance.Drop()
 
-	adapter, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		ForceFallbackAdapter: forceFallbackAdapter,
-	})
+	adapter, err := instance.RequestAdapter(cfg.RequestAdapterOptions(nil))
 	if err != nil {
 		panic(err)
 	}
@@ -71,32 +62,24 @@ func main() {
 	defer device.Drop()
 	queue := device.GetQueue()
 
-	bufferDimensions := newBufferDimensions(uint64(width), uint64(height))
-
-	bufferSize := bufferDimensions.paddedBytesPerRow * bufferDimensions.height
-	// The output buffer lets us retrieve the data as an array
-	outputBuffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
-		Size:  bufferSize,
-		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
-	})
+	reader, err := capture.NewReader(device, uint32(width), uint32(height))
 	if err != nil {
 		panic(err)
 	}
-	defer outputBuffer.Drop()
-
-	textureExtent := wgpu.Extent3D{
-		Width:              uint32(bufferDimensions.width),
-		Height:             uint32(bufferDimensions.height),
-		DepthOrArrayLayers: 1,
-	}
+	defer reader.Drop()
+	reader.Trace = tracer
 
 	// The render pipeline renders data into this texture
 	texture, err := device.CreateTexture(&wgpu.TextureDescriptor{
-		Size:          textureExtent,
+		Size: wgpu.Extent3D{
+			Width:              uint32(width),
+			Height:             uint32(height),
+			DepthOrArrayLayers: 1,
+		},
 		MipLevelCount: 1,
 		SampleCount:   1,
 		Dimension:     wgpu.TextureDimension_2D,
-		Format:        wgpu.TextureFormat_RGBA8UnormSrgb,
+		Format:        capture.Format,
 		Usage:         wgpu.TextureUsage_RenderAttachment | wgpu.TextureUsage_CopySrc,
 	})
 	if err != nil {
@@ -105,6 +88,7 @@ func main() {
 	defer texture.Drop()
 
 	// Set the background to be red
+	encode := tracer.Begin("Encode")
 	encoder, err := device.CreateCommandEncoder(nil)
 	if err != nil {
 		panic(err)
@@ -124,49 +108,24 @@ func main() {
 	renderPass.End()
 
 	// Copy the data from the texture to the buffer
-	encoder.CopyTextureToBuffer(
-		texture.AsImageCopy(),
-		&wgpu.ImageCopyBuffer{
-			Buffer: outputBuffer,
-			Layout: wgpu.TextureDataLayout{
-				Offset:       0,
-				BytesPerRow:  uint32(bufferDimensions.paddedBytesPerRow),
-				RowsPerImage: wgpu.CopyStrideUndefined,
-			},
-		},
-		&textureExtent,
-	)
-
-	index := queue.Submit(encoder.Finish(nil))
-
-	outputBuffer.MapAsync(wgpu.MapMode_Read, 0, bufferSize, func(status wgpu.BufferMapAsyncStatus) {
-		if status != wgpu.BufferMapAsyncStatus_Success {
-			panic("failed to map buffer")
-		}
-	})
-	defer outputBuffer.Unmap()
-
-	device.Poll(true, &wgpu.WrappedSubmissionIndex{
-		Queue:           queue,
-		SubmissionIndex: index,
-	})
+	reader.Copy(encoder, texture)
+	commands := encoder.Finish(nil)
+	encode.End()
 
-	data := outputBuffer.GetMappedRange(0, uint(bufferSize))
+	submit := tracer.Begin("Submit")
+	index := queue.Submit(commands)
+	submit.End()
 
-	// Save png
-	f, err := os.Create("image.png")
+	img, err := reader.Read(index)
 	if err != nil {
 		panic(err)
 	}
-	defer f.Close()
 
-	imageEncoder := png.Encoder{CompressionLevel: png.BestCompression}
-	err = imageEncoder.Encode(f, &image.NRGBA{
-		Pix:    data,
-		Stride: int(bufferDimensions.paddedBytesPerRow),
-		Rect:   image.Rect(0, 0, width, height),
-	})
+	// Save png
+	save := tracer.Begin("Save PNG")
+	err = capture.SavePNG("image.png", img)
+	save.End()
 	if err != nil {
 		panic(err)
 	}
-}
\ No newline at end of file
+}
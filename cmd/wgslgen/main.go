@@ -0,0 +1,564 @@
+// Command wgslgen keeps Go structs and the WGSL structs they are uploaded
+// to in agreement. It writes the WGSL structs matching Go structs, or with
+// -check compares them with the structs of an existing shader, and fails
+// when the layouts differ. It is meant to be run by go:generate:
+//
+//	//go:generate go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgslgen -type CameraUniform -space uniform -o camera_gen.wgsl
+//	//go:generate go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgslgen -type SimParams -space uniform -check compute.wgsl
+//
+// Go field types map to WGSL types: float32, int32 and uint32 to f32, i32
+// and u32, [N]T and glm.VecN[T] to vecN<T> for N from 2 to 4, [C][R]T and
+// glm.Mat4[T] to matCxR<T>, other arrays to array<T, N> and structs of the
+// same package to the WGSL struct of the same name, which is generated
+// too. Field names are converted to snake_case unless a `wgsl:"name"` tag
+// gives one, and fields named _ are padding.
+//
+// In the uniform and storage address spaces every field must be at the
+// offset WGSL puts it at. Padding in Go that WGSL doesn't need becomes a
+// @size attribute, padding WGSL needs but Go is missing, like after a
+// vec3, is an error. In the vertex space the fields get consecutive
+// @location attributes, starting at 0 or at a `wgsl:"name,location=N"`
+// tag, and matrices are split into one vector per column.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+)
+
+const glmPath = "github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of Go struct `names`; required")
+	space     = flag.String("space", "storage", "address `space` whose layout rules apply: uniform, storage or vertex")
+	output    = flag.String("o", "", "output `file`; default <first type>_gen.wgsl")
+	check     = flag.String("check", "", "compare with the structs of this WGSL `file` instead of writing one")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: wgslgen -type T[,T...] [flags] [package dir]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		usage()
+		os.Exit(2)
+	}
+	if *space != "uniform" && *space != "storage" && *space != "vertex" {
+		fmt.Fprintf(os.Stderr, "wgslgen: invalid -space %q\n", *space)
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir, strings.Split(*typeNames, ",")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, names []string) error {
+	g, err := load(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := g.structType(name); err != nil {
+			return err
+		}
+	}
+
+	if *check != "" {
+		return g.check(inDir(dir, *check), names)
+	}
+
+	code, err := g.generate()
+	if err != nil {
+		return err
+	}
+	out := *output
+	if out == "" {
+		out = strings.ToLower(names[0]) + "_gen.wgsl"
+	}
+	return os.WriteFile(inDir(dir, out), code, 0o644)
+}
+
+// inDir resolves the -o and -check paths, relative ones are relative to the
+// package directory.
+func inDir(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// goStruct is a Go struct converted to WGSL types, with the offsets the Go
+// compiler puts the fields at.
+type goStruct struct {
+	name   string
+	pos    token.Position
+	fields []goField
+	size   int
+	align  int
+}
+
+type goField struct {
+	name     string
+	pos      token.Position
+	typ      wgsl.Type
+	offset   int
+	location int
+}
+
+type generator struct {
+	fset  *token.FileSet
+	types map[string]*ast.TypeSpec
+	files map[string]*ast.File
+	// structs holds the converted structs in the order they need to be
+	// declared.
+	structs   []*goStruct
+	converted map[string]*goStruct
+}
+
+func load(dir string) (*generator, error) {
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		fset:      token.NewFileSet(),
+		types:     map[string]*ast.TypeSpec{},
+		files:     map[string]*ast.File{},
+		converted: map[string]*goStruct{},
+	}
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(g.fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				g.types[spec.Name.Name] = spec
+				g.files[spec.Name.Name] = f
+			}
+			return true
+		})
+	}
+	return g, nil
+}
+
+func (g *generator) errorf(pos token.Pos, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", g.fset.Position(pos), fmt.Sprintf(format, args...))
+}
+
+// structType converts the Go struct name and the structs it uses.
+func (g *generator) structType(name string) (*goStruct, error) {
+	if s, ok := g.converted[name]; ok {
+		if s == nil {
+			return nil, fmt.Errorf("struct %s contains itself", name)
+		}
+		return s, nil
+	}
+	spec, ok := g.types[name]
+	if !ok {
+		return nil, fmt.Errorf("no type %s", name)
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok || spec.TypeParams != nil {
+		return nil, g.errorf(spec.Pos(), "%s isn't a struct", name)
+	}
+	g.converted[name] = nil
+
+	s := &goStruct{name: name, pos: g.fset.Position(spec.Pos()), align: 1}
+	location := 0
+	for _, field := range st.Fields.List {
+		typ, size, align, err := g.goType(g.files[name], field.Type)
+		if err != nil {
+			return nil, err
+		}
+		fieldName, loc, err := g.tag(field)
+		if err != nil {
+			return nil, err
+		}
+		if loc >= 0 {
+			location = loc
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			return nil, g.errorf(field.Pos(), "embedded fields aren't supported")
+		}
+		for _, n := range names {
+			s.size = roundUp(align, s.size)
+			if n.Name != "_" {
+				f := goField{
+					name:     fieldName,
+					pos:      g.fset.Position(n.Pos()),
+					typ:      typ,
+					offset:   s.size,
+					location: location,
+				}
+				if f.name == "" {
+					f.name = snakeCase(n.Name)
+				}
+				s.fields = append(s.fields, f)
+				location++
+				if cols, _, ok := matrix(typ); ok && *space == "vertex" {
+					location += cols - 1
+				}
+			}
+			s.size += size
+			s.align = max(s.align, align)
+		}
+	}
+	s.size = roundUp(s.align, s.size)
+
+	g.converted[name] = s
+	g.structs = append(g.structs, s)
+	return s, nil
+}
+
+// tag returns the name and location of the `wgsl:"name,location=N"` tag of
+// field, location is -1 without one.
+func (g *generator) tag(field *ast.Field) (name string, location int, err error) {
+	if field.Tag == nil {
+		return "", -1, nil
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", -1, g.errorf(field.Tag.Pos(), "invalid tag")
+	}
+	value, ok := reflect.StructTag(tag).Lookup("wgsl")
+	if !ok {
+		return "", -1, nil
+	}
+	name, options, _ := strings.Cut(value, ",")
+	location = -1
+	if options != "" {
+		n, ok := strings.CutPrefix(options, "location=")
+		location, err = strconv.Atoi(n)
+		if !ok || err != nil || location < 0 {
+			return "", -1, g.errorf(field.Tag.Pos(), "invalid wgsl tag option %q", options)
+		}
+	}
+	return name, location, nil
+}
+
+// goType returns the WGSL type matching the Go type expr in file, with its
+// Go size and alignment.
+func (g *generator) goType(file *ast.File, expr ast.Expr) (t wgsl.Type, size, align int, err error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		switch expr.Name {
+		case "float32":
+			return wgsl.Type{Name: "f32"}, 4, 4, nil
+		case "int32":
+			return wgsl.Type{Name: "i32"}, 4, 4, nil
+		case "uint32":
+			return wgsl.Type{Name: "u32"}, 4, 4, nil
+		}
+		if _, ok := g.types[expr.Name]; ok {
+			s, err := g.structType(expr.Name)
+			if err != nil {
+				return t, 0, 0, err
+			}
+			return wgsl.Type{Name: s.name}, s.size, s.align, nil
+		}
+
+	case *ast.ArrayType:
+		lit, ok := expr.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return t, 0, 0, g.errorf(expr.Pos(), "array length must be an integer literal")
+		}
+		n, err := strconv.Atoi(lit.Value)
+		if err != nil || n <= 0 {
+			return t, 0, 0, g.errorf(lit.Pos(), "invalid array length %s", lit.Value)
+		}
+		elem, size, align, err := g.goType(file, expr.Elt)
+		if err != nil {
+			return t, 0, 0, err
+		}
+		if n >= 2 && n <= 4 {
+			if isScalar(elem) {
+				return vector(n, elem), n * size, align, nil
+			}
+			if _, isArray := expr.Elt.(*ast.ArrayType); isArray && strings.HasPrefix(elem.Name, "vec") && elem.Args[0].Name == "f32" {
+				return wgsl.Type{Name: fmt.Sprintf("mat%dx%c", n, elem.Name[3]), Args: elem.Args}, n * size, align, nil
+			}
+		}
+		return wgsl.Type{Name: "array", Args: []wgsl.Type{elem, {Name: strconv.Itoa(n)}}}, n * size, align, nil
+
+	case *ast.IndexExpr:
+		sel, ok := expr.X.(*ast.SelectorExpr)
+		if !ok || !importsGLM(file, sel.X) {
+			break
+		}
+		elem, size, align, err := g.goType(file, expr.Index)
+		if err != nil {
+			return t, 0, 0, err
+		}
+		if elem.Name != "f32" {
+			break
+		}
+		switch sel.Sel.Name {
+		case "Vec2":
+			return vector(2, elem), 2 * size, align, nil
+		case "Vec3":
+			return vector(3, elem), 3 * size, align, nil
+		case "Mat4":
+			return wgsl.Type{Name: "mat4x4", Args: []wgsl.Type{elem}}, 16 * size, align, nil
+		}
+	}
+	return t, 0, 0, g.errorf(expr.Pos(), "%s has no WGSL equivalent", g.format(expr))
+}
+
+func importsGLM(file *ast.File, x ast.Expr) bool {
+	ident, ok := x.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := "glm"
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if path == glmPath && name == ident.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) format(expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, g.fset, expr)
+	return buf.String()
+}
+
+func isScalar(t wgsl.Type) bool {
+	return t.Name == "f32" || t.Name == "i32" || t.Name == "u32"
+}
+
+func vector(n int, elem wgsl.Type) wgsl.Type {
+	return wgsl.Type{Name: "vec" + strconv.Itoa(n), Args: []wgsl.Type{elem}}
+}
+
+func matrix(t wgsl.Type) (cols int, rows byte, ok bool) {
+	if len(t.Name) != 6 || !strings.HasPrefix(t.Name, "mat") {
+		return 0, 0, false
+	}
+	return int(t.Name[3] - '0'), t.Name[5], true
+}
+
+// snakeCase converts a Go name like texCoords to tex_coords.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// split before an upper case letter, and before the last one of
+			// an initialism like the P in UVPos
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (prevLower || nextLower) && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// members returns the WGSL members of s, with @size where the Go struct
+// has padding WGSL wouldn't insert.
+func (g *generator) members(m *wgsl.Module, s *goStruct) ([]wgsl.Member, error) {
+	if *space == "vertex" {
+		var members []wgsl.Member
+		for _, f := range s.fields {
+			if cols, rows, ok := matrix(f.typ); ok {
+				column := wgsl.Type{Name: "vec" + string(rows), Args: f.typ.Args}
+				for i := 0; i < cols; i++ {
+					members = append(members, wgsl.Member{Name: f.name + "_" + strconv.Itoa(i), Type: column, Location: f.location + i})
+				}
+				continue
+			}
+			if _, err := wgsl.VertexFormat(f.typ); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.pos, err)
+			}
+			members = append(members, wgsl.Member{Name: f.name, Type: f.typ, Location: f.location})
+		}
+		return members, nil
+	}
+
+	ws := &wgsl.Struct{Name: s.name}
+	m.Structs[s.name] = ws
+	for _, f := range s.fields {
+		ws.Members = append(ws.Members, wgsl.Member{Name: f.name, Type: f.typ, Location: -1})
+		fields, _, err := m.StructLayout(s.name, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.pos, err)
+		}
+		i := len(fields) - 1
+		if fields[i].Offset < f.offset && i > 0 {
+			// Go has padding before the field
+			ws.Members[i-1].Size = f.offset - fields[i-1].Offset
+			fields, _, _ = m.StructLayout(s.name, "")
+		}
+		switch {
+		case fields[i].Offset > f.offset:
+			return nil, fmt.Errorf("%s: %s is at offset %d in Go but %d in WGSL, add %d bytes of padding before it",
+				f.pos, f.name, f.offset, fields[i].Offset, fields[i].Offset-f.offset)
+		case fields[i].Offset < f.offset:
+			return nil, fmt.Errorf("%s: %s is at offset %d in Go but WGSL structs can't start with padding", f.pos, f.name, f.offset)
+		}
+	}
+
+	fields, size, err := m.StructLayout(s.name, *space)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.pos, err)
+	}
+	if size < s.size && len(fields) > 0 {
+		// Go has padding at the end
+		last := len(ws.Members) - 1
+		ws.Members[last].Size = s.size - fields[last].Offset
+		_, size, _ = m.StructLayout(s.name, *space)
+	}
+	if size != s.size {
+		return nil, fmt.Errorf("%s: %s is %d bytes in Go but %d in WGSL, add %d bytes of padding at the end",
+			s.pos, s.name, s.size, size, size-s.size)
+	}
+	return ws.Members, nil
+}
+
+func (g *generator) generate() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"wgslgen %s\"; DO NOT EDIT.\n", strings.Join(os.Args[1:], " "))
+
+	m := &wgsl.Module{Structs: map[string]*wgsl.Struct{}}
+	var errs []error
+	for _, s := range g.structs {
+		members, err := g.members(m, s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Fprintf(&buf, "\nstruct %s {\n", s.name)
+		for _, member := range members {
+			buf.WriteString("    ")
+			if member.Location >= 0 {
+				fmt.Fprintf(&buf, "@location(%d) ", member.Location)
+			}
+			if member.Size > 0 {
+				fmt.Fprintf(&buf, "@size(%d) ", member.Size)
+			}
+			fmt.Fprintf(&buf, "%s: %s,\n", member.Name, member.Type)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), errors.Join(errs...)
+}
+
+// check compares the Go structs with the WGSL structs of the same name in
+// file.
+func (g *generator) check(file string, names []string) error {
+	src, err := wgsl.Process(os.DirFS(filepath.Dir(file)), filepath.Base(file), nil)
+	if err != nil {
+		return err
+	}
+	m, err := wgsl.Reflect(src.Code)
+	if err != nil {
+		return src.MapError(err)
+	}
+
+	var errs []error
+	for _, s := range g.structs {
+		ws, ok := m.Structs[s.name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s has no WGSL struct %s", s.pos, file, s.name))
+			continue
+		}
+		at := src.Offset(ws.Offset)
+
+		if *space == "vertex" {
+			errs = append(errs, g.checkVertex(m, s, ws, at)...)
+			continue
+		}
+
+		fields, size, err := m.StructLayout(s.name, *space)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", at, err))
+			continue
+		}
+		if len(fields) != len(s.fields) {
+			errs = append(errs, fmt.Errorf("%s: %s has %d fields in Go but %d in WGSL", s.pos, s.name, len(s.fields), len(fields)))
+			continue
+		}
+		for i, f := range s.fields {
+			w := fields[i]
+			switch {
+			case !sameName(f.name, w.Name):
+				errs = append(errs, fmt.Errorf("%s: field %d is %s in Go but %s in WGSL", f.pos, i, f.name, w.Name))
+			case f.typ.String() != w.Type.String():
+				errs = append(errs, fmt.Errorf("%s: %s is %s in Go but %s in WGSL", f.pos, f.name, f.typ, w.Type))
+			case f.offset != w.Offset:
+				errs = append(errs, fmt.Errorf("%s: %s is at offset %d in Go but %d in WGSL", f.pos, f.name, f.offset, w.Offset))
+			}
+		}
+		if size != s.size {
+			errs = append(errs, fmt.Errorf("%s: %s is %d bytes in Go but %d in WGSL", s.pos, s.name, s.size, size))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *generator) checkVertex(m *wgsl.Module, s *goStruct, ws *wgsl.Struct, at wgsl.Location) []error {
+	members, err := g.members(m, s)
+	if err != nil {
+		return []error{err}
+	}
+	if len(members) != len(ws.Members) {
+		return []error{fmt.Errorf("%s: %s has %d attributes in Go but %d in WGSL", s.pos, s.name, len(members), len(ws.Members))}
+	}
+	var errs []error
+	for i, member := range members {
+		w := ws.Members[i]
+		if !sameName(member.Name, w.Name) || member.Type.String() != w.Type.String() || member.Location != w.Location {
+			errs = append(errs, fmt.Errorf("%s: %s.%s is @location(%d) %s in Go but @location(%d) %s: %s in WGSL",
+				at, s.name, member.Name, member.Location, member.Type, w.Location, w.Name, w.Type))
+		}
+	}
+	return errs
+}
+
+// sameName compares names ignoring case and underscores, so viewProj
+// matches view_proj.
+func sameName(a, b string) bool {
+	normalize := func(s string) string { return strings.ToLower(strings.ReplaceAll(s, "_", "")) }
+	return normalize(a) == normalize(b)
+}
+
+func roundUp(k, n int) int {
+	return (n + k - 1) / k * k
+}
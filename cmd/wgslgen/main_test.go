@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckExamples runs the -check comparison for the Go structs of the
+// examples that are shared with their shaders.
+func TestCheckExamples(t *testing.T) {
+	for _, test := range []struct {
+		dir   string
+		types string
+		space string
+		file  string
+	}{
+		{dir: "boids", types: "SimParams", space: "uniform", file: "compute.wgsl"},
+		{dir: "dynamic-uniforms", types: "Object", space: "uniform", file: "shader.wgsl"},
+		{dir: "learn-wgpu/beginner/tutorial8-challenge", types: "CameraUniform", space: "uniform", file: "camera_gen.wgsl"},
+		{dir: "learn-wgpu/beginner/tutorial8-challenge", types: "Vertex,InstanceRaw", space: "vertex", file: "vertex_gen.wgsl"},
+	} {
+		setFlags(t, test.space, test.file)
+		dir := filepath.Join("..", "..", filepath.FromSlash(test.dir))
+		if err := run(dir, strings.Split(test.types, ",")); err != nil {
+			t.Errorf("%s -type %s -check %s: %v", test.dir, test.types, test.file, err)
+		}
+	}
+}
+
+// TestCheckMismatch makes sure -check fails for a shader without the
+// struct.
+func TestCheckMismatch(t *testing.T) {
+	setFlags(t, "uniform", "../boids/compute.wgsl")
+	err := run(filepath.Join("..", "..", "dynamic-uniforms"), []string{"Object"})
+	if err == nil || !strings.Contains(err.Error(), "has no WGSL struct Object") {
+		t.Errorf("Object against compute.wgsl: got %v, want a missing struct error", err)
+	}
+}
+
+// TestAbsolutePaths makes sure absolute -check and -o paths aren't joined
+// to the package directory.
+func TestAbsolutePaths(t *testing.T) {
+	dir := filepath.Join("..", "..", "dynamic-uniforms")
+	shader, err := filepath.Abs(filepath.Join(dir, "shader.wgsl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setFlags(t, "uniform", shader)
+	if err := run(dir, []string{"Object"}); err != nil {
+		t.Errorf("-check %s: %v", shader, err)
+	}
+
+	out := filepath.Join(t.TempDir(), "object_gen.wgsl")
+	setFlags(t, "uniform", "")
+	oldOutput := *output
+	t.Cleanup(func() { *output = oldOutput })
+	*output = out
+	if err := run(dir, []string{"Object"}); err != nil {
+		t.Fatalf("-o %s: %v", out, err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Error(err)
+	}
+}
+
+func setFlags(t *testing.T, s, c string) {
+	oldSpace, oldCheck := *space, *check
+	t.Cleanup(func() { *space, *check = oldSpace, oldCheck })
+	*space, *check = s, c
+}
@@ -0,0 +1,344 @@
+// Command wgsllint checks the WGSL shaders embedded in Go packages without
+// a GPU, so mistakes are found before an example opens its window.
+//
+//	go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgsllint ./...
+//
+// For every package it parses the .wgsl files matched by //go:embed, after
+// preprocessing them, and reports:
+//
+//   - syntax errors, in function bodies too,
+//   - calls of functions that are neither builtins nor declared, e.g.
+//     misspelled builtins,
+//   - bindings no entry point uses,
+//   - EntryPoint strings of wgpu.VertexState, wgpu.FragmentState and
+//     wgpu.ProgrammableStageDescriptor literals naming no @vertex,
+//     @fragment or @compute function of the package's shaders.
+//
+// It exits with status 1 when it reports anything.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+const wgpuPath = "github.com/rajveermalviya/go-webgpu/wgpu"
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: wgsllint [packages]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	var dirs []string
+	for _, pattern := range patterns {
+		found, err := expand(pattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		dirs = append(dirs, found...)
+	}
+
+	var diags []string
+	for _, dir := range dirs {
+		found, err := lint(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		diags = append(diags, found...)
+	}
+	for _, d := range diags {
+		fmt.Println(d)
+	}
+	if len(diags) > 0 {
+		os.Exit(1)
+	}
+}
+
+// expand turns a package pattern, a directory optionally ending in /...,
+// into directories.
+func expand(pattern string) ([]string, error) {
+	root, recursive := strings.CutSuffix(pattern, "/...")
+	if pattern == "..." {
+		root, recursive = ".", true
+	}
+	if !recursive {
+		return []string{pattern}, nil
+	}
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs, err
+}
+
+// shader is an embedded WGSL file.
+type shader struct {
+	path   string
+	src    *wgsl.Source
+	module *wgsl.Module
+}
+
+// lint returns the problems found in the package in dir.
+func lint(dir string) ([]string, error) {
+	pkg, err := build.ImportDir(dir, 0)
+	var noGo *build.NoGoError
+	if errors.As(err, &noGo) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := embeddedShaders(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var diags []string
+	seen := map[string]bool{}
+	report := func(format string, args ...any) {
+		d := fmt.Sprintf(format, args...)
+		if !seen[d] {
+			seen[d] = true
+			diags = append(diags, d)
+		}
+	}
+
+	var shaders []*shader
+	failed := false
+	for _, path := range files {
+		s := &shader{path: path}
+		s.src, err = wgsl.Process(os.DirFS(filepath.Dir(path)), filepath.Base(path), nil)
+		if err != nil {
+			report("%s", relative(dir, err))
+			failed = true
+			continue
+		}
+		s.module, err = wgsl.Reflect(s.src.Code)
+		if err != nil {
+			report("%s", relative(dir, s.src.MapError(err)))
+			failed = true
+			continue
+		}
+		shaders = append(shaders, s)
+		for _, err := range s.module.UndefinedFunctions() {
+			report("%s", relative(dir, s.src.MapError(err)))
+		}
+
+		if len(s.module.EntryPoints) == 0 {
+			// only included by other shaders
+			continue
+		}
+		for _, b := range s.module.Bindings {
+			if b.Visibility == wgpu.ShaderStage_None {
+				report("%s: %s (@group(%d) @binding(%d)) isn't used by any entry point",
+					located(dir, s.src.Offset(b.Offset)), b.Name, b.Group, b.Binding)
+			}
+		}
+	}
+
+	if failed {
+		// the entry points of the broken shaders are unknown
+		sort.Strings(diags)
+		return diags, nil
+	}
+	entryPoints, err := goEntryPoints(pkg)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entryPoints {
+		if !defined(shaders, e.name, e.stage) {
+			report("%s: %s entry point %q isn't defined by the shaders of the package%s",
+				e.pos, strings.ToLower(e.stage.String()), e.name, candidates(shaders, e.stage))
+		}
+	}
+
+	sort.Strings(diags)
+	return diags, nil
+}
+
+// embeddedShaders returns the .wgsl files matched by the //go:embed
+// patterns of pkg.
+func embeddedShaders(pkg *build.Package) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	for _, pattern := range pkg.EmbedPatterns {
+		matches, err := filepath.Glob(filepath.Join(pkg.Dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if strings.HasSuffix(m, ".wgsl") && !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+type entryPoint struct {
+	name  string
+	stage wgpu.ShaderStage
+	pos   token.Position
+}
+
+var stages = map[string]wgpu.ShaderStage{
+	"VertexState":                 wgpu.ShaderStage_Vertex,
+	"FragmentState":               wgpu.ShaderStage_Fragment,
+	"ProgrammableStageDescriptor": wgpu.ShaderStage_Compute,
+}
+
+// goEntryPoints returns the EntryPoint strings of the wgpu stage literals
+// in the Go files of pkg.
+func goEntryPoints(pkg *build.Package) ([]entryPoint, error) {
+	fset := token.NewFileSet()
+	var entryPoints []entryPoint
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		wgpuName := importName(f, wgpuPath)
+		if wgpuName == "" {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			sel, ok := lit.Type.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != wgpuName {
+				return true
+			}
+			stage, ok := stages[sel.Sel.Name]
+			if !ok {
+				return true
+			}
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				key, ok := kv.Key.(*ast.Ident)
+				value, isLit := kv.Value.(*ast.BasicLit)
+				if !ok || key.Name != "EntryPoint" || !isLit || value.Kind != token.STRING {
+					continue
+				}
+				name, err := strconv.Unquote(value.Value)
+				if err != nil {
+					continue
+				}
+				entryPoints = append(entryPoints, entryPoint{
+					name:  name,
+					stage: stage,
+					pos:   fset.Position(value.Pos()),
+				})
+			}
+			return true
+		})
+	}
+	return entryPoints, nil
+}
+
+func importName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return filepath.Base(path)
+	}
+	return ""
+}
+
+func defined(shaders []*shader, name string, stage wgpu.ShaderStage) bool {
+	for _, s := range shaders {
+		if e := s.module.EntryPoint(name); e != nil && e.Stage == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates lists the entry points of stage, as a hint for typos.
+func candidates(shaders []*shader, stage wgpu.ShaderStage) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, s := range shaders {
+		for _, e := range s.module.EntryPoints {
+			if e.Stage == stage && !seen[e.Name] {
+				seen[e.Name] = true
+				names = append(names, e.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return " (" + strings.ToLower(stage.String()) + " entry points: " + strings.Join(names, ", ") + ")"
+}
+
+// located formats a location in a shader of dir as a path relative to the
+// working directory.
+func located(dir string, loc wgsl.Location) string {
+	return wgsl.Location{File: filepath.Join(dir, loc.File), Line: loc.Line}.String()
+}
+
+// relative prefixes the file of preprocessing and compile errors with dir.
+func relative(dir string, err error) string {
+	var compileErr *wgsl.CompileError
+	if errors.As(err, &compileErr) {
+		return located(dir, compileErr.Location) + ": " + compileErr.Msg
+	}
+	var preprocessErr *wgsl.Error
+	if errors.As(err, &preprocessErr) {
+		return located(dir, preprocessErr.Location) + ": " + preprocessErr.Msg
+	}
+	return err.Error()
+}
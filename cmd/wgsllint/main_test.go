@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestModule lints every package of the module, the shaders of the
+// examples must stay clean.
+func TestModule(t *testing.T) {
+	dirs, err := expand(filepath.Join("..", "..") + "/...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	linted := 0
+	for _, dir := range dirs {
+		diags, err := lint(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", dir, err)
+		}
+		for _, d := range diags {
+			t.Error(d)
+		}
+		linted++
+	}
+	if linted < 10 {
+		t.Errorf("linted only %d packages", linted)
+	}
+}
+
+func TestLint(t *testing.T) {
+	for _, test := range []struct {
+		dir  string
+		want []string
+	}{
+		{
+			dir:  "syntax",
+			want: []string{`testdata/syntax/shader.wgsl:2: expected ":", found "mat4x4"`},
+		},
+		{
+			dir: "body",
+			want: []string{
+				"testdata/body/builtin.wgsl:3: call of undefined function normalise",
+				`testdata/body/expression.wgsl:3: expected an expression, found ";"`,
+			},
+		},
+		{
+			dir:  "unused",
+			want: []string{"testdata/unused/shader.wgsl:4: s_diffuse (@group(0) @binding(1)) isn't used by any entry point"},
+		},
+		{
+			dir: "entrypoint",
+			want: []string{`testdata/entrypoint/main.go:17:14: fragment entry point "fs_mian" isn't defined by the shaders of the package` +
+				" (fragment entry points: fs_main)"},
+		},
+	} {
+		diags, err := lint(filepath.Join("testdata", test.dir))
+		if err != nil {
+			t.Errorf("%s: %v", test.dir, err)
+			continue
+		}
+		if len(diags) != len(test.want) {
+			t.Errorf("%s: got %q, want %q", test.dir, diags, test.want)
+			continue
+		}
+		for i := range diags {
+			if filepath.ToSlash(diags[i]) != test.want[i] {
+				t.Errorf("%s: got %q, want %q", test.dir, diags[i], test.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+@fragment
+fn fs_main(@location(0) normal: vec3<f32>) -> @location(0) vec4<f32> {
+    return vec4<f32>(normalise(normal), 1.0);
+}
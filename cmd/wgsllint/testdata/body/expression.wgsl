@@ -0,0 +1,5 @@
+@vertex
+fn vs_main(@location(0) position: vec3<f32>) -> @builtin(position) vec4<f32> {
+    let scaled = position * ;
+    return vec4<f32>(scaled, 1.0);
+}
@@ -0,0 +1,6 @@
+package main
+
+import "embed"
+
+//go:embed *.wgsl
+var shaders embed.FS
@@ -0,0 +1,18 @@
+package main
+
+import (
+	_ "embed"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+//go:embed shader.wgsl
+var shader string
+
+var vertex = wgpu.VertexState{
+	EntryPoint: "vs_main",
+}
+
+var fragment = wgpu.FragmentState{
+	EntryPoint: "fs_mian",
+}
@@ -0,0 +1,11 @@
+struct Camera {
+    view_proj mat4x4<f32>,
+}
+
+@group(0) @binding(0)
+var<uniform> camera: Camera;
+
+@vertex
+fn vs_main() -> @builtin(position) vec4<f32> {
+    return camera.view_proj[0];
+}
@@ -0,0 +1,6 @@
+package main
+
+import _ "embed"
+
+//go:embed shader.wgsl
+var shader string
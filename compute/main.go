@@ -1,33 +1,25 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
-	"unsafe"
+	"time"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/config"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/kernel"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgpulog"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 
 	_ "embed"
 )
 
-var forceFallbackAdapter = os.Getenv("WGPU_FORCE_FALLBACK_ADAPTER") == "1"
+var cfg = config.FromEnv()
 
 func init() {
-	switch os.Getenv("WGPU_LOG_LEVEL") {
-	case "OFF":
-		wgpu.SetLogLevel(wgpu.LogLevel_Off)
-	case "ERROR":
-		wgpu.SetLogLevel(wgpu.LogLevel_Error)
-	case "WARN":
-		wgpu.SetLogLevel(wgpu.LogLevel_Warn)
-	case "INFO":
-		wgpu.SetLogLevel(wgpu.LogLevel_Info)
-	case "DEBUG":
-		wgpu.SetLogLevel(wgpu.LogLevel_Debug)
-	case "TRACE":
-		wgpu.SetLogLevel(wgpu.LogLevel_Trace)
-	}
+	cfg.RegisterFlags(flag.CommandLine)
 }
 
 //go:embed shader.wgsl
@@ -37,14 +29,41 @@ var shader string
 const OVERFLOW = 0xffffffff
 
 func main() {
+	flag.Parse()
 	numbers := []uint32{1, 2, 3, 4}
+	if flag.NArg() > 0 {
+		numbers = numbers[:0]
+		for _, arg := range flag.Args() {
+			n, err := strconv.ParseUint(arg, 10, 32)
+			if err != nil {
+				panic(err)
+			}
+			numbers = append(numbers, uint32(n))
+		}
+	}
+	cfg.Apply()
+	defer wgpulog.Flush()
+	if cfg.ListAdapters {
+		if err := cfg.PrintAdapters(os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if err := cfg.SingleSample(); err != nil {
+		panic(err)
+	}
+
+	tracer := cfg.NewTracer()
+	defer func() {
+		if err := cfg.WriteTrace(tracer); err != nil {
+			panic(err)
+		}
+	}()
 
-	instance := wgpu.CreateInstance(nil)
+	instance := wgpu.CreateInstance(cfg.InstanceDescriptor())
 	defer instance.Drop()
 
-	adapter, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		ForceFallbackAdapter: forceFallbackAdapter,
-	})
+	adapter, err := instance.RequestAdapter(cfg.RequestAdapterOptions(nil))
 	if err != nil {
 		panic(err)
 	}
@@ -55,105 +74,15 @@ func main() {
 		panic(err)
 	}
 	defer device.Drop()
-	queue := device.GetQueue()
 
-	shaderModule, err := device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: shader,
-		},
-	})
-	if err != nil {
-		panic(err)
-	}
-	defer shaderModule.Drop()
-
-	size := uint64(len(numbers)) * uint64(unsafe.Sizeof(uint32(0)))
+	// the GPU gets ten seconds before the readback gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	stagingBuffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
-		Size:             size,
-		Usage:            wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
-		MappedAtCreation: false,
-	})
+	steps, err := kernel.Run[uint32, uint32](ctx, device, shader, "main", numbers, &kernel.Options{Trace: tracer})
 	if err != nil {
 		panic(err)
 	}
-	defer stagingBuffer.Drop()
-
-	storageBuffer, err := device.CreateBufferInit(&wgpu.BufferInitDescriptor{
-		Label:    "Storage Buffer",
-		Contents: wgpu.ToBytes(numbers),
-		Usage: wgpu.BufferUsage_Storage |
-			wgpu.BufferUsage_CopyDst |
-			wgpu.BufferUsage_CopySrc,
-	})
-	if err != nil {
-		panic(err)
-	}
-	defer storageBuffer.Drop()
-
-	computePipeline, err := device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
-		Compute: wgpu.ProgrammableStageDescriptor{
-			Module:     shaderModule,
-			EntryPoint: "main",
-		},
-	})
-	if err != nil {
-		panic(err)
-	}
-	defer computePipeline.Drop()
-
-	bindGroupLayout := computePipeline.GetBindGroupLayout(0)
-	defer bindGroupLayout.Drop()
-
-	bindGroup, err := device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Layout: bindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{{
-			Binding: 0,
-			Buffer:  storageBuffer,
-			Size:    wgpu.WholeSize,
-		}},
-	})
-	if err != nil {
-		panic(err)
-	}
-	defer bindGroup.Drop()
-
-	encoder, err := device.CreateCommandEncoder(nil)
-	if err != nil {
-		panic(err)
-	}
-
-	computePass := encoder.BeginComputePass(nil)
-	computePass.SetPipeline(computePipeline)
-	computePass.SetBindGroup(0, bindGroup, nil)
-	computePass.DispatchWorkgroups(uint32(len(numbers)), 1, 1)
-	computePass.End()
-
-	encoder.CopyBufferToBuffer(storageBuffer, 0, stagingBuffer, 0, size)
-
-	queue.Submit(encoder.Finish(nil))
-
-	var status wgpu.BufferMapAsyncStatus
-	stagingBuffer.MapAsync(wgpu.MapMode_Read, 0, size, func(s wgpu.BufferMapAsyncStatus) {
-		status = s
-	})
-
-	device.Poll(true, nil)
-
-	if status != wgpu.BufferMapAsyncStatus_Success {
-		panic(status)
-	}
-
-	steps := make([]uint32, len(numbers))
-	{
-		data := stagingBuffer.GetMappedRange(0, uint(size))
-
-		copy(steps, wgpu.FromBytes[uint32](data))
-
-		data = nil
-		stagingBuffer.Unmap()
-	}
 
 	dispSteps := mapSlice(steps, func(e uint32) string {
 		if e == OVERFLOW {
@@ -1,6 +1,10 @@
 @group(0)
 @binding(0)
-var<storage, read_write> v_indices: array<u32>; // this is used as both input and output for convenience
+var<storage, read> numbers: array<u32>;
+
+@group(0)
+@binding(1)
+var<storage, read_write> steps: array<u32>;
 
 // The Collatz Conjecture states that for any integer n:
 // If n is even, n = n/2
@@ -32,7 +36,11 @@ fn collatz_iterations(n_base: u32) -> u32 {
 }
 
 @compute
-@workgroup_size(1)
+@workgroup_size(64)
 fn main(@builtin(global_invocation_id) global_id: vec3<u32>) {
-    v_indices[global_id.x] = collatz_iterations(v_indices[global_id.x]);
+    // the last workgroup runs past the end of the numbers
+    if (global_id.x >= arrayLength(&numbers)) {
+        return;
+    }
+    steps[global_id.x] = collatz_iterations(numbers[global_id.x]);
 }
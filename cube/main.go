@@ -1,41 +1,20 @@
 package main
 
 import (
-	"fmt"
 	"math"
-	"os"
-	"runtime"
-	"strings"
+	"time"
 	"unsafe"
 
-	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/uniform"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 
 	_ "embed"
 )
 
-var forceFallbackAdapter = os.Getenv("WGPU_FORCE_FALLBACK_ADAPTER") == "1"
-
-func init() {
-	runtime.LockOSThread()
-
-	switch os.Getenv("WGPU_LOG_LEVEL") {
-	case "OFF":
-		wgpu.SetLogLevel(wgpu.LogLevel_Off)
-	case "ERROR":
-		wgpu.SetLogLevel(wgpu.LogLevel_Error)
-	case "WARN":
-		wgpu.SetLogLevel(wgpu.LogLevel_Warn)
-	case "INFO":
-		wgpu.SetLogLevel(wgpu.LogLevel_Info)
-	case "DEBUG":
-		wgpu.SetLogLevel(wgpu.LogLevel_Debug)
-	case "TRACE":
-		wgpu.SetLogLevel(wgpu.LogLevel_Trace)
-	}
-}
-
 type Vertex struct {
 	pos      [4]float32
 	texCoord [2]float32
@@ -141,224 +120,161 @@ func generateMatrix(aspectRatio float32) glm.Mat4[float32] {
 var shader string
 
 type State struct {
-	surface    *wgpu.Surface
-	swapChain  *wgpu.SwapChain
-	device     *wgpu.Device
-	queue      *wgpu.Queue
-	config     *wgpu.SwapChainDescriptor
-	vertexBuf  *wgpu.Buffer
-	indexBuf   *wgpu.Buffer
-	uniformBuf *wgpu.Buffer
-	pipeline   *wgpu.RenderPipeline
-	bindGroup  *wgpu.BindGroup
+	resources  *resource.Registry
+	vertexBuf  *resource.Handle[*wgpu.Buffer]
+	indexBuf   *resource.Handle[*wgpu.Buffer]
+	uniformBuf *uniform.Buffer[glm.Mat4[float32]]
+	texture    *resource.Handle[*wgpu.Texture]
+	view       *resource.Handle[*wgpu.TextureView]
+	shader     *resource.Handle[*wgpu.ShaderModule]
+	pipeline   *resource.Handle[*wgpu.RenderPipeline]
+	bindGroup  *resource.Handle[*wgpu.BindGroup]
 }
 
-func InitState(window *glfw.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
-
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adapter, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		ForceFallbackAdapter: forceFallbackAdapter,
-		CompatibleSurface:    s.surface,
-	})
-	if err != nil {
-		return s, err
-	}
-	defer adapter.Drop()
-
-	s.device, err = adapter.RequestDevice(nil)
-	if err != nil {
-		return s, err
-	}
-	s.queue = s.device.GetQueue()
-
-	width, height := window.GetSize()
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adapter),
-		Width:       uint32(width),
-		Height:      uint32(height),
-		PresentMode: wgpu.PresentMode_Fifo,
-	}
-
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-	if err != nil {
-		return s, err
-	}
+// Init creates everything through ctx.Resources so that the cube survives a
+// device loss, and its shader can be reloaded with -shader-dir cube. The
+// objects are dropped again by Destroy.
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.resources = ctx.Resources
 
-	s.vertexBuf, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.vertexBuf, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
 		Contents: wgpu.ToBytes(vertexData[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.indexBuf, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.indexBuf, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Index Buffer",
 		Contents: wgpu.ToBytes(indexData[:]),
 		Usage:    wgpu.BufferUsage_Index,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	texels := createTexels()
-	textureExtent := wgpu.Extent3D{
-		Width:              texelsSize,
-		Height:             texelsSize,
-		DepthOrArrayLayers: 1,
-	}
-	texture, err := s.device.CreateTexture(&wgpu.TextureDescriptor{
-		Size:          textureExtent,
-		MipLevelCount: 1,
-		SampleCount:   1,
-		Dimension:     wgpu.TextureDimension_2D,
-		Format:        wgpu.TextureFormat_R8Uint,
-		Usage:         wgpu.TextureUsage_TextureBinding | wgpu.TextureUsage_CopyDst,
-	})
-	if err != nil {
-		return s, err
-	}
-	defer texture.Drop()
-
-	textureView := texture.CreateView(nil)
-	defer textureView.Drop()
-
-	s.queue.WriteTexture(
-		texture.AsImageCopy(),
+	s.texture, err = s.resources.CreateTextureInit(
+		&wgpu.TextureDescriptor{
+			Size: wgpu.Extent3D{
+				Width:              texelsSize,
+				Height:             texelsSize,
+				DepthOrArrayLayers: 1,
+			},
+			MipLevelCount: 1,
+			SampleCount:   1,
+			Dimension:     wgpu.TextureDimension_2D,
+			Format:        wgpu.TextureFormat_R8Uint,
+			Usage:         wgpu.TextureUsage_TextureBinding | wgpu.TextureUsage_CopyDst,
+		},
 		wgpu.ToBytes(texels[:]),
-		&wgpu.TextureDataLayout{
+		wgpu.TextureDataLayout{
 			Offset:       0,
 			BytesPerRow:  texelsSize,
 			RowsPerImage: wgpu.CopyStrideUndefined,
 		},
-		&textureExtent,
 	)
+	if err != nil {
+		return err
+	}
 
-	mxTotal := generateMatrix(float32(s.config.Width) / float32(s.config.Height))
-	s.uniformBuf, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
-		Label:    "Uniform Buffer",
-		Contents: wgpu.ToBytes(mxTotal[:]),
-		Usage:    wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
+	s.view, err = resource.Track(s.resources, func(*wgpu.Device, *wgpu.Queue) (*wgpu.TextureView, error) {
+		return s.texture.Get().CreateView(nil), nil
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label:          "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: shader},
-	})
+	mxTotal := generateMatrix(float32(ctx.Config.Width) / float32(ctx.Config.Height))
+	s.uniformBuf, err = uniform.New(s.resources, "Uniform Buffer", mxTotal)
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer shader.Drop()
 
-	s.pipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-			Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout},
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{
-				{
-					Format:    s.config.Format,
-					Blend:     nil,
-					WriteMask: wgpu.ColorWriteMask_All,
+	s.shader, err = ctx.Shaders.ShaderModule("shader.wgsl", shader)
+	if err != nil {
+		return err
+	}
+
+	format := ctx.Config.Format
+	s.pipeline, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Vertex: wgpu.VertexState{
+				Module:     s.shader.Get(),
+				EntryPoint: "vs_main",
+				Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout},
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     s.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{
+					{
+						Format:    format,
+						Blend:     nil,
+						WriteMask: wgpu.ColorWriteMask_All,
+					},
 				},
 			},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		DepthStencil: nil,
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_Back,
+			},
+			DepthStencil: nil,
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	bindGroupLayout := s.pipeline.GetBindGroupLayout(0)
-	defer bindGroupLayout.Drop()
+	s.bindGroup, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		bindGroupLayout := s.pipeline.Get().GetBindGroupLayout(0)
+		defer leak.Track(s.resources.Leaks, bindGroupLayout).Drop()
 
-	s.bindGroup, err = s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Layout: bindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding: 0,
-				Buffer:  s.uniformBuf,
-				Size:    wgpu.WholeSize,
-			},
-			{
-				Binding:     1,
-				TextureView: textureView,
-				Size:        wgpu.WholeSize,
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout: bindGroupLayout,
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding: 0,
+					Buffer:  s.uniformBuf.Buffer(),
+					Size:    wgpu.WholeSize,
+				},
+				{
+					Binding:     1,
+					TextureView: s.view.Get(),
+					Size:        wgpu.WholeSize,
+				},
 			},
-		},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	return s, nil
+	return nil
 }
 
-func (s *State) Resize(width, height int) {
-	if width > 0 && height > 0 {
-		s.config.Width = uint32(width)
-		s.config.Height = uint32(height)
+func (s *State) Update(dt time.Duration) {}
 
-		mxTotal := generateMatrix(float32(width) / float32(height))
-		s.queue.WriteBuffer(s.uniformBuf, 0, wgpu.ToBytes(mxTotal[:]))
-
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-	}
+// Resize is also called after a device loss, Upload then writes the whole
+// matrix to the recreated uniform buffer.
+func (s *State) Resize(width, height uint32) {
+	s.uniformBuf.Set(generateMatrix(float32(width) / float32(height)))
+	s.uniformBuf.Upload(s.resources.Queue())
 }
 
-func (s *State) Render() error {
-	nextTexture, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer nextTexture.Drop()
-
-	encoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
-	}
-
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{
 			{
-				View:       nextTexture,
+				View:       view,
 				LoadOp:     wgpu.LoadOp_Clear,
 				StoreOp:    wgpu.StoreOp_Store,
 				ClearValue: wgpu.Color{R: 0.1, G: 0.2, B: 0.3, A: 1.0},
@@ -366,100 +282,27 @@ func (s *State) Render() error {
 		},
 	})
 
-	renderPass.SetPipeline(s.pipeline)
-	renderPass.SetBindGroup(0, s.bindGroup, nil)
-	renderPass.SetIndexBuffer(s.indexBuf, wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
-	renderPass.SetVertexBuffer(0, s.vertexBuf, 0, wgpu.WholeSize)
+	renderPass.SetPipeline(s.pipeline.Get())
+	renderPass.SetBindGroup(0, s.bindGroup.Get(), nil)
+	renderPass.SetIndexBuffer(s.indexBuf.Get(), wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
+	renderPass.SetVertexBuffer(0, s.vertexBuf.Get(), 0, wgpu.WholeSize)
 	renderPass.DrawIndexed(uint32(len(indexData)), 1, 0, 0, 0)
 	renderPass.End()
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
 func (s *State) Destroy() {
-	if s.bindGroup != nil {
-		s.bindGroup.Drop()
-		s.bindGroup = nil
-	}
-	if s.pipeline != nil {
-		s.pipeline.Drop()
-		s.pipeline = nil
-	}
-	if s.uniformBuf != nil {
-		s.uniformBuf.Drop()
-		s.uniformBuf = nil
-	}
-	if s.indexBuf != nil {
-		s.indexBuf.Drop()
-		s.indexBuf = nil
-	}
-	if s.vertexBuf != nil {
-		s.vertexBuf.Drop()
-		s.vertexBuf = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
+	s.bindGroup.Drop()
+	s.pipeline.Drop()
+	s.shader.Drop()
+	s.view.Drop()
+	s.texture.Drop()
+	s.uniformBuf.Drop()
+	s.indexBuf.Drop()
+	s.vertexBuf.Drop()
 }
 
 func main() {
-	if err := glfw.Init(); err != nil {
+	if err := framework.Run(&State{}, framework.Options{}); err != nil {
 		panic(err)
 	}
-	defer glfw.Terminate()
-
-	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
-	window, err := glfw.CreateWindow(640, 480, "go-webgpu with glfw", nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	defer window.Destroy()
-
-	s, err := InitState(window)
-	if err != nil {
-		panic(err)
-	}
-	defer s.Destroy()
-
-	window.SetSizeCallback(func(w *glfw.Window, width, height int) {
-		s.Resize(width, height)
-	})
-
-	for !window.ShouldClose() {
-		glfw.PollEvents()
-
-		err := s.Render()
-		if err != nil {
-			fmt.Println(err)
-
-			errstr := err.Error()
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(window.GetSize())
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(window.GetSize())
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }
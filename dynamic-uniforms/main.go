@@ -0,0 +1,273 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/uniform"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+
+	_ "embed"
+)
+
+const (
+	columns = 32
+	rows    = 24
+	objects = columns * rows
+	// framesInFlight is how many frames the ring holds before Alloc waits
+	// for the oldest one.
+	framesInFlight = 3
+)
+
+//go:embed shader.wgsl
+var shader string
+
+// Object is the per-draw uniform, each draw binds its own copy through a
+// dynamic offset into the ring buffer.
+//
+//go:generate go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgslgen -type Object -space uniform -check shader.wgsl
+type Object struct {
+	Transform glm.Mat4[float32]
+	Color     [4]float32
+}
+
+type State struct {
+	ctx        *framework.Context
+	ring       *uniform.Ring
+	ringBuf    *resource.Handle[*wgpu.Buffer]
+	layout     *resource.Handle[*wgpu.BindGroupLayout]
+	bindGroup  *resource.Handle[*wgpu.BindGroup]
+	shader     *resource.Handle[*wgpu.ShaderModule]
+	pipeline   *resource.Handle[*wgpu.RenderPipeline]
+	aspect     float32
+	elapsed    float64
+	objectData [objects]Object
+	// ringFull is set once the skipped draws were reported
+	ringFull bool
+}
+
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.ctx = ctx
+	s.aspect = float32(ctx.Config.Width) / float32(ctx.Config.Height)
+
+	// every object takes a whole aligned slot, usually 256 bytes
+	align := uniform.RingAlignment(ctx.Device)
+	slot := (uniform.Size[Object]() + align - 1) / align * align
+	s.ring, err = uniform.NewRing(framesInFlight*objects*slot, align)
+	if err != nil {
+		return err
+	}
+	s.ring.Wait = func(index wgpu.SubmissionIndex) {
+		s.ctx.Device.Poll(true, &wgpu.WrappedSubmissionIndex{
+			Queue:           s.ctx.Queue,
+			SubmissionIndex: index,
+		})
+	}
+
+	s.ringBuf, err = ctx.Resources.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Uniform Ring",
+		Size:  s.ring.Size(),
+		Usage: wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the layout is derived from the shader, only the dynamic offset can't
+	// be seen there
+	shaderModule, err := wgsl.Reflect(shader)
+	if err != nil {
+		return err
+	}
+	layoutEntries, err := shaderModule.BindGroupLayoutEntries(0)
+	if err != nil {
+		return err
+	}
+	layoutEntries[0].Buffer.HasDynamicOffset = true
+	layoutEntries[0].Buffer.MinBindingSize = uniform.Size[Object]()
+
+	s.layout, err = ctx.Resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Label:   "Object Bind Group Layout",
+		Entries: layoutEntries,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.bindGroup, err = resource.Track(ctx.Resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Label:  "Object Bind Group",
+			Layout: s.layout.Get(),
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding: 0,
+					Buffer:  s.ringBuf.Get(),
+					// the size seen by one draw, the offset picks the object
+					Size: uniform.Size[Object](),
+				},
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.shader, err = ctx.Shaders.ShaderModule("shader.wgsl", shader)
+	if err != nil {
+		return err
+	}
+
+	format := ctx.Config.Format
+	s.pipeline, err = resource.Track(ctx.Resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		pipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
+			Label:            "Render Pipeline Layout",
+			BindGroupLayouts: []*wgpu.BindGroupLayout{s.layout.Get()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer leak.Track(ctx.Resources.Leaks, pipelineLayout).Drop()
+
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label:  "Render Pipeline",
+			Layout: pipelineLayout,
+			Vertex: wgpu.VertexState{
+				Module:     s.shader.Get(),
+				EntryPoint: "vs_main",
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     s.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{
+					{
+						Format:    format,
+						Blend:     &wgpu.BlendState_Replace,
+						WriteMask: wgpu.ColorWriteMask_All,
+					},
+				},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_None,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
+	})
+	return err
+}
+
+// Update gives every object its own position, rotation, scale and color.
+func (s *State) Update(dt time.Duration) {
+	s.elapsed += dt.Seconds()
+
+	aspect := glm.Mat4[float32]{
+		1 / s.aspect, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+	cell := float32(2) / rows
+	for i := range s.objectData {
+		x, y := i%columns, i/columns
+		phase := s.elapsed + float64(x+y)*0.15
+		scale := cell * (0.45 + 0.25*float32(math.Sin(phase)))
+
+		translation := glm.Mat4FromTranslation(glm.Vec3[float32]{
+			(float32(x) - (columns-1)/2.0) * cell,
+			(float32(y) - (rows-1)/2.0) * cell,
+			0,
+		})
+		rotation := glm.Mat4FromAngleZ(float32(phase * float64(1+i%3)))
+		scaling := glm.Mat4[float32]{
+			scale, 0, 0, 0,
+			0, scale, 0, 0,
+			0, 0, 1, 0,
+			0, 0, 0, 1,
+		}
+
+		hue := float32(x)/columns*360 + float32(y)*4
+		color := glm.ColorFromHSV(float32(math.Mod(float64(hue), 360)), 0.7, 0.9, 1).ToLinear()
+		s.objectData[i] = Object{
+			Transform: aspect.Mul4(translation).Mul4(rotation).Mul4(scaling),
+			Color:     [4]float32{color.R, color.G, color.B, color.A},
+		}
+	}
+}
+
+func (s *State) Resize(width, height uint32) {
+	s.aspect = float32(width) / float32(height)
+}
+
+// DeviceRecovered forgets the ring's frames, their fences belong to the old
+// queue.
+func (s *State) DeviceRecovered() {
+	s.ring.Reset()
+}
+
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
+	offsets := make([]uint32, 0, objects)
+	for i, object := range s.objectData {
+		offset, err := uniform.Push(s.ring, object)
+		if err != nil {
+			// the ring is too small for the frame, draw the objects that fit
+			if !s.ringFull {
+				slog.Warn("skipping draws", "objects", objects-i, "err", err)
+				s.ringFull = true
+			}
+			break
+		}
+		offsets = append(offsets, offset)
+	}
+	s.ring.Flush(s.ctx.Queue, s.ringBuf.Get())
+
+	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
+		ColorAttachments: []wgpu.RenderPassColorAttachment{
+			{
+				View:       view,
+				LoadOp:     wgpu.LoadOp_Clear,
+				StoreOp:    wgpu.StoreOp_Store,
+				ClearValue: wgpu.Color{R: 0.02, G: 0.02, B: 0.03, A: 1.0},
+			},
+		},
+	})
+
+	renderPass.SetPipeline(s.pipeline.Get())
+	for _, offset := range offsets {
+		renderPass.SetBindGroup(0, s.bindGroup.Get(), []uint32{offset})
+		renderPass.Draw(6, 1, 0, 0)
+	}
+	renderPass.End()
+}
+
+// Submitted fences the frame's objects, the ring reuses their space once
+// the GPU is done with them.
+func (s *State) Submitted(index wgpu.SubmissionIndex) {
+	s.ring.Fence(index)
+}
+
+func (s *State) Destroy() {
+	s.pipeline.Drop()
+	s.shader.Drop()
+	s.bindGroup.Drop()
+	s.layout.Drop()
+	s.ringBuf.Drop()
+}
+
+func main() {
+	if err := framework.Run(&State{}, framework.Options{
+		Title: "dynamic-uniforms",
+	}); err != nil {
+		panic(err)
+	}
+}
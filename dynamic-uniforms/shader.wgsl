@@ -0,0 +1,37 @@
+struct Object {
+    transform: mat4x4<f32>,
+    color: vec4<f32>,
+};
+
+// bound with a dynamic offset, every draw sees its own Object
+@group(0)
+@binding(0)
+var<uniform> object: Object;
+
+struct VertexOutput {
+    @builtin(position) position: vec4<f32>,
+    @location(0) color: vec4<f32>,
+};
+
+@vertex
+fn vs_main(@builtin(vertex_index) index: u32) -> VertexOutput {
+    // two triangles covering the unit square around the origin
+    var corners = array<vec2<f32>, 6>(
+        vec2<f32>(-0.5, -0.5),
+        vec2<f32>(0.5, -0.5),
+        vec2<f32>(0.5, 0.5),
+        vec2<f32>(0.5, 0.5),
+        vec2<f32>(-0.5, 0.5),
+        vec2<f32>(-0.5, -0.5),
+    );
+
+    var result: VertexOutput;
+    result.position = object.transform * vec4<f32>(corners[index], 0.0, 1.0);
+    result.color = object.color;
+    return result;
+}
+
+@fragment
+fn fs_main(vertex: VertexOutput) -> @location(0) vec4<f32> {
+    return vertex.color;
+}
@@ -1,6 +1,6 @@
 module github.com/rajveermalviya/go-webgpu-examples
 
-go 1.20
+go 1.21
 
 require (
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20221017161538-93cebf72946b
@@ -0,0 +1,151 @@
+// Package capture reads rendered textures back to the CPU and saves them as
+// PNG images.
+package capture
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"unsafe"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/readback"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/trace"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Format is the texture format Reader expects, its bytes map directly onto
+// image.NRGBA.
+const Format = wgpu.TextureFormat_RGBA8UnormSrgb
+
+type BufferDimensions struct {
+	Width               uint64
+	Height              uint64
+	UnpaddedBytesPerRow uint64
+	PaddedBytesPerRow   uint64
+}
+
+// NewBufferDimensions pads each row to wgpu.CopyBytesPerRowAlignment as
+// required by CommandEncoder.CopyTextureToBuffer.
+func NewBufferDimensions(width uint64, height uint64) BufferDimensions {
+	const bytesPerPixel = unsafe.Sizeof(uint32(0))
+	unpaddedBytesPerRow := width * uint64(bytesPerPixel)
+	align := uint64(wgpu.CopyBytesPerRowAlignment)
+	paddedBytesPerRowPadding := (align - unpaddedBytesPerRow%align) % align
+	paddedBytesPerRow := unpaddedBytesPerRow + uint64(paddedBytesPerRowPadding)
+	return BufferDimensions{
+		width,
+		height,
+		unpaddedBytesPerRow,
+		paddedBytesPerRow,
+	}
+}
+
+func (d BufferDimensions) Size() uint64 {
+	return d.PaddedBytesPerRow * d.Height
+}
+
+// Reader owns a mappable buffer that textures of one size are copied into.
+type Reader struct {
+	// Trace, if set, records how long Read waits for the buffer mapping.
+	Trace *trace.Tracer
+
+	device     *wgpu.Device
+	queue      *wgpu.Queue
+	dimensions BufferDimensions
+	buffer     *wgpu.Buffer
+}
+
+func NewReader(device *wgpu.Device, width, height uint32) (*Reader, error) {
+	dimensions := NewBufferDimensions(uint64(width), uint64(height))
+
+	// The output buffer lets us retrieve the data as an array
+	buffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Capture Buffer",
+		Size:  dimensions.Size(),
+		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		device:     device,
+		queue:      device.GetQueue(),
+		dimensions: dimensions,
+		buffer:     buffer,
+	}, nil
+}
+
+func (r *Reader) Dimensions() BufferDimensions {
+	return r.dimensions
+}
+
+// Copy records a copy of texture into the Reader's buffer, texture must
+// have been created with wgpu.TextureUsage_CopySrc.
+func (r *Reader) Copy(encoder *wgpu.CommandEncoder, texture *wgpu.Texture) {
+	encoder.CopyTextureToBuffer(
+		texture.AsImageCopy(),
+		&wgpu.ImageCopyBuffer{
+			Buffer: r.buffer,
+			Layout: wgpu.TextureDataLayout{
+				Offset:       0,
+				BytesPerRow:  uint32(r.dimensions.PaddedBytesPerRow),
+				RowsPerImage: wgpu.CopyStrideUndefined,
+			},
+		},
+		&wgpu.Extent3D{
+			Width:              uint32(r.dimensions.Width),
+			Height:             uint32(r.dimensions.Height),
+			DepthOrArrayLayers: 1,
+		},
+	)
+}
+
+// Read waits for the submission containing Copy and returns a copy of the
+// buffer's contents with the row padding removed.
+func (r *Reader) Read(index wgpu.SubmissionIndex) (*image.NRGBA, error) {
+	span := r.Trace.Begin("MapAsync wait")
+	ctx, cancel := context.WithTimeout(context.Background(), readback.DefaultTimeout)
+	defer cancel()
+	results := readback.ReadBuffer(ctx, r.device, r.buffer, 0, r.dimensions.Size())
+	// the caller waits anyway, polling here saves the readback's poll interval
+	r.device.Poll(true, &wgpu.WrappedSubmissionIndex{
+		Queue:           r.queue,
+		SubmissionIndex: index,
+	})
+	result := <-results
+	span.End()
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	data := result.Data
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(r.dimensions.Width), int(r.dimensions.Height)))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		src := data[uint64(y)*r.dimensions.PaddedBytesPerRow:]
+		copy(img.Pix[y*img.Stride:(y+1)*img.Stride], src[:r.dimensions.UnpaddedBytesPerRow])
+	}
+	return img, nil
+}
+
+func (r *Reader) Drop() {
+	if r.buffer != nil {
+		r.buffer.Drop()
+		r.buffer = nil
+	}
+}
+
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	imageEncoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := imageEncoder.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
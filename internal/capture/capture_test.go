@@ -0,0 +1,162 @@
+package capture
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func newDevice(t *testing.T) *wgpu.Device {
+	t.Helper()
+	instance := wgpu.CreateInstance(nil)
+	t.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	t.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	t.Cleanup(device.Drop)
+	return device
+}
+
+func TestBufferDimensions(t *testing.T) {
+	for _, test := range []struct {
+		width, height    uint64
+		unpadded, padded uint64
+	}{
+		{width: 1, height: 1, unpadded: 4, padded: 256},
+		{width: 63, height: 2, unpadded: 252, padded: 256},
+		{width: 64, height: 2, unpadded: 256, padded: 256},
+		{width: 65, height: 3, unpadded: 260, padded: 512},
+		{width: 640, height: 480, unpadded: 2560, padded: 2560},
+	} {
+		d := NewBufferDimensions(test.width, test.height)
+		if d.UnpaddedBytesPerRow != test.unpadded || d.PaddedBytesPerRow != test.padded {
+			t.Errorf("%dx%d: %d bytes per row padded to %d, want %d padded to %d",
+				test.width, test.height, d.UnpaddedBytesPerRow, d.PaddedBytesPerRow, test.unpadded, test.padded)
+		}
+		if want := test.padded * test.height; d.Size() != want {
+			t.Errorf("%dx%d: size %d, want %d", test.width, test.height, d.Size(), want)
+		}
+	}
+}
+
+func TestReadUnpadsRows(t *testing.T) {
+	device := newDevice(t)
+	r, err := NewReader(device, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Drop()
+
+	// every row is 12 bytes of pixels followed by padding, which is filled
+	// with 0xff to tell it apart
+	d := r.Dimensions()
+	data := make([]byte, d.Size())
+	for i := range data {
+		data[i] = 0xff
+	}
+	for y := uint64(0); y < d.Height; y++ {
+		for x := uint64(0); x < d.UnpaddedBytesPerRow; x++ {
+			data[y*d.PaddedBytesPerRow+x] = byte(y*d.UnpaddedBytesPerRow + x)
+		}
+	}
+	queue := device.GetQueue()
+	queue.WriteBuffer(r.buffer, 0, data)
+	index := queue.Submit()
+
+	img, err := r.Read(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Rect, image.Rect(0, 0, 3, 2); got != want {
+		t.Fatalf("image bounds %v, want %v", got, want)
+	}
+	for i, b := range img.Pix {
+		if b != byte(i) {
+			t.Fatalf("byte %d of the image is %d, want %d", i, b, i)
+		}
+	}
+}
+
+func TestCapture(t *testing.T) {
+	device := newDevice(t)
+	queue := device.GetQueue()
+
+	// a width that needs row padding
+	const width, height = 65, 3
+	texture, err := device.CreateTexture(&wgpu.TextureDescriptor{
+		Size:          wgpu.Extent3D{Width: width, Height: height, DepthOrArrayLayers: 1},
+		MipLevelCount: 1,
+		SampleCount:   1,
+		Dimension:     wgpu.TextureDimension_2D,
+		Format:        Format,
+		Usage:         wgpu.TextureUsage_RenderAttachment | wgpu.TextureUsage_CopySrc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer texture.Drop()
+	view := texture.CreateView(nil)
+	defer view.Drop()
+
+	r, err := NewReader(device, width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Drop()
+
+	encoder, err := device.CreateCommandEncoder(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
+		ColorAttachments: []wgpu.RenderPassColorAttachment{{
+			View:       view,
+			LoadOp:     wgpu.LoadOp_Clear,
+			StoreOp:    wgpu.StoreOp_Store,
+			ClearValue: wgpu.Color{R: 1, B: 1, A: 1},
+		}},
+	})
+	pass.End()
+	r.Copy(encoder, texture)
+	img, err := r.Read(queue.Submit(encoder.Finish(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := color.NRGBA{R: 255, B: 255, A: 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if got := img.NRGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.png")
+	if err := SavePNG(path, img); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.NRGBAModel.Convert(saved.At(width-1, height-1)); got != want {
+		t.Errorf("saved pixel is %v, want %v", got, want)
+	}
+}
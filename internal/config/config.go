@@ -0,0 +1,356 @@
+// Package config holds the command line options shared by the examples for
+// picking an adapter and configuring the device and swap chain.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/trace"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgpulog"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+type Config struct {
+	// Backends is the set of backends to create the instance with, 0 (the
+	// default) lets wgpu use all of them.
+	Backends             wgpu.InstanceBackend
+	PowerPreference      wgpu.PowerPreference
+	ForceFallbackAdapter bool
+	PresentMode          wgpu.PresentMode
+	// SampleCount is the MSAA sample count, 0 leaves the choice to the
+	// example.
+	SampleCount uint32
+	// Width and Height are the initial window size, 0 leaves the choice to
+	// the example.
+	Width  int
+	Height int
+	// LogLevel is only applied if HasLogLevel is set, otherwise wgpu keeps
+	// its default.
+	LogLevel    wgpu.LogLevel
+	HasLogLevel bool
+	// LogJSON makes the wgpu log messages, which go through log/slog,
+	// print as JSON instead of text.
+	LogJSON bool
+	// Trace is the file to write a Chrome trace of the run to, empty
+	// disables tracing.
+	Trace        string
+	ListAdapters bool
+}
+
+// FromEnv returns the default configuration, taking the adapter and logging
+// options from WGPU_FORCE_FALLBACK_ADAPTER, WGPU_LOG_LEVEL and
+// WGPU_LOG_FORMAT.
+func FromEnv() Config {
+	c := Config{
+		PowerPreference:      wgpu.PowerPreference_Undefined,
+		ForceFallbackAdapter: os.Getenv("WGPU_FORCE_FALLBACK_ADAPTER") == "1",
+		PresentMode:          wgpu.PresentMode_Fifo,
+		LogJSON:              strings.EqualFold(os.Getenv("WGPU_LOG_FORMAT"), "json"),
+	}
+	if level, ok := logLevels[strings.ToLower(os.Getenv("WGPU_LOG_LEVEL"))]; ok {
+		c.LogLevel = level
+		c.HasLogLevel = true
+	}
+	return c
+}
+
+var backends = map[string]wgpu.InstanceBackend{
+	"all":       wgpu.InstanceBackend_None,
+	"primary":   wgpu.InstanceBackend_Primary,
+	"secondary": wgpu.InstanceBackend_Secondary,
+	"vulkan":    wgpu.InstanceBackend_Vulkan,
+	"metal":     wgpu.InstanceBackend_Metal,
+	"dx12":      wgpu.InstanceBackend_DX12,
+	"dx11":      wgpu.InstanceBackend_DX11,
+	"gl":        wgpu.InstanceBackend_GL,
+}
+
+var powerPreferences = map[string]wgpu.PowerPreference{
+	"default": wgpu.PowerPreference_Undefined,
+	"low":     wgpu.PowerPreference_LowPower,
+	"high":    wgpu.PowerPreference_HighPerformance,
+}
+
+var presentModes = map[string]wgpu.PresentMode{
+	"fifo":      wgpu.PresentMode_Fifo,
+	"mailbox":   wgpu.PresentMode_Mailbox,
+	"immediate": wgpu.PresentMode_Immediate,
+}
+
+var logLevels = map[string]wgpu.LogLevel{
+	"off":   wgpu.LogLevel_Off,
+	"error": wgpu.LogLevel_Error,
+	"warn":  wgpu.LogLevel_Warn,
+	"info":  wgpu.LogLevel_Info,
+	"debug": wgpu.LogLevel_Debug,
+	"trace": wgpu.LogLevel_Trace,
+}
+
+// RegisterFlags adds the flags for c to fs, the current values of c are
+// the defaults.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.Func("backend", "graphics backends to use, a comma separated list of: "+keys(backends), func(s string) error {
+		// all is the zero mask, or'ing it with the others would silently
+		// narrow it down to them
+		names := strings.Split(s, ",")
+		var b wgpu.InstanceBackend
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			v, ok := backends[name]
+			if !ok {
+				return fmt.Errorf("unknown backend %q", name)
+			}
+			if name == "all" && len(names) > 1 {
+				return fmt.Errorf("backend all can't be combined with other backends")
+			}
+			b |= v
+		}
+		c.Backends = b
+		return nil
+	})
+	fs.Func("power", "adapter power preference, one of: "+keys(powerPreferences), func(s string) error {
+		return parse(powerPreferences, s, &c.PowerPreference)
+	})
+	fs.BoolVar(&c.ForceFallbackAdapter, "fallback-adapter", c.ForceFallbackAdapter, "force the software fallback adapter")
+	fs.Func("present-mode", "swap chain present mode, one of: "+keys(presentModes), func(s string) error {
+		return parse(presentModes, s, &c.PresentMode)
+	})
+	fs.Func("msaa", "MSAA sample count, 1 disables multisampling", func(s string) error {
+		var n uint32
+		if _, err := fmt.Sscan(s, &n); err != nil {
+			return err
+		}
+		if n == 0 || n&(n-1) != 0 {
+			return fmt.Errorf("sample count must be a power of two, got %d", n)
+		}
+		c.SampleCount = n
+		return nil
+	})
+	fs.IntVar(&c.Width, "width", c.Width, "initial window width")
+	fs.IntVar(&c.Height, "height", c.Height, "initial window height")
+	fs.Func("log-level", "wgpu log level, one of: "+keys(logLevels), func(s string) error {
+		if err := parse(logLevels, s, &c.LogLevel); err != nil {
+			return err
+		}
+		c.HasLogLevel = true
+		return nil
+	})
+	fs.Func("log-format", "wgpu log format, one of: json, text", func(s string) error {
+		switch strings.ToLower(s) {
+		case "json":
+			c.LogJSON = true
+		case "text":
+			c.LogJSON = false
+		default:
+			return fmt.Errorf("unknown log format %q", s)
+		}
+		return nil
+	})
+	fs.StringVar(&c.Trace, "trace", c.Trace, "write a Chrome trace of the run to this file, open it in https://ui.perfetto.dev")
+	fs.BoolVar(&c.ListAdapters, "list-adapters", c.ListAdapters, "print the available adapters and their limits, then exit")
+}
+
+// Apply sets the process wide wgpu state, i.e. the log level, and routes
+// wgpu's log messages to stderr through log/slog. On android they stay in
+// logcat, where wgpu writes them by default.
+func (c *Config) Apply() {
+	if c.HasLogLevel {
+		wgpu.SetLogLevel(c.LogLevel)
+	}
+	if runtime.GOOS == "android" {
+		return
+	}
+	handler := wgpulog.NewHandler(os.Stderr, c.LogJSON, wgpulog.LevelTrace)
+	wgpulog.Install(wgpulog.NewBridge(slog.New(handler)))
+}
+
+// SingleSample returns an error if -msaa asked for multisampling, it is
+// called by programs that always render with one sample.
+func (c *Config) SingleSample() error {
+	if c.SampleCount > 1 {
+		return fmt.Errorf("-msaa %d: multisampling isn't supported by this example", c.SampleCount)
+	}
+	return nil
+}
+
+// sampleCounts are the sample counts SupportedSampleCounts tries.
+var sampleCounts = []uint32{1, 2, 4, 8, 16}
+
+// SupportedSampleCounts returns the sample counts device can create
+// render attachments of format with. The binding can't query the
+// adapter's format features, so each count is tried with a 1x1 texture.
+func SupportedSampleCounts(device *wgpu.Device, format wgpu.TextureFormat) []uint32 {
+	var supported []uint32
+	for _, n := range sampleCounts {
+		texture, err := device.CreateTexture(&wgpu.TextureDescriptor{
+			Label:         "sample count probe",
+			Size:          wgpu.Extent3D{Width: 1, Height: 1, DepthOrArrayLayers: 1},
+			MipLevelCount: 1,
+			SampleCount:   n,
+			Dimension:     wgpu.TextureDimension_2D,
+			Format:        format,
+			Usage:         wgpu.TextureUsage_RenderAttachment,
+		})
+		if err != nil {
+			continue
+		}
+		texture.Drop()
+		supported = append(supported, n)
+	}
+	return supported
+}
+
+// CheckSampleCount returns an error if -msaa asked for a sample count
+// device doesn't support for format, the surface's format, it is called
+// once the device is created.
+func (c *Config) CheckSampleCount(device *wgpu.Device, format wgpu.TextureFormat) error {
+	if c.SampleCount <= 1 {
+		return nil
+	}
+	supported := SupportedSampleCounts(device, format)
+	for _, n := range supported {
+		if n == c.SampleCount {
+			return nil
+		}
+	}
+	counts := make([]string, len(supported))
+	for i, n := range supported {
+		counts[i] = fmt.Sprint(n)
+	}
+	return fmt.Errorf("-msaa %d: the adapter doesn't support it for %s, supported sample counts: %s",
+		c.SampleCount, format, strings.Join(counts, ", "))
+}
+
+// NewTracer returns a tracer if -trace was given, nil otherwise, write it
+// out with WriteTrace.
+func (c *Config) NewTracer() *trace.Tracer {
+	if c.Trace == "" {
+		return nil
+	}
+	return trace.New()
+}
+
+// WriteTrace writes t to the -trace file.
+func (c *Config) WriteTrace(t *trace.Tracer) error {
+	if c.Trace == "" {
+		return nil
+	}
+	return t.WriteFile(c.Trace)
+}
+
+// InstanceDescriptor returns nil if no backends were chosen, since wgpu
+// reads an explicit 0 as no backends at all.
+func (c *Config) InstanceDescriptor() *wgpu.InstanceDescriptor {
+	if c.Backends == wgpu.InstanceBackend_None {
+		return nil
+	}
+	return &wgpu.InstanceDescriptor{
+		Backends: c.Backends,
+	}
+}
+
+func (c *Config) RequestAdapterOptions(surface *wgpu.Surface) *wgpu.RequestAdapterOptions {
+	return &wgpu.RequestAdapterOptions{
+		CompatibleSurface:    surface,
+		PowerPreference:      c.PowerPreference,
+		ForceFallbackAdapter: c.ForceFallbackAdapter,
+	}
+}
+
+// PrintAdapters prints every adapter of the configured backends that can be
+// requested with any power preference.
+func (c *Config) PrintAdapters(w io.Writer) error {
+	type key struct {
+		backend  wgpu.BackendType
+		vendorID uint32
+		deviceID uint32
+		name     string
+	}
+	seen := map[key]bool{}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, backend := range []wgpu.InstanceBackend{
+		wgpu.InstanceBackend_Vulkan,
+		wgpu.InstanceBackend_Metal,
+		wgpu.InstanceBackend_DX12,
+		wgpu.InstanceBackend_DX11,
+		wgpu.InstanceBackend_GL,
+	} {
+		if c.Backends != wgpu.InstanceBackend_None && c.Backends&backend == 0 {
+			continue
+		}
+
+		instance := wgpu.CreateInstance(&wgpu.InstanceDescriptor{Backends: backend})
+		for _, options := range []wgpu.RequestAdapterOptions{
+			{PowerPreference: wgpu.PowerPreference_HighPerformance},
+			{PowerPreference: wgpu.PowerPreference_LowPower},
+			{ForceFallbackAdapter: true},
+		} {
+			adapter, err := instance.RequestAdapter(&options)
+			if err != nil {
+				continue
+			}
+
+			props := adapter.GetProperties()
+			k := key{props.BackendType, props.VendorID, props.DeviceID, props.Name}
+			if !seen[k] {
+				seen[k] = true
+				printAdapter(tw, adapter, props)
+			}
+			adapter.Drop()
+		}
+		instance.Drop()
+	}
+
+	if len(seen) == 0 {
+		return fmt.Errorf("config: no adapters found")
+	}
+	return tw.Flush()
+}
+
+func printAdapter(w io.Writer, adapter *wgpu.Adapter, props wgpu.AdapterProperties) {
+	fmt.Fprintf(w, "%s (%s)\n", props.Name, props.BackendType)
+	fmt.Fprintf(w, "\ttype\t%s\n", props.AdapterType)
+	fmt.Fprintf(w, "\tvendor\t0x%04x\n", props.VendorID)
+	fmt.Fprintf(w, "\tdevice\t0x%04x\n", props.DeviceID)
+	fmt.Fprintf(w, "\tdriver\t%s\n", props.DriverDescription)
+
+	var features []string
+	for _, f := range adapter.EnumerateFeatures() {
+		features = append(features, f.String())
+	}
+	fmt.Fprintf(w, "\tfeatures\t%s\n", strings.Join(features, ", "))
+
+	limits := reflect.ValueOf(adapter.GetLimits().Limits)
+	for i := 0; i < limits.NumField(); i++ {
+		fmt.Fprintf(w, "\t%s\t%v\n", limits.Type().Field(i).Name, limits.Field(i).Interface())
+	}
+	fmt.Fprintln(w)
+}
+
+func parse[T any](values map[string]T, s string, dst *T) error {
+	v, ok := values[strings.ToLower(s)]
+	if !ok {
+		return fmt.Errorf("unknown value %q, expected one of: %s", s, keys(values))
+	}
+	*dst = v
+	return nil
+}
+
+func keys[T any](values map[string]T) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
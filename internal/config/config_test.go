@@ -0,0 +1,95 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func TestBackendFlag(t *testing.T) {
+	for _, test := range []struct {
+		arg     string
+		want    wgpu.InstanceBackend
+		wantErr bool
+	}{
+		{arg: "all", want: wgpu.InstanceBackend_None},
+		{arg: "vulkan", want: wgpu.InstanceBackend_Vulkan},
+		{arg: "Vulkan, gl", want: wgpu.InstanceBackend_Vulkan | wgpu.InstanceBackend_GL},
+		{arg: "all,vulkan", wantErr: true},
+		{arg: "vulkan,all", wantErr: true},
+		{arg: "all,all", wantErr: true},
+		{arg: "glide", wantErr: true},
+	} {
+		var c Config
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		c.RegisterFlags(fs)
+
+		err := fs.Parse([]string{"-backend", test.arg})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("-backend %s: got %v, want an error", test.arg, c.Backends)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("-backend %s: %v", test.arg, err)
+		} else if c.Backends != test.want {
+			t.Errorf("-backend %s: got %v, want %v", test.arg, c.Backends, test.want)
+		}
+	}
+}
+
+func TestSingleSample(t *testing.T) {
+	for _, test := range []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: nil},
+		{args: []string{"-msaa", "1"}},
+		{args: []string{"-msaa", "4"}, wantErr: true},
+	} {
+		var c Config
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		c.RegisterFlags(fs)
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.SingleSample(); (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want error %t", test.args, err, test.wantErr)
+		}
+	}
+}
+
+func TestCheckSampleCount(t *testing.T) {
+	instance := wgpu.CreateInstance(nil)
+	defer instance.Drop()
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	defer adapter.Drop()
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	defer device.Drop()
+
+	const format = wgpu.TextureFormat_RGBA8Unorm
+	supported := SupportedSampleCounts(device, format)
+	// WebGPU guarantees 1 and 4 for renderable formats
+	if !slices.Contains(supported, 1) || !slices.Contains(supported, 4) {
+		t.Errorf("supported sample counts %v, want at least 1 and 4", supported)
+	}
+	for _, n := range sampleCounts {
+		c := Config{SampleCount: n}
+		err := c.CheckSampleCount(device, format)
+		if want := slices.Contains(supported, n); (err == nil) != want {
+			t.Errorf("-msaa %d: got error %v, want supported %t", n, err, want)
+		}
+	}
+}
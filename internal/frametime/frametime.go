@@ -0,0 +1,210 @@
+// Package frametime measures frame times and the time spent in parts of a
+// frame, and reports averages and percentiles over the last frames.
+//
+// Nothing here reads the clock, callers pass the current time in, so the
+// numbers can be checked against a fake clock.
+package frametime
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultWindow is the number of samples the statistics are computed over.
+const DefaultWindow = 240
+
+// Series keeps the last samples of a duration.
+type Series struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func NewSeries(window int) *Series {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Series{samples: make([]time.Duration, window)}
+}
+
+func (s *Series) Add(d time.Duration) {
+	s.samples[s.next] = d
+	s.next++
+	if s.next == len(s.samples) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+// Len returns the number of samples kept.
+func (s *Series) Len() int {
+	if s.full {
+		return len(s.samples)
+	}
+	return s.next
+}
+
+type Stats struct {
+	Count         int
+	Mean          time.Duration
+	Min, Max      time.Duration
+	P50, P95, P99 time.Duration
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("mean %v p50 %v p95 %v p99 %v max %v",
+		round(s.Mean), round(s.P50), round(s.P95), round(s.P99), round(s.Max))
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
+
+func (s *Series) Stats() Stats {
+	n := s.Len()
+	if n == 0 {
+		return Stats{}
+	}
+
+	sorted := append([]time.Duration(nil), s.samples[:n]...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return Stats{
+		Count: n,
+		Mean:  total / time.Duration(n),
+		Min:   sorted[0],
+		Max:   sorted[n-1],
+		P50:   percentile(sorted, 50),
+		P95:   percentile(sorted, 95),
+		P99:   percentile(sorted, 99),
+	}
+}
+
+// percentile uses the nearest rank method on sorted samples.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Timer measures the time between frames.
+type Timer struct {
+	Frames *Series
+	// Smoothing is the weight of the previous FPS value in the exponential
+	// moving average, between 0 and 1.
+	Smoothing float64
+
+	last time.Time
+	fps  float64
+}
+
+func NewTimer(window int) *Timer {
+	return &Timer{
+		Frames:    NewSeries(window),
+		Smoothing: 0.9,
+	}
+}
+
+// Tick starts a new frame at now and returns the time since the previous
+// one, or 0 for the first frame.
+func (t *Timer) Tick(now time.Time) time.Duration {
+	if t.last.IsZero() {
+		t.last = now
+		return 0
+	}
+
+	dt := now.Sub(t.last)
+	t.last = now
+	t.Frames.Add(dt)
+
+	if dt > 0 {
+		fps := float64(time.Second) / float64(dt)
+		if t.fps == 0 {
+			t.fps = fps
+		} else {
+			t.fps = t.Smoothing*t.fps + (1-t.Smoothing)*fps
+		}
+	}
+	return dt
+}
+
+// FPS returns the smoothed frame rate.
+func (t *Timer) FPS() float64 {
+	return t.fps
+}
+
+// Profile combines a Timer with named sections of the frame, e.g. the CPU
+// time spent recording commands, and reports them periodically.
+type Profile struct {
+	Timer *Timer
+	// Interval is the time between reports, 0 disables them.
+	Interval time.Duration
+	Out      io.Writer
+
+	window   int
+	names    []string
+	sections map[string]*Series
+	next     time.Time
+}
+
+func NewProfile(window int, interval time.Duration, out io.Writer) *Profile {
+	return &Profile{
+		Timer:    NewTimer(window),
+		Interval: interval,
+		Out:      out,
+		window:   window,
+		sections: map[string]*Series{},
+	}
+}
+
+// Add records d for section name in the current frame.
+func (p *Profile) Add(name string, d time.Duration) {
+	s, ok := p.sections[name]
+	if !ok {
+		s = NewSeries(p.window)
+		p.sections[name] = s
+		p.names = append(p.names, name)
+	}
+	s.Add(d)
+}
+
+// Section returns the samples of name, or nil if none were added.
+func (p *Profile) Section(name string) *Series {
+	return p.sections[name]
+}
+
+// Frame starts a new frame at now, writes a report if one is due and
+// returns the time since the previous frame.
+func (p *Profile) Frame(now time.Time) time.Duration {
+	dt := p.Timer.Tick(now)
+	if p.Interval <= 0 || p.Out == nil {
+		return dt
+	}
+	if p.next.IsZero() {
+		p.next = now.Add(p.Interval)
+	} else if !now.Before(p.next) {
+		p.Report(p.Out)
+		p.next = now.Add(p.Interval)
+	}
+	return dt
+}
+
+// Report writes the frame rate and the statistics of every section to w.
+func (p *Profile) Report(w io.Writer) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%.1f fps\n", p.Timer.FPS())
+	fmt.Fprintf(&b, "  frame   %v\n", p.Timer.Frames.Stats())
+	for _, name := range p.names {
+		fmt.Fprintf(&b, "  %-7s %v\n", name, p.sections[name].Stats())
+	}
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,107 @@
+package frametime
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func series(window int, ms ...int) *Series {
+	s := NewSeries(window)
+	for _, m := range ms {
+		s.Add(time.Duration(m) * time.Millisecond)
+	}
+	return s
+}
+
+func TestStats(t *testing.T) {
+	var hundred []int
+	for i := 100; i >= 1; i-- {
+		hundred = append(hundred, i)
+	}
+
+	for _, test := range []struct {
+		name   string
+		series *Series
+		want   Stats
+	}{
+		{name: "empty", series: series(4), want: Stats{}},
+		{
+			name:   "one",
+			series: series(4, 7),
+			want:   Stats{Count: 1, Mean: 7 * time.Millisecond, Min: 7 * time.Millisecond, Max: 7 * time.Millisecond, P50: 7 * time.Millisecond, P95: 7 * time.Millisecond, P99: 7 * time.Millisecond},
+		},
+		{
+			name:   "unsorted",
+			series: series(4, 4, 1, 3, 2),
+			want:   Stats{Count: 4, Mean: 2500 * time.Microsecond, Min: 1 * time.Millisecond, Max: 4 * time.Millisecond, P50: 2 * time.Millisecond, P95: 4 * time.Millisecond, P99: 4 * time.Millisecond},
+		},
+		{
+			name:   "hundred",
+			series: series(100, hundred...),
+			want:   Stats{Count: 100, Mean: 50500 * time.Microsecond, Min: 1 * time.Millisecond, Max: 100 * time.Millisecond, P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond},
+		},
+		{
+			// only the last 4 samples are kept
+			name:   "wrapped",
+			series: series(4, 100, 100, 1, 2, 3, 4),
+			want:   Stats{Count: 4, Mean: 2500 * time.Microsecond, Min: 1 * time.Millisecond, Max: 4 * time.Millisecond, P50: 2 * time.Millisecond, P95: 4 * time.Millisecond, P99: 4 * time.Millisecond},
+		},
+	} {
+		if got := test.series.Stats(); got != test.want {
+			t.Errorf("%s: Stats() = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSeriesDefaultWindow(t *testing.T) {
+	s := NewSeries(0)
+	for i := 0; i < DefaultWindow+10; i++ {
+		s.Add(time.Millisecond)
+	}
+	if n := s.Len(); n != DefaultWindow {
+		t.Errorf("Len() = %d, want %d", n, DefaultWindow)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer(4)
+	start := time.Unix(0, 0)
+
+	if dt := timer.Tick(start); dt != 0 {
+		t.Errorf("first Tick() = %v, want 0", dt)
+	}
+	if dt := timer.Tick(start.Add(10 * time.Millisecond)); dt != 10*time.Millisecond {
+		t.Errorf("second Tick() = %v, want 10ms", dt)
+	}
+	if fps := timer.FPS(); fps != 100 {
+		t.Errorf("FPS() = %v, want 100", fps)
+	}
+	if n := timer.Frames.Len(); n != 1 {
+		t.Errorf("Frames.Len() = %d, want 1", n)
+	}
+}
+
+func TestProfileReport(t *testing.T) {
+	var out bytes.Buffer
+	p := NewProfile(4, time.Second, &out)
+	now := time.Unix(0, 0)
+
+	for i := 0; i < 120; i++ {
+		p.Frame(now)
+		p.Add("record", time.Millisecond)
+		now = now.Add(10 * time.Millisecond)
+	}
+
+	// the first frame only schedules the reports, one follows every second
+	if n := strings.Count(out.String(), " fps\n"); n != 1 {
+		t.Errorf("got %d reports, want 1:\n%s", n, out.String())
+	}
+	if !strings.Contains(out.String(), "  record  mean 1ms") {
+		t.Errorf("report is missing the record section:\n%s", out.String())
+	}
+	if s := p.Section("missing"); s != nil {
+		t.Errorf("Section(missing) = %v, want nil", s)
+	}
+}
@@ -0,0 +1,603 @@
+// Package framework runs an example inside a window, owning the instance,
+// adapter, device, surface and swap chain so that each example only has to
+// implement App. The windowing backend is picked with the -window flag, the
+// adapter and swap chain with the flags of the config package. Built with
+// the nowindow tag no windowing backend is linked in and Run always renders
+// headless.
+package framework
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/capture"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/config"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/frametime"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/hotreload"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/surface"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/timestep"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/trace"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgpulog"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+var trackLeaks = os.Getenv("WGPU_TRACK_LEAKS") == "1"
+
+// stderr receives the leak report and the frame statistics.
+var stderr io.Writer = os.Stderr
+
+var cfg = config.FromEnv()
+
+var (
+	windowFlag   = flag.String("window", "", "windowing backend, one of: "+strings.Join(windowing.Backends(), ", "))
+	headlessFlag = flag.Bool("headless", false, "render offscreen into PNG files, same as -window headless")
+	framesFlag   = flag.Int("frames", 1, "number of frames to render with -headless")
+	outFlag      = flag.String("out", ".", "directory to write the frames to with -headless")
+	statsFlag    = flag.Duration("stats", 0, "print frame time statistics at this interval, 0 disables them")
+	shaderDir    = flag.String("shader-dir", "", "read shaders from this directory and reload them when they change")
+	timeScale    = flag.Float64("time-scale", 1, "simulation speed passed on to App.Update, 0.5 is half speed")
+	gpuTimeFlag  = flag.Bool("gpu-time", false, "wait for every frame to finish on the GPU and report the wait, this serializes CPU and GPU")
+)
+
+func init() {
+	runtime.LockOSThread()
+
+	cfg.RegisterFlags(flag.CommandLine)
+}
+
+// Context holds the objects owned by the runner. They are valid from
+// App.Init until App.Destroy returns, and must not be dropped by the App.
+//
+// When the device is lost the runner requests a new Adapter, Device and
+// Queue, rebuilds everything created through Resources and then calls
+// App.Resize, so Apps should not keep copies of these fields.
+type Context struct {
+	Instance *wgpu.Instance
+	Adapter  *wgpu.Adapter
+	Device   *wgpu.Device
+	Queue    *wgpu.Queue
+	// Resources records the objects the App wants recreated after a device
+	// loss, it drops the ones still alive after App.Destroy.
+	Resources *resource.Registry
+	// Leaks is set when WGPU_TRACK_LEAKS=1. Its report is printed to stderr
+	// after App.Destroy and before Resources drops what is left, so it
+	// lists every tracked object the App didn't drop itself. Resources
+	// tracks its handles, objects created outside of it can be tracked
+	// with leak.Track. A nil tracker tracks nothing.
+	Leaks *leak.Tracker
+	// Shaders creates shader modules in Resources. With -shader-dir they
+	// are read from disk and reloaded, together with the pipelines and bind
+	// groups created after them in Resources, when the files change.
+	Shaders *hotreload.Reloader
+	// Surface is nil when running headless.
+	Surface *wgpu.Surface
+	// Config describes the current swap chain, Width and Height are
+	// updated before App.Resize is called.
+	Config *wgpu.SwapChainDescriptor
+	// SampleCount is the MSAA sample count the App should render with.
+	SampleCount uint32
+	// Profile times the frames, the runner adds the "update", "render" and,
+	// with -gpu-time, "gpu" sections. Apps may add their own.
+	Profile *frametime.Profile
+	// Clock turns the real frame time into the dt passed to App.Update,
+	// pausing, single stepping or slowing it down. The runner binds it to
+	// 'P' (pause), 'N' (step while paused) and 'M' (cycle slow motion).
+	Clock *timestep.Clock
+	// Trace is set with -trace, Apps may record their own spans in it. A
+	// nil tracer records nothing.
+	Trace *trace.Tracer
+}
+
+type App interface {
+	// Init creates the App's resources. Destroy is called even if Init
+	// fails, so it must cope with partially initialized state.
+	Init(ctx *Context) error
+	// Update is called once per frame before Render with the time elapsed
+	// since the previous frame.
+	Update(dt time.Duration)
+	// Render records the frame into encoder, the runner submits it and
+	// presents view afterwards.
+	Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder)
+	Resize(width, height uint32)
+	Destroy()
+}
+
+// EventHandler can be implemented by an App to receive the window's input
+// events.
+type EventHandler interface {
+	Event(e windowing.Event)
+}
+
+// SubmitHandler can be implemented by an App to learn the submission index
+// of each frame, e.g. to know when the GPU is done with the frame's
+// buffers. Submitted is called right after the frame is submitted.
+type SubmitHandler interface {
+	Submitted(index wgpu.SubmissionIndex)
+}
+
+// DeviceRecoveredHandler can be implemented by an App that keeps state tied
+// to the device outside of Context.Resources, e.g. fences of the old queue.
+// DeviceRecovered is called after a device loss, once Resources are rebuilt
+// on the new device and before App.Resize.
+type DeviceRecoveredHandler interface {
+	DeviceRecovered()
+}
+
+type Options struct {
+	Title  string
+	Width  int
+	Height int
+	// SampleCount is the default for Context.SampleCount, the -msaa flag
+	// overrides it. Setting it declares that the App renders with
+	// Context.SampleCount, for the others, which render with one sample,
+	// Run rejects -msaa above 1.
+	SampleCount uint32
+	// Window is the windowing backend used when -window is not given. It
+	// is ignored when the backend isn't built in, e.g. with the nowindow
+	// tag.
+	Window string
+}
+
+func Run(app App, opts Options) error {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	cfg.Apply()
+	defer wgpulog.Flush()
+	if cfg.ListAdapters {
+		return cfg.PrintAdapters(os.Stdout)
+	}
+
+	name := *windowFlag
+	if name == "" && slices.Contains(windowing.Backends(), opts.Window) {
+		name = opts.Window
+	}
+	if name == "" {
+		name = defaultWindow
+	}
+	if *headlessFlag {
+		name = "headless"
+	}
+
+	if opts.Title == "" {
+		opts.Title = "go-webgpu with " + name
+	}
+	if cfg.Width > 0 {
+		opts.Width = cfg.Width
+	}
+	if cfg.Height > 0 {
+		opts.Height = cfg.Height
+	}
+	if opts.SampleCount == 0 {
+		if err := cfg.SingleSample(); err != nil {
+			return err
+		}
+	}
+	if cfg.SampleCount > 0 {
+		opts.SampleCount = cfg.SampleCount
+	}
+	if opts.SampleCount == 0 {
+		opts.SampleCount = 1
+	}
+	if opts.Width <= 0 {
+		opts.Width = 640
+	}
+	if opts.Height <= 0 {
+		opts.Height = 480
+	}
+
+	if name == "headless" {
+		return runHeadless(app, opts)
+	}
+
+	window, err := windowing.New(name, windowing.Options{
+		Title:  opts.Title,
+		Width:  opts.Width,
+		Height: opts.Height,
+	})
+	if err != nil {
+		return err
+	}
+	defer window.Destroy()
+
+	// On android the surface is only created after the app is resumed
+	for window.SurfaceDescriptor() == nil {
+		if !window.Poll() {
+			return nil
+		}
+	}
+
+	r, err := newRunner(window)
+	if err != nil {
+		return err
+	}
+	defer r.destroy()
+	if err := cfg.CheckSampleCount(r.ctx.Device, r.ctx.Config.Format); err != nil {
+		return err
+	}
+	r.ctx.SampleCount = opts.SampleCount
+
+	if err := app.Init(&r.ctx); err != nil {
+		app.Destroy()
+		return err
+	}
+	defer app.Destroy()
+	r.app = app
+
+	// eventErr is the first error of the event callback since the last
+	// frame, it is handled like an error of the frame
+	var eventErr error
+	handler, _ := app.(EventHandler)
+	window.SetEventCallback(func(e windowing.Event) {
+		switch e := e.(type) {
+		case windowing.ResizedEvent:
+			if err := r.resize(e.Width, e.Height); err != nil && eventErr == nil {
+				eventErr = err
+			}
+		case windowing.SurfaceCreatedEvent:
+			if err := r.createSurface(); err != nil && eventErr == nil {
+				eventErr = err
+			}
+		case windowing.SurfaceDestroyedEvent:
+			r.dropSurface()
+		case windowing.KeyEvent:
+			// Print resource usage on pressing 'R'
+			if e.Key == windowing.KeyR && e.Pressed {
+				report := r.ctx.Instance.GenerateReport()
+				buf, _ := json.MarshalIndent(report, "", "  ")
+				fmt.Print(string(buf))
+			}
+			if e.Pressed {
+				clockKey(r.ctx.Clock, e.Key)
+			}
+		}
+
+		if handler != nil {
+			handler.Event(e)
+		}
+	})
+
+	recovery := surface.NewRecovery(func() error {
+		return r.resize(window.Size())
+	})
+
+	for frame := 0; window.Poll(); frame++ {
+		span := r.ctx.Trace.Begin("Frame").Arg("frame", frame)
+
+		now := time.Now()
+		r.ctx.Shaders.Poll(now)
+		update := r.ctx.Trace.Begin("Update")
+		app.Update(r.ctx.Clock.Advance(r.ctx.Profile.Frame(now)))
+		update.End()
+		r.ctx.Profile.Add("update", time.Since(now))
+
+		err := eventErr
+		eventErr = nil
+		if err == nil {
+			err = r.render()
+		}
+		span.End()
+		if err != nil {
+			slog.Warn("frame failed", "kind", surface.Classify(err), "err", err)
+		}
+		if err := recovery.Handle(err); err != nil {
+			if surface.Classify(err) != surface.KindDeviceLost {
+				return err
+			}
+			if err := r.recoverDevice(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type runner struct {
+	window    windowing.Window
+	ctx       Context
+	swapChain *wgpu.SwapChain
+	app       App
+
+	// target and reader replace the swap chain when running headless
+	target *wgpu.Texture
+	reader *capture.Reader
+}
+
+func newRunner(window windowing.Window) (r *runner, err error) {
+	defer func() {
+		if err != nil {
+			r.destroy()
+			r = nil
+		}
+	}()
+	r = &runner{window: window}
+	r.ctx.Profile = newProfile()
+	r.ctx.Clock = newClock()
+	r.ctx.Trace = cfg.NewTracer()
+	if trackLeaks {
+		r.ctx.Leaks = leak.NewTracker()
+	}
+
+	r.ctx.Instance = wgpu.CreateInstance(cfg.InstanceDescriptor())
+
+	r.ctx.Surface = r.ctx.Instance.CreateSurface(window.SurfaceDescriptor())
+
+	err = r.requestDevice()
+	if err != nil {
+		return r, err
+	}
+
+	width, height := window.Size()
+	r.ctx.Config = &wgpu.SwapChainDescriptor{
+		Usage:       wgpu.TextureUsage_RenderAttachment,
+		Format:      r.ctx.Surface.GetPreferredFormat(r.ctx.Adapter),
+		Width:       width,
+		Height:      height,
+		PresentMode: cfg.PresentMode,
+	}
+	r.swapChain, err = r.ctx.Device.CreateSwapChain(r.ctx.Surface, r.ctx.Config)
+	if err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// requestDevice requests an adapter compatible with the current surface, if
+// any, and a device from it. ctx.Resources is created for the first device
+// and rebuilt for the following ones.
+func (r *runner) requestDevice() (err error) {
+	r.ctx.Adapter, err = r.ctx.Instance.RequestAdapter(cfg.RequestAdapterOptions(r.ctx.Surface))
+	if err != nil {
+		return err
+	}
+
+	r.ctx.Device, err = r.ctx.Adapter.RequestDevice(nil)
+	if err != nil {
+		return err
+	}
+	r.ctx.Queue = r.ctx.Device.GetQueue()
+
+	if r.ctx.Resources == nil {
+		r.ctx.Resources = resource.NewRegistry(r.ctx.Device)
+		r.ctx.Resources.Leaks = r.ctx.Leaks
+		r.ctx.Shaders = hotreload.New(r.ctx.Resources, *shaderDir)
+		return nil
+	}
+	return r.ctx.Resources.Rebuild(r.ctx.Device)
+}
+
+// recoverDevice replaces a lost device and recreates the swap chain on the
+// new one, keeping the format chosen by newRunner.
+func (r *runner) recoverDevice() (err error) {
+	if r.swapChain != nil {
+		r.swapChain.Drop()
+		r.swapChain = nil
+	}
+	r.ctx.Device.Drop()
+	r.ctx.Device = nil
+	r.ctx.Adapter.Drop()
+	r.ctx.Adapter = nil
+
+	err = r.requestDevice()
+	if err != nil {
+		return err
+	}
+
+	if r.ctx.Surface != nil {
+		r.swapChain, err = r.ctx.Device.CreateSwapChain(r.ctx.Surface, r.ctx.Config)
+		if err != nil {
+			return err
+		}
+	}
+
+	if handler, ok := r.app.(DeviceRecoveredHandler); ok {
+		handler.DeviceRecovered()
+	}
+	r.app.Resize(r.ctx.Config.Width, r.ctx.Config.Height)
+	return nil
+}
+
+// createSurface recreates the surface and swap chain after the window got a
+// new native surface, keeping the format chosen by newRunner.
+func (r *runner) createSurface() (err error) {
+	r.dropSurface()
+
+	r.ctx.Surface = r.ctx.Instance.CreateSurface(r.window.SurfaceDescriptor())
+
+	width, height := r.window.Size()
+	r.ctx.Config.Width = width
+	r.ctx.Config.Height = height
+	r.swapChain, err = r.ctx.Device.CreateSwapChain(r.ctx.Surface, r.ctx.Config)
+	return err
+}
+
+func (r *runner) dropSurface() {
+	if r.swapChain != nil {
+		r.swapChain.Drop()
+		r.swapChain = nil
+	}
+	if r.ctx.Surface != nil {
+		r.ctx.Surface.Drop()
+		r.ctx.Surface = nil
+	}
+}
+
+func (r *runner) resize(width, height uint32) (err error) {
+	if width > 0 && height > 0 && r.ctx.Surface != nil {
+		r.ctx.Config.Width = width
+		r.ctx.Config.Height = height
+
+		if r.swapChain != nil {
+			r.swapChain.Drop()
+		}
+		r.swapChain, err = r.ctx.Device.CreateSwapChain(r.ctx.Surface, r.ctx.Config)
+		if err != nil {
+			return err
+		}
+
+		r.app.Resize(r.ctx.Config.Width, r.ctx.Config.Height)
+	}
+	return nil
+}
+
+func (r *runner) render() error {
+	if r.swapChain == nil {
+		return nil
+	}
+
+	start := time.Now()
+	acquire := r.ctx.Trace.Begin("Acquire")
+	nextTexture, err := r.swapChain.GetCurrentTextureView()
+	acquire.End()
+	if err != nil {
+		r.ctx.Trace.Instant("Surface error")
+		return err
+	}
+	defer nextTexture.Drop()
+
+	encode := r.ctx.Trace.Begin("Encode")
+
+	encoder, err := r.ctx.Device.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{
+		Label: "Command Encoder",
+	})
+	if err != nil {
+		encode.End()
+		return err
+	}
+
+	r.app.Render(nextTexture, encoder)
+	commands := encoder.Finish(nil)
+	encode.End()
+
+	submit := r.ctx.Trace.Begin("Submit")
+	index := r.ctx.Queue.Submit(commands)
+	submit.End()
+	if handler, ok := r.app.(SubmitHandler); ok {
+		handler.Submitted(index)
+	}
+
+	present := r.ctx.Trace.Begin("Present")
+	r.swapChain.Present()
+	present.End()
+	r.ctx.Profile.Add("render", time.Since(start))
+
+	if *gpuTimeFlag {
+		r.waitGPU(index)
+	}
+	return nil
+}
+
+// slowMotion are the time scales 'M' cycles through.
+var slowMotion = []float64{1, 0.5, 0.25, 0.1}
+
+func clockKey(clock *timestep.Clock, key windowing.Key) {
+	switch key {
+	case windowing.KeyP:
+		clock.TogglePause()
+		fmt.Println("paused:", clock.Paused())
+	case windowing.KeyN:
+		clock.Step()
+	case windowing.KeyM:
+		next := slowMotion[0]
+		for i, scale := range slowMotion {
+			if scale < clock.Scale {
+				next = slowMotion[i]
+				break
+			}
+		}
+		clock.Scale = next
+		fmt.Println("time scale:", clock.Scale)
+	}
+}
+
+func newClock() *timestep.Clock {
+	clock := timestep.NewClock()
+	clock.Scale = *timeScale
+	return clock
+}
+
+func newProfile() *frametime.Profile {
+	return frametime.NewProfile(frametime.DefaultWindow, *statsFlag, stderr)
+}
+
+// waitGPU blocks until the submission index is done and adds the time to
+// the "gpu" section. The bindings have no timestamp queries, so this is the
+// closest measure of GPU time available, which includes the time the GPU
+// was still busy with earlier frames.
+func (r *runner) waitGPU(index wgpu.SubmissionIndex) {
+	start := time.Now()
+	defer r.ctx.Trace.Begin("GPU wait").End()
+	r.ctx.Device.Poll(true, &wgpu.WrappedSubmissionIndex{
+		Queue:           r.ctx.Queue,
+		SubmissionIndex: index,
+	})
+	r.ctx.Profile.Add("gpu", time.Since(start))
+}
+
+func (r *runner) destroy() {
+	if *statsFlag > 0 && r.ctx.Profile != nil {
+		r.ctx.Profile.Report(stderr)
+	}
+	if err := cfg.WriteTrace(r.ctx.Trace); err != nil {
+		fmt.Fprintln(stderr, err)
+	}
+	if r.reader != nil {
+		r.reader.Drop()
+		r.reader = nil
+	}
+	if r.target != nil {
+		r.target.Drop()
+		r.target = nil
+	}
+	if r.swapChain != nil {
+		r.swapChain.Drop()
+		r.swapChain = nil
+	}
+	if r.ctx.Config != nil {
+		r.ctx.Config = nil
+	}
+	// report before Resources drops the handles the App left behind, so
+	// they show up as leaks
+	if r.ctx.Leaks != nil {
+		r.ctx.Leaks.Report(stderr)
+		r.ctx.Leaks = nil
+	}
+	if r.ctx.Resources != nil {
+		r.ctx.Resources.Drop()
+		r.ctx.Resources = nil
+		r.ctx.Shaders = nil
+	}
+	if r.ctx.Queue != nil {
+		r.ctx.Queue = nil
+	}
+	if r.ctx.Device != nil {
+		r.ctx.Device.Drop()
+		r.ctx.Device = nil
+	}
+	if r.ctx.Adapter != nil {
+		r.ctx.Adapter.Drop()
+		r.ctx.Adapter = nil
+	}
+	if r.ctx.Surface != nil {
+		r.ctx.Surface.Drop()
+		r.ctx.Surface = nil
+	}
+	if r.ctx.Instance != nil {
+		r.ctx.Instance.Drop()
+		r.ctx.Instance = nil
+	}
+}
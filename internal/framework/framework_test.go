@@ -0,0 +1,114 @@
+package framework
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// leakyApp creates two buffers and only drops one of them.
+type leakyApp struct {
+	kept    *resource.Handle[*wgpu.Buffer]
+	dropped *resource.Handle[*wgpu.Buffer]
+}
+
+func (a *leakyApp) Init(ctx *Context) (err error) {
+	desc := &wgpu.BufferDescriptor{Size: 16, Usage: wgpu.BufferUsage_CopyDst}
+	a.kept, err = ctx.Resources.CreateBuffer(desc)
+	if err != nil {
+		return err
+	}
+	a.dropped, err = ctx.Resources.CreateBuffer(desc)
+	return err
+}
+
+func (a *leakyApp) Update(dt time.Duration)                                     {}
+func (a *leakyApp) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {}
+func (a *leakyApp) Resize(width, height uint32)                                 {}
+
+func (a *leakyApp) Destroy() {
+	a.dropped.Drop()
+}
+
+// headless switches Run to rendering frames headless into a temporary
+// directory for the duration of the test.
+func headless(t *testing.T, frames int) {
+	t.Helper()
+
+	instance := wgpu.CreateInstance(nil)
+	defer instance.Drop()
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	adapter.Drop()
+
+	oldHeadless, oldFrames, oldOut := *headlessFlag, *framesFlag, *outFlag
+	*headlessFlag, *framesFlag, *outFlag = true, frames, t.TempDir()
+	t.Cleanup(func() {
+		*headlessFlag, *framesFlag, *outFlag = oldHeadless, oldFrames, oldOut
+	})
+}
+
+func TestLeakReport(t *testing.T) {
+	headless(t, 1)
+
+	var b bytes.Buffer
+	oldTrack, oldStderr := trackLeaks, stderr
+	trackLeaks, stderr = true, &b
+	defer func() { trackLeaks, stderr = oldTrack, oldStderr }()
+
+	if err := Run(&leakyApp{}, Options{Width: 16, Height: 16}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := b.String()
+	if n := strings.Count(report, "leak: *wgpu.Buffer not dropped"); n != 1 {
+		t.Fatalf("report has %d leaked buffers, want 1:\n%s", n, report)
+	}
+	if !strings.Contains(report, "framework.(*leakyApp).Init") {
+		t.Errorf("report doesn't point at leakyApp.Init:\n%s", report)
+	}
+	if strings.Contains(report, "dropped twice") {
+		t.Errorf("report has double drops:\n%s", report)
+	}
+}
+
+func TestStatsReport(t *testing.T) {
+	headless(t, 3)
+
+	var b bytes.Buffer
+	oldStats, oldStderr := *statsFlag, stderr
+	*statsFlag, stderr = time.Hour, &b
+	defer func() { *statsFlag, stderr = oldStats, oldStderr }()
+
+	if err := Run(&leakyApp{}, Options{Width: 16, Height: 16}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the report on exit, the interval never passes
+	report := b.String()
+	if strings.Count(report, " fps\n") != 1 {
+		t.Fatalf("got %q, want one report", report)
+	}
+	for _, section := range []string{"frame", "update", "render"} {
+		if !strings.Contains(report, "  "+section+" ") {
+			t.Errorf("report has no %s section:\n%s", section, report)
+		}
+	}
+}
+
+func TestRejectSampleCount(t *testing.T) {
+	old := cfg.SampleCount
+	cfg.SampleCount = 4
+	defer func() { cfg.SampleCount = old }()
+
+	// leakyApp renders with one sample, it never gets initialized
+	if err := Run(&leakyApp{}, Options{}); err == nil || !strings.Contains(err.Error(), "-msaa 4") {
+		t.Fatalf("got error %v, want -msaa 4 to be rejected", err)
+	}
+}
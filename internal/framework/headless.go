@@ -0,0 +1,170 @@
+package framework
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/capture"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// headlessFrameTime is the real frame time the Clock is advanced by when
+// running headless, so that the output doesn't depend on how fast the
+// frames are rendered.
+const headlessFrameTime = time.Second / 60
+
+func runHeadless(app App, opts Options) error {
+	window, err := windowing.New("headless", windowing.Options{
+		Title:  opts.Title,
+		Width:  opts.Width,
+		Height: opts.Height,
+	})
+	if err != nil {
+		return err
+	}
+	defer window.Destroy()
+
+	if err := os.MkdirAll(*outFlag, 0o755); err != nil {
+		return err
+	}
+
+	r, err := newHeadlessRunner(window)
+	if err != nil {
+		return err
+	}
+	defer r.destroy()
+	if err := cfg.CheckSampleCount(r.ctx.Device, r.ctx.Config.Format); err != nil {
+		return err
+	}
+	r.ctx.SampleCount = opts.SampleCount
+
+	if err := app.Init(&r.ctx); err != nil {
+		app.Destroy()
+		return err
+	}
+	defer app.Destroy()
+	r.app = app
+
+	for frame := 0; frame < *framesFlag && window.Poll(); frame++ {
+		span := r.ctx.Trace.Begin("Frame").Arg("frame", frame)
+
+		now := time.Now()
+		r.ctx.Shaders.Poll(now)
+		r.ctx.Profile.Frame(now)
+		update := r.ctx.Trace.Begin("Update")
+		app.Update(r.ctx.Clock.Advance(headlessFrameTime))
+		update.End()
+		r.ctx.Profile.Add("update", time.Since(now))
+
+		img, err := r.renderHeadless()
+		if err != nil {
+			return err
+		}
+
+		save := r.ctx.Trace.Begin("Save PNG")
+		path := filepath.Join(*outFlag, fmt.Sprintf("frame-%04d.png", frame))
+		err = capture.SavePNG(path, img)
+		save.End()
+		span.End()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newHeadlessRunner(window windowing.Window) (r *runner, err error) {
+	defer func() {
+		if err != nil {
+			r.destroy()
+			r = nil
+		}
+	}()
+	r = &runner{window: window}
+	r.ctx.Profile = newProfile()
+	r.ctx.Clock = newClock()
+	r.ctx.Trace = cfg.NewTracer()
+	if trackLeaks {
+		r.ctx.Leaks = leak.NewTracker()
+	}
+
+	r.ctx.Instance = wgpu.CreateInstance(cfg.InstanceDescriptor())
+
+	err = r.requestDevice()
+	if err != nil {
+		return r, err
+	}
+
+	width, height := window.Size()
+	r.ctx.Config = &wgpu.SwapChainDescriptor{
+		Usage:       wgpu.TextureUsage_RenderAttachment | wgpu.TextureUsage_CopySrc,
+		Format:      capture.Format,
+		Width:       width,
+		Height:      height,
+		PresentMode: wgpu.PresentMode_Fifo,
+	}
+
+	r.target, err = r.ctx.Device.CreateTexture(&wgpu.TextureDescriptor{
+		Label: "Headless Target",
+		Size: wgpu.Extent3D{
+			Width:              width,
+			Height:             height,
+			DepthOrArrayLayers: 1,
+		},
+		MipLevelCount: 1,
+		SampleCount:   1,
+		Dimension:     wgpu.TextureDimension_2D,
+		Format:        r.ctx.Config.Format,
+		Usage:         r.ctx.Config.Usage,
+	})
+	if err != nil {
+		return r, err
+	}
+
+	r.reader, err = capture.NewReader(r.ctx.Device, width, height)
+	if err != nil {
+		return r, err
+	}
+	r.reader.Trace = r.ctx.Trace
+
+	return r, nil
+}
+
+// renderHeadless always waits for the GPU to read the frame back, that wait
+// is part of the "render" section.
+func (r *runner) renderHeadless() (*image.NRGBA, error) {
+	start := time.Now()
+	defer func() { r.ctx.Profile.Add("render", time.Since(start)) }()
+
+	view := r.target.CreateView(nil)
+	defer view.Drop()
+
+	encode := r.ctx.Trace.Begin("Encode")
+	encoder, err := r.ctx.Device.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{
+		Label: "Command Encoder",
+	})
+	if err != nil {
+		encode.End()
+		return nil, err
+	}
+
+	r.app.Render(view, encoder)
+	r.reader.Copy(encoder, r.target)
+	commands := encoder.Finish(nil)
+	encode.End()
+
+	submit := r.ctx.Trace.Begin("Submit")
+	index := r.ctx.Queue.Submit(commands)
+	submit.End()
+	if handler, ok := r.app.(SubmitHandler); ok {
+		handler.Submitted(index)
+	}
+
+	return r.reader.Read(index)
+}
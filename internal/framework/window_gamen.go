@@ -0,0 +1,5 @@
+//go:build (linux || windows) && !nowindow
+
+package framework
+
+import _ "github.com/rajveermalviya/go-webgpu-examples/internal/windowing/gamenwindow"
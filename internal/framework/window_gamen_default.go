@@ -0,0 +1,5 @@
+//go:build (android || ((linux || windows) && noglfw)) && !nowindow
+
+package framework
+
+const defaultWindow = "gamen"
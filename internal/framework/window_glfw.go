@@ -0,0 +1,7 @@
+//go:build !android && !noglfw && !nowindow
+
+package framework
+
+import _ "github.com/rajveermalviya/go-webgpu-examples/internal/windowing/glfwwindow"
+
+const defaultWindow = "glfw"
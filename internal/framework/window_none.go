@@ -0,0 +1,7 @@
+//go:build nowindow
+
+package framework
+
+// Built with the nowindow tag neither windowing backend is linked in, so
+// the examples need no cgo windowing libraries and can only run headless.
+const defaultWindow = "headless"
@@ -0,0 +1,7 @@
+//go:build !android && !linux && !windows && noglfw && !nowindow
+
+package framework
+
+// gamen only supports linux, windows and android, so there is no window
+// backend left with noglfw. This fails the build instead of every run.
+const defaultWindow = noglfwNeedsLinuxWindowsOrAndroid
@@ -0,0 +1,192 @@
+package glm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Color is an RGBA color with components in [0, 1]. Whether it holds
+// linear or sRGB-encoded values is up to the caller; use ToLinear and
+// ToSRGB to convert between the two.
+type Color[T float] struct {
+	R, G, B, A T
+}
+
+// SRGBToLinear decodes a single sRGB-encoded component.
+func SRGBToLinear[T float](c T) T {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return T(math.Pow((float64(c)+0.055)/1.055, 2.4))
+}
+
+// LinearToSRGB encodes a single linear component.
+func LinearToSRGB[T float](c T) T {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return T(1.055*math.Pow(float64(c), 1/2.4) - 0.055)
+}
+
+// ToLinear decodes an sRGB color. Alpha is always linear and kept as is.
+func (c Color[T]) ToLinear() Color[T] {
+	return Color[T]{SRGBToLinear(c.R), SRGBToLinear(c.G), SRGBToLinear(c.B), c.A}
+}
+
+// ToSRGB encodes a linear color. Alpha is always linear and kept as is.
+func (c Color[T]) ToSRGB() Color[T] {
+	return Color[T]{LinearToSRGB(c.R), LinearToSRGB(c.G), LinearToSRGB(c.B), c.A}
+}
+
+func (c Color[T]) Premultiply() Color[T] {
+	return Color[T]{c.R * c.A, c.G * c.A, c.B * c.A, c.A}
+}
+
+func (c Color[T]) Unpremultiply() Color[T] {
+	if c.A == 0 {
+		return Color[T]{}
+	}
+	return Color[T]{c.R / c.A, c.G / c.A, c.B / c.A, c.A}
+}
+
+// ToWGPU converts to a wgpu.Color without any color space conversion. Clear
+// values are interpreted as linear, even for *Srgb render targets, so
+// sRGB-authored colors must go through ToLinear first.
+func (c Color[T]) ToWGPU() wgpu.Color {
+	return wgpu.Color{R: float64(c.R), G: float64(c.G), B: float64(c.B), A: float64(c.A)}
+}
+
+// ColorFromHSV converts hue in degrees, saturation and value in [0, 1].
+func ColorFromHSV[T float](h, s, v, a T) Color[T] {
+	c := v * s
+	return hueToColor(h, c, v-c, a)
+}
+
+// HSV returns hue in degrees, saturation and value in [0, 1].
+func (c Color[T]) HSV() (h, s, v T) {
+	max, min := c.maxMin()
+	v = max
+	if max > 0 {
+		s = (max - min) / max
+	}
+	return c.hue(max, min), s, v
+}
+
+// ColorFromHSL converts hue in degrees, saturation and lightness in [0, 1].
+func ColorFromHSL[T float](h, s, l, a T) Color[T] {
+	c := (1 - T(math.Abs(float64(2*l-1)))) * s
+	return hueToColor(h, c, l-c/2, a)
+}
+
+// HSL returns hue in degrees, saturation and lightness in [0, 1].
+func (c Color[T]) HSL() (h, s, l T) {
+	max, min := c.maxMin()
+	l = (max + min) / 2
+	if d := max - min; d > 0 {
+		s = d / (1 - T(math.Abs(float64(2*l-1))))
+	}
+	return c.hue(max, min), s, l
+}
+
+// ParseHexColor parses "#rgb", "#rgba", "#rrggbb" or "#rrggbbaa", with or
+// without the leading '#'. The components are returned as written, which for
+// colors picked from design tools usually means sRGB-encoded.
+func ParseHexColor[T float](s string) (Color[T], error) {
+	hex := strings.TrimPrefix(s, "#")
+
+	switch len(hex) {
+	case 3, 4:
+		expanded := make([]byte, 0, 2*len(hex))
+		for i := 0; i < len(hex); i++ {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	case 6, 8:
+	default:
+		return Color[T]{}, fmt.Errorf("glm: invalid hex color %q", s)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color[T]{}, fmt.Errorf("glm: invalid hex color %q", s)
+	}
+
+	return Color[T]{
+		R: T((v>>24)&0xff) / 255,
+		G: T((v>>16)&0xff) / 255,
+		B: T((v>>8)&0xff) / 255,
+		A: T(v&0xff) / 255,
+	}, nil
+}
+
+// Hex formats the color as "#rrggbbaa", clamping components to [0, 1].
+func (c Color[T]) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", toByte(c.R), toByte(c.G), toByte(c.B), toByte(c.A))
+}
+
+func toByte[T float](c T) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, float64(c))) * 255))
+}
+
+func (c Color[T]) maxMin() (max, min T) {
+	max = T(math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B))))
+	min = T(math.Min(float64(c.R), math.Min(float64(c.G), float64(c.B))))
+	return max, min
+}
+
+func (c Color[T]) hue(max, min T) T {
+	d := max - min
+	if d == 0 {
+		return 0
+	}
+
+	var h T
+	switch max {
+	case c.R:
+		h = (c.G - c.B) / d
+		if h < 0 {
+			h += 6
+		}
+	case c.G:
+		h = (c.B-c.R)/d + 2
+	default:
+		h = (c.R-c.G)/d + 4
+	}
+	return h * 60
+}
+
+// hueToColor builds a color from hue in degrees, chroma c and the offset m
+// added to every component.
+func hueToColor[T float](h, c, m, a T) Color[T] {
+	h = T(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+	hp := h / 60
+	x := c * (1 - T(math.Abs(math.Mod(float64(hp), 2)-1)))
+
+	var r, g, b T
+	switch {
+	case hp < 1:
+		r, g, b = c, x, 0
+	case hp < 2:
+		r, g, b = x, c, 0
+	case hp < 3:
+		r, g, b = 0, c, x
+	case hp < 4:
+		r, g, b = 0, x, c
+	case hp < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return Color[T]{r + m, g + m, b + m, a}
+}
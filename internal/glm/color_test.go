@@ -0,0 +1,160 @@
+package glm
+
+import (
+	"math"
+	"testing"
+)
+
+func near[T float](a, b T) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func nearColor[T float](a, b Color[T]) bool {
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+func TestSRGB(t *testing.T) {
+	for _, test := range []struct{ srgb, linear float64 }{
+		{0, 0},
+		{1, 1},
+		{0.04045, 0.04045 / 12.92},
+		{0.5, 0.214041140482},
+		{0.735356983052, 0.5},
+	} {
+		if got := SRGBToLinear(test.srgb); !near(got, test.linear) {
+			t.Errorf("SRGBToLinear(%v) = %v, want %v", test.srgb, got, test.linear)
+		}
+		if got := LinearToSRGB(test.linear); !near(got, test.srgb) {
+			t.Errorf("LinearToSRGB(%v) = %v, want %v", test.linear, got, test.srgb)
+		}
+	}
+
+	for i := 0; i <= 255; i++ {
+		c := float32(i) / 255
+		if got := LinearToSRGB(SRGBToLinear(c)); !near(got, c) {
+			t.Errorf("LinearToSRGB(SRGBToLinear(%v)) = %v", c, got)
+		}
+	}
+
+	c := Color[float64]{0.2, 0.5, 0.9, 0.3}
+	if got := c.ToLinear().ToSRGB(); !nearColor(got, c) {
+		t.Errorf("round trip of %v gives %v", c, got)
+	}
+	if got := c.ToLinear().A; got != c.A {
+		t.Errorf("ToLinear changed alpha to %v", got)
+	}
+}
+
+func TestHSV(t *testing.T) {
+	for _, test := range []struct {
+		c       Color[float64]
+		h, s, v float64
+	}{
+		{Color[float64]{1, 0, 0, 1}, 0, 1, 1},
+		{Color[float64]{0, 1, 0, 1}, 120, 1, 1},
+		{Color[float64]{0, 0, 1, 1}, 240, 1, 1},
+		{Color[float64]{1, 1, 0, 1}, 60, 1, 1},
+		{Color[float64]{0.5, 0.5, 0.5, 1}, 0, 0, 0.5},
+		{Color[float64]{0, 0, 0, 1}, 0, 0, 0},
+	} {
+		h, s, v := test.c.HSV()
+		if !near(h, test.h) || !near(s, test.s) || !near(v, test.v) {
+			t.Errorf("%v.HSV() = %v, %v, %v, want %v, %v, %v", test.c, h, s, v, test.h, test.s, test.v)
+		}
+		if got := ColorFromHSV(test.h, test.s, test.v, 1); !nearColor(got, test.c) {
+			t.Errorf("ColorFromHSV(%v, %v, %v) = %v, want %v", test.h, test.s, test.v, got, test.c)
+		}
+	}
+
+	// hues wrap around
+	if got, want := ColorFromHSV(-120.0, 1, 1, 1), ColorFromHSV(240.0, 1, 1, 1); !nearColor(got, want) {
+		t.Errorf("ColorFromHSV(-120) = %v, want %v", got, want)
+	}
+}
+
+func TestHSL(t *testing.T) {
+	for _, test := range []struct {
+		c       Color[float64]
+		h, s, l float64
+	}{
+		{Color[float64]{1, 0, 0, 1}, 0, 1, 0.5},
+		{Color[float64]{0, 0.5, 0, 1}, 120, 1, 0.25},
+		{Color[float64]{0.75, 0.75, 1, 1}, 240, 1, 0.875},
+		{Color[float64]{1, 1, 1, 1}, 0, 0, 1},
+	} {
+		h, s, l := test.c.HSL()
+		if !near(h, test.h) || !near(s, test.s) || !near(l, test.l) {
+			t.Errorf("%v.HSL() = %v, %v, %v, want %v, %v, %v", test.c, h, s, l, test.h, test.s, test.l)
+		}
+		if got := ColorFromHSL(test.h, test.s, test.l, 1); !nearColor(got, test.c) {
+			t.Errorf("ColorFromHSL(%v, %v, %v) = %v, want %v", test.h, test.s, test.l, got, test.c)
+		}
+	}
+}
+
+func TestHSVHSLRoundTrip(t *testing.T) {
+	s := NewSampler[float64](1)
+	for i := 0; i < 1000; i++ {
+		c := Color[float64]{s.Float(), s.Float(), s.Float(), s.Float()}
+
+		h, sat, v := c.HSV()
+		if got := ColorFromHSV(h, sat, v, c.A); !nearColor(got, c) {
+			t.Fatalf("HSV round trip of %v gives %v", c, got)
+		}
+		h, sat, l := c.HSL()
+		if got := ColorFromHSL(h, sat, l, c.A); !nearColor(got, c) {
+			t.Fatalf("HSL round trip of %v gives %v", c, got)
+		}
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		want Color[float64]
+		err  bool
+	}{
+		{s: "#ff0000", want: Color[float64]{1, 0, 0, 1}},
+		{s: "00ff00", want: Color[float64]{0, 1, 0, 1}},
+		{s: "#0000ff80", want: Color[float64]{0, 0, 1, 128.0 / 255}},
+		{s: "#f80", want: Color[float64]{1, 0x88 / 255.0, 0, 1}},
+		{s: "#f80c", want: Color[float64]{1, 0x88 / 255.0, 0, 0xcc / 255.0}},
+		{s: "#FFFFFF", want: Color[float64]{1, 1, 1, 1}},
+		{s: "", err: true},
+		{s: "#", err: true},
+		{s: "#12345", err: true},
+		{s: "#ggg", err: true},
+		{s: "#1234567890", err: true},
+	} {
+		got, err := ParseHexColor[float64](test.s)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseHexColor(%q) = %v, want an error", test.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseHexColor(%q): %v", test.s, err)
+			continue
+		}
+		if !nearColor(got, test.want) {
+			t.Errorf("ParseHexColor(%q) = %v, want %v", test.s, got, test.want)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	for _, s := range []string{"#000000ff", "#ff8000cc", "#12345678"} {
+		c, err := ParseHexColor[float32](s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Hex(); got != s {
+			t.Errorf("Hex() of %s = %s", s, got)
+		}
+	}
+
+	if got := (Color[float64]{2, -1, 0.5, 1}).Hex(); got != "#ff0080ff" {
+		t.Errorf("Hex() = %s, want clamped #ff0080ff", got)
+	}
+}
@@ -0,0 +1,114 @@
+package glm
+
+import "sort"
+
+// Bezier evaluates the cubic Bezier curve with control points p0..p3.
+func Bezier[T float](p0, p1, p2, p3 Vec3[T], t T) Vec3[T] {
+	u := 1 - t
+	return p0.MulScalar(u * u * u).
+		Add(p1.MulScalar(3 * u * u * t)).
+		Add(p2.MulScalar(3 * u * t * t)).
+		Add(p3.MulScalar(t * t * t))
+}
+
+// Hermite evaluates the cubic Hermite curve from p0 to p1 with tangents m0
+// and m1.
+func Hermite[T float](p0, m0, p1, m1 Vec3[T], t T) Vec3[T] {
+	t2 := t * t
+	t3 := t2 * t
+	return p0.MulScalar(2*t3 - 3*t2 + 1).
+		Add(m0.MulScalar(t3 - 2*t2 + t)).
+		Add(p1.MulScalar(-2*t3 + 3*t2)).
+		Add(m1.MulScalar(t3 - t2))
+}
+
+// CatmullRom evaluates the uniform Catmull-Rom segment between p1 and p2.
+func CatmullRom[T float](p0, p1, p2, p3 Vec3[T], t T) Vec3[T] {
+	return Hermite(p1, p2.Sub(p0).MulScalar(0.5), p2, p3.Sub(p1).MulScalar(0.5), t)
+}
+
+// CatmullRomSpline passes through every point. The end points are
+// duplicated so the curve starts at the first point and ends at the last.
+type CatmullRomSpline[T float] struct {
+	Points []Vec3[T]
+}
+
+// At evaluates the spline with t in [0, 1] spanning all segments.
+func (s CatmullRomSpline[T]) At(t T) Vec3[T] {
+	n := len(s.Points)
+	switch n {
+	case 0:
+		return Vec3[T]{}
+	case 1:
+		return s.Points[0]
+	}
+
+	segments := n - 1
+	f := t * T(segments)
+	i := int(f)
+	if i < 0 {
+		i = 0
+	}
+	if i >= segments {
+		i = segments - 1
+	}
+	local := f - T(i)
+
+	p := func(i int) Vec3[T] {
+		return s.Points[clampInt(i, 0, n-1)]
+	}
+	return CatmullRom(p(i-1), p(i), p(i+1), p(i+2), local)
+}
+
+// ArcLength maps distance travelled along a curve back to the curve
+// parameter, so a path can be followed at constant speed.
+type ArcLength[T float] struct {
+	params    []T
+	distances []T
+}
+
+// NewArcLength samples curve at the given number of uniform steps over
+// t in [0, 1].
+func NewArcLength[T float](curve func(t T) Vec3[T], samples int) *ArcLength[T] {
+	if samples < 1 {
+		samples = 1
+	}
+
+	a := &ArcLength[T]{
+		params:    make([]T, samples+1),
+		distances: make([]T, samples+1),
+	}
+
+	prev := curve(0)
+	for i := 1; i <= samples; i++ {
+		t := T(i) / T(samples)
+		p := curve(t)
+		a.params[i] = t
+		a.distances[i] = a.distances[i-1] + p.Sub(prev).Magnitude()
+		prev = p
+	}
+
+	return a
+}
+
+func (a *ArcLength[T]) Length() T {
+	return a.distances[len(a.distances)-1]
+}
+
+// Param returns the curve parameter at distance d along the curve.
+func (a *ArcLength[T]) Param(d T) T {
+	if d <= 0 {
+		return 0
+	}
+	if d >= a.Length() {
+		return 1
+	}
+
+	i := sort.Search(len(a.distances), func(i int) bool { return a.distances[i] >= d })
+	d0, d1 := a.distances[i-1], a.distances[i]
+	t0, t1 := a.params[i-1], a.params[i]
+	if d1 == d0 {
+		return t0
+	}
+	return t0 + (t1-t0)*(d-d0)/(d1-d0)
+}
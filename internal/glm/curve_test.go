@@ -0,0 +1,100 @@
+package glm
+
+import "testing"
+
+func nearVec3[T float](a, b Vec3[T]) bool {
+	return near(a[0], b[0]) && near(a[1], b[1]) && near(a[2], b[2])
+}
+
+func TestCurveEndpoints(t *testing.T) {
+	p0 := Vec3[float64]{0, 0, 0}
+	p1 := Vec3[float64]{1, 2, 0}
+	p2 := Vec3[float64]{3, 2, 1}
+	p3 := Vec3[float64]{4, 0, 1}
+
+	for _, test := range []struct {
+		name string
+		got  Vec3[float64]
+		want Vec3[float64]
+	}{
+		{"Bezier(0)", Bezier(p0, p1, p2, p3, 0), p0},
+		{"Bezier(1)", Bezier(p0, p1, p2, p3, 1), p3},
+		// (p0 + 3 p1 + 3 p2 + p3) / 8
+		{"Bezier(0.5)", Bezier(p0, p1, p2, p3, 0.5), Vec3[float64]{2, 1.5, 0.5}},
+		{"Hermite(0)", Hermite(p0, p1, p3, p2, 0), p0},
+		{"Hermite(1)", Hermite(p0, p1, p3, p2, 1), p3},
+		// (p0 + p1) / 2 + (m0 - m1) / 8
+		{"Hermite(0.5)", Hermite(p0, p1, p3, p2, 0.5), Vec3[float64]{1.75, 0, 0.375}},
+		{"CatmullRom(0)", CatmullRom(p0, p1, p2, p3, 0), p1},
+		{"CatmullRom(1)", CatmullRom(p0, p1, p2, p3, 1), p2},
+	} {
+		if !nearVec3(test.got, test.want) {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestCatmullRomSplineKnots(t *testing.T) {
+	s := CatmullRomSpline[float32]{Points: []Vec3[float32]{
+		{0, 0, 0},
+		{1, 1, 0},
+		{2, 0, 1},
+		{4, 2, 2},
+		{5, 5, 0},
+	}}
+
+	segments := float32(len(s.Points) - 1)
+	for i, p := range s.Points {
+		if got := s.At(float32(i) / segments); !nearVec3(got, p) {
+			t.Errorf("At(%d/%v) = %v, want knot %v", i, segments, got, p)
+		}
+	}
+
+	if got := (CatmullRomSpline[float32]{}).At(0.5); got != (Vec3[float32]{}) {
+		t.Errorf("empty spline At(0.5) = %v, want zero", got)
+	}
+	one := CatmullRomSpline[float32]{Points: s.Points[:1]}
+	if got := one.At(0.5); got != s.Points[0] {
+		t.Errorf("single point spline At(0.5) = %v, want %v", got, s.Points[0])
+	}
+}
+
+func TestArcLength(t *testing.T) {
+	// a straight line traversed at non-uniform speed
+	p0 := Vec3[float64]{0, 0, 0}
+	p3 := Vec3[float64]{10, 0, 0}
+	curve := func(t float64) Vec3[float64] {
+		return Bezier(p0, p0, p0, p3, t)
+	}
+	a := NewArcLength(curve, 256)
+
+	if got := a.Length(); !near(got, 10) {
+		t.Errorf("Length() = %v, want 10", got)
+	}
+	if got := a.Param(0); got != 0 {
+		t.Errorf("Param(0) = %v, want 0", got)
+	}
+	if got := a.Param(a.Length()); got != 1 {
+		t.Errorf("Param(Length()) = %v, want 1", got)
+	}
+	if got := a.Param(-1); got != 0 {
+		t.Errorf("Param(-1) = %v, want 0", got)
+	}
+	if got := a.Param(11); got != 1 {
+		t.Errorf("Param(11) = %v, want 1", got)
+	}
+
+	prev := a.Param(0)
+	for d := 0.1; d < a.Length(); d += 0.1 {
+		param := a.Param(d)
+		if param < prev {
+			t.Fatalf("Param(%v) = %v, less than %v before it", d, param, prev)
+		}
+		prev = param
+
+		// the curve is at x = d, up to the sampling error
+		if x := curve(param)[0]; x < d-0.05 || x > d+0.05 {
+			t.Errorf("curve(Param(%v)) is at x = %v", d, x)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package glm
+
+import "math"
+
+// Easing functions map t in [0, 1] to an eased value with f(0) = 0 and
+// f(1) = 1. See https://easings.net for their shapes.
+
+func EaseLinear[T float](t T) T {
+	return t
+}
+
+func EaseInQuad[T float](t T) T {
+	return t * t
+}
+
+func EaseOutQuad[T float](t T) T {
+	return 1 - (1-t)*(1-t)
+}
+
+func EaseInOutQuad[T float](t T) T {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	u := -2*t + 2
+	return 1 - u*u/2
+}
+
+func EaseInCubic[T float](t T) T {
+	return t * t * t
+}
+
+func EaseOutCubic[T float](t T) T {
+	u := 1 - t
+	return 1 - u*u*u
+}
+
+func EaseInOutCubic[T float](t T) T {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	u := -2*t + 2
+	return 1 - u*u*u/2
+}
+
+func EaseInSine[T float](t T) T {
+	return 1 - T(math.Cos(float64(t)*math.Pi/2))
+}
+
+func EaseOutSine[T float](t T) T {
+	return T(math.Sin(float64(t) * math.Pi / 2))
+}
+
+func EaseInOutSine[T float](t T) T {
+	return -(T(math.Cos(math.Pi*float64(t))) - 1) / 2
+}
+
+func EaseInExpo[T float](t T) T {
+	if t <= 0 {
+		return 0
+	}
+	return T(math.Pow(2, 10*float64(t)-10))
+}
+
+func EaseOutExpo[T float](t T) T {
+	if t >= 1 {
+		return 1
+	}
+	return 1 - T(math.Pow(2, -10*float64(t)))
+}
+
+func EaseInOutExpo[T float](t T) T {
+	switch {
+	case t <= 0:
+		return 0
+	case t >= 1:
+		return 1
+	case t < 0.5:
+		return T(math.Pow(2, 20*float64(t)-10)) / 2
+	default:
+		return (2 - T(math.Pow(2, -20*float64(t)+10))) / 2
+	}
+}
+
+func EaseOutBack[T float](t T) T {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	u := t - 1
+	return 1 + c3*u*u*u + c1*u*u
+}
+
+func EaseOutElastic[T float](t T) T {
+	switch {
+	case t <= 0:
+		return 0
+	case t >= 1:
+		return 1
+	}
+	const c4 = 2 * math.Pi / 3
+	return T(math.Pow(2, -10*float64(t))*math.Sin((float64(t)*10-0.75)*c4)) + 1
+}
+
+func EaseOutBounce[T float](t T) T {
+	const n1 = 7.5625
+	const d1 = 2.75
+
+	switch {
+	case t < 1/d1:
+		return n1 * t * t
+	case t < 2/d1:
+		t -= 1.5 / d1
+		return n1*t*t + 0.75
+	case t < 2.5/d1:
+		t -= 2.25 / d1
+		return n1*t*t + 0.9375
+	default:
+		t -= 2.625 / d1
+		return n1*t*t + 0.984375
+	}
+}
@@ -0,0 +1,38 @@
+package glm
+
+import "testing"
+
+func TestEasingEndpoints(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		f    func(float64) float64
+		half float64
+	}{
+		{"EaseLinear", EaseLinear[float64], 0.5},
+		{"EaseInQuad", EaseInQuad[float64], 0.25},
+		{"EaseOutQuad", EaseOutQuad[float64], 0.75},
+		{"EaseInOutQuad", EaseInOutQuad[float64], 0.5},
+		{"EaseInCubic", EaseInCubic[float64], 0.125},
+		{"EaseOutCubic", EaseOutCubic[float64], 0.875},
+		{"EaseInOutCubic", EaseInOutCubic[float64], 0.5},
+		{"EaseInSine", EaseInSine[float64], 0.292893218813},
+		{"EaseOutSine", EaseOutSine[float64], 0.707106781187},
+		{"EaseInOutSine", EaseInOutSine[float64], 0.5},
+		{"EaseInExpo", EaseInExpo[float64], 0.03125},
+		{"EaseOutExpo", EaseOutExpo[float64], 0.96875},
+		{"EaseInOutExpo", EaseInOutExpo[float64], 0.5},
+		{"EaseOutBack", EaseOutBack[float64], 1.0876975},
+		{"EaseOutElastic", EaseOutElastic[float64], 1.015625},
+		{"EaseOutBounce", EaseOutBounce[float64], 0.765625},
+	} {
+		if got := test.f(0); !near(got, 0) {
+			t.Errorf("%s(0) = %v, want 0", test.name, got)
+		}
+		if got := test.f(1); !near(got, 1) {
+			t.Errorf("%s(1) = %v, want 1", test.name, got)
+		}
+		if got := test.f(0.5); !near(got, test.half) {
+			t.Errorf("%s(0.5) = %v, want %v", test.name, got, test.half)
+		}
+	}
+}
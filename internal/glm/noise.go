@@ -0,0 +1,357 @@
+package glm
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Noise generates coherent noise. Two Noise values created with the same
+// seed always produce the same output.
+type Noise[T float] struct {
+	perm [512]uint8
+	seed uint64
+}
+
+// NewNoise returns noise whose permutation table and feature points are
+// derived from seed.
+func NewNoise[T float](seed int64) *Noise[T] {
+	n := &Noise[T]{seed: uint64(seed)}
+
+	p := rand.New(rand.NewSource(seed)).Perm(256)
+	for i := range n.perm {
+		n.perm[i] = uint8(p[i&255])
+	}
+
+	return n
+}
+
+// Perlin2 returns improved Perlin noise in approximately [-1, 1].
+func (n *Noise[T]) Perlin2(x, y T) T {
+	return T(n.perlin2(float64(x), float64(y)))
+}
+
+// Perlin3 returns improved Perlin noise in approximately [-1, 1].
+func (n *Noise[T]) Perlin3(x, y, z T) T {
+	return T(n.perlin3(float64(x), float64(y), float64(z)))
+}
+
+// Simplex2 returns simplex noise in approximately [-1, 1].
+func (n *Noise[T]) Simplex2(x, y T) T {
+	return T(n.simplex2(float64(x), float64(y)))
+}
+
+// Simplex3 returns simplex noise in approximately [-1, 1].
+func (n *Noise[T]) Simplex3(x, y, z T) T {
+	return T(n.simplex3(float64(x), float64(y), float64(z)))
+}
+
+// Worley2 returns the distance to the nearest feature point (F1), with one
+// feature point per unit cell.
+func (n *Noise[T]) Worley2(x, y T) T {
+	return T(n.worley2(float64(x), float64(y)))
+}
+
+// Worley3 returns the distance to the nearest feature point (F1), with one
+// feature point per unit cell.
+func (n *Noise[T]) Worley3(x, y, z T) T {
+	return T(n.worley3(float64(x), float64(y), float64(z)))
+}
+
+// FBm2 sums octaves of noise, each scaled in frequency by lacunarity and in
+// amplitude by gain. The result is normalized to the range of noise.
+func FBm2[T float](noise func(x, y T) T, x, y T, octaves int, lacunarity, gain T) T {
+	var sum, norm T
+	freq, amp := T(1), T(1)
+	for i := 0; i < octaves; i++ {
+		sum += amp * noise(x*freq, y*freq)
+		norm += amp
+		freq *= lacunarity
+		amp *= gain
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// FBm3 sums octaves of noise, each scaled in frequency by lacunarity and in
+// amplitude by gain. The result is normalized to the range of noise.
+func FBm3[T float](noise func(x, y, z T) T, x, y, z T, octaves int, lacunarity, gain T) T {
+	var sum, norm T
+	freq, amp := T(1), T(1)
+	for i := 0; i < octaves; i++ {
+		sum += amp * noise(x*freq, y*freq, z*freq)
+		norm += amp
+		freq *= lacunarity
+		amp *= gain
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+func (n *Noise[T]) perlin2(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	X, Y := int(fx)&255, int(fy)&255
+	x, y = x-fx, y-fy
+	u, v := fade(x), fade(y)
+
+	p := &n.perm
+	aa := p[int(p[X])+Y]
+	ab := p[int(p[X])+Y+1]
+	ba := p[int(p[X+1])+Y]
+	bb := p[int(p[X+1])+Y+1]
+
+	return lerp(v,
+		lerp(u, grad2(aa, x, y), grad2(ba, x-1, y)),
+		lerp(u, grad2(ab, x, y-1), grad2(bb, x-1, y-1)),
+	)
+}
+
+func (n *Noise[T]) perlin3(x, y, z float64) float64 {
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	X, Y, Z := int(fx)&255, int(fy)&255, int(fz)&255
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	p := &n.perm
+	a := int(p[X]) + Y
+	aa := int(p[a]) + Z
+	ab := int(p[a+1]) + Z
+	b := int(p[X+1]) + Y
+	ba := int(p[b]) + Z
+	bb := int(p[b+1]) + Z
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad3(p[aa], x, y, z), grad3(p[ba], x-1, y, z)),
+			lerp(u, grad3(p[ab], x, y-1, z), grad3(p[bb], x-1, y-1, z)),
+		),
+		lerp(v,
+			lerp(u, grad3(p[aa+1], x, y, z-1), grad3(p[ba+1], x-1, y, z-1)),
+			lerp(u, grad3(p[ab+1], x, y-1, z-1), grad3(p[bb+1], x-1, y-1, z-1)),
+		),
+	)
+}
+
+var (
+	simplexF2 = 0.5 * (math.Sqrt(3) - 1)
+	simplexG2 = (3 - math.Sqrt(3)) / 6
+)
+
+const (
+	simplexF3 = 1.0 / 3.0
+	simplexG3 = 1.0 / 6.0
+)
+
+func (n *Noise[T]) simplex2(x, y float64) float64 {
+	s := (x + y) * simplexF2
+	i, j := math.Floor(x+s), math.Floor(y+s)
+	t := (i + j) * simplexG2
+	x0, y0 := x-(i-t), y-(j-t)
+
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+
+	x1, y1 := x0-float64(i1)+simplexG2, y0-float64(j1)+simplexG2
+	x2, y2 := x0-1+2*simplexG2, y0-1+2*simplexG2
+
+	p := &n.perm
+	ii, jj := int(i)&255, int(j)&255
+	gi0 := p[ii+int(p[jj])]
+	gi1 := p[ii+i1+int(p[jj+j1])]
+	gi2 := p[ii+1+int(p[jj+1])]
+
+	var n0, n1, n2 float64
+	if t0 := 0.5 - x0*x0 - y0*y0; t0 > 0 {
+		t0 *= t0
+		n0 = t0 * t0 * grad2(gi0, x0, y0)
+	}
+	if t1 := 0.5 - x1*x1 - y1*y1; t1 > 0 {
+		t1 *= t1
+		n1 = t1 * t1 * grad2(gi1, x1, y1)
+	}
+	if t2 := 0.5 - x2*x2 - y2*y2; t2 > 0 {
+		t2 *= t2
+		n2 = t2 * t2 * grad2(gi2, x2, y2)
+	}
+
+	return 70 * (n0 + n1 + n2)
+}
+
+func (n *Noise[T]) simplex3(x, y, z float64) float64 {
+	s := (x + y + z) * simplexF3
+	i, j, k := math.Floor(x+s), math.Floor(y+s), math.Floor(z+s)
+	t := (i + j + k) * simplexG3
+	x0, y0, z0 := x-(i-t), y-(j-t), z-(k-t)
+
+	var i1, j1, k1, i2, j2, k2 int
+	if x0 >= y0 {
+		switch {
+		case y0 >= z0:
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		case x0 >= z0:
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		default:
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else {
+		switch {
+		case y0 < z0:
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		case x0 < z0:
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		default:
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	x1, y1, z1 := x0-float64(i1)+simplexG3, y0-float64(j1)+simplexG3, z0-float64(k1)+simplexG3
+	x2, y2, z2 := x0-float64(i2)+2*simplexG3, y0-float64(j2)+2*simplexG3, z0-float64(k2)+2*simplexG3
+	x3, y3, z3 := x0-1+3*simplexG3, y0-1+3*simplexG3, z0-1+3*simplexG3
+
+	p := &n.perm
+	ii, jj, kk := int(i)&255, int(j)&255, int(k)&255
+	gi0 := p[ii+int(p[jj+int(p[kk])])]
+	gi1 := p[ii+i1+int(p[jj+j1+int(p[kk+k1])])]
+	gi2 := p[ii+i2+int(p[jj+j2+int(p[kk+k2])])]
+	gi3 := p[ii+1+int(p[jj+1+int(p[kk+1])])]
+
+	var n0, n1, n2, n3 float64
+	if t0 := 0.6 - x0*x0 - y0*y0 - z0*z0; t0 > 0 {
+		t0 *= t0
+		n0 = t0 * t0 * grad3(gi0, x0, y0, z0)
+	}
+	if t1 := 0.6 - x1*x1 - y1*y1 - z1*z1; t1 > 0 {
+		t1 *= t1
+		n1 = t1 * t1 * grad3(gi1, x1, y1, z1)
+	}
+	if t2 := 0.6 - x2*x2 - y2*y2 - z2*z2; t2 > 0 {
+		t2 *= t2
+		n2 = t2 * t2 * grad3(gi2, x2, y2, z2)
+	}
+	if t3 := 0.6 - x3*x3 - y3*y3 - z3*z3; t3 > 0 {
+		t3 *= t3
+		n3 = t3 * t3 * grad3(gi3, x3, y3, z3)
+	}
+
+	return 32 * (n0 + n1 + n2 + n3)
+}
+
+func (n *Noise[T]) worley2(x, y float64) float64 {
+	cx, cy := math.Floor(x), math.Floor(y)
+	best := math.Inf(1)
+
+	for dy := -1.0; dy <= 1; dy++ {
+		for dx := -1.0; dx <= 1; dx++ {
+			ix, iy := int64(cx+dx), int64(cy+dy)
+			px := cx + dx + n.cellPoint(ix, iy, 0, 0)
+			py := cy + dy + n.cellPoint(ix, iy, 0, 1)
+
+			d := (px-x)*(px-x) + (py-y)*(py-y)
+			if d < best {
+				best = d
+			}
+		}
+	}
+
+	return math.Sqrt(best)
+}
+
+func (n *Noise[T]) worley3(x, y, z float64) float64 {
+	cx, cy, cz := math.Floor(x), math.Floor(y), math.Floor(z)
+	best := math.Inf(1)
+
+	for dz := -1.0; dz <= 1; dz++ {
+		for dy := -1.0; dy <= 1; dy++ {
+			for dx := -1.0; dx <= 1; dx++ {
+				ix, iy, iz := int64(cx+dx), int64(cy+dy), int64(cz+dz)
+				px := cx + dx + n.cellPoint(ix, iy, iz, 0)
+				py := cy + dy + n.cellPoint(ix, iy, iz, 1)
+				pz := cz + dz + n.cellPoint(ix, iy, iz, 2)
+
+				d := (px-x)*(px-x) + (py-y)*(py-y) + (pz-z)*(pz-z)
+				if d < best {
+					best = d
+				}
+			}
+		}
+	}
+
+	return math.Sqrt(best)
+}
+
+// cellPoint returns one coordinate in [0, 1) of the feature point of a cell.
+func (n *Noise[T]) cellPoint(ix, iy, iz int64, axis uint64) float64 {
+	h := mix64(n.seed ^ axis*0x9e3779b97f4a7c15)
+	h = mix64(h ^ uint64(ix)*0xbf58476d1ce4e5b9)
+	h = mix64(h ^ uint64(iy)*0x94d049bb133111eb)
+	h = mix64(h ^ uint64(iz)*0xd6e8feb86659fd93)
+	return float64(h>>11) / (1 << 53)
+}
+
+// splitmix64 finalizer
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+func grad2(hash uint8, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+func grad3(hash uint8, x, y, z float64) float64 {
+	h := hash & 15
+
+	u := y
+	if h < 8 {
+		u = x
+	}
+
+	var v float64
+	switch {
+	case h < 4:
+		v = y
+	case h == 12 || h == 14:
+		v = x
+	default:
+		v = z
+	}
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
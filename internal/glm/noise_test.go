@@ -0,0 +1,105 @@
+package glm
+
+import (
+	"math"
+	"testing"
+)
+
+// The values below were produced by seed 1 and pin down the permutation
+// and hashing, a change to either shows up here.
+func TestNoiseReference(t *testing.T) {
+	n := NewNoise[float64](1)
+
+	for _, test := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"Perlin2", n.Perlin2(0.3, 0.7), 0.177865474080},
+		{"Perlin3", n.Perlin3(0.3, 0.7, 1.9), -0.062587670137},
+		{"Simplex2", n.Simplex2(0.3, 0.7), -0.674477876187},
+		{"Simplex3", n.Simplex3(0.3, 0.7, 1.9), 0.134833912626},
+		{"Worley2", n.Worley2(0.3, 0.7), 0.166339510652},
+		{"Worley3", n.Worley3(0.3, 0.7, 1.9), 0.308580002082},
+	} {
+		if math.Abs(test.got-test.want) > 1e-9 {
+			t.Errorf("%s = %.12f, want %.12f", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestNoiseDeterministic(t *testing.T) {
+	a, b, c := NewNoise[float32](42), NewNoise[float32](42), NewNoise[float32](43)
+
+	differs := false
+	for i := 0; i < 100; i++ {
+		x, y, z := float32(i)*0.37, float32(i)*0.11, float32(i)*0.53
+		for _, f := range []func(n *Noise[float32]) float32{
+			func(n *Noise[float32]) float32 { return n.Perlin2(x, y) },
+			func(n *Noise[float32]) float32 { return n.Perlin3(x, y, z) },
+			func(n *Noise[float32]) float32 { return n.Simplex2(x, y) },
+			func(n *Noise[float32]) float32 { return n.Simplex3(x, y, z) },
+			func(n *Noise[float32]) float32 { return n.Worley2(x, y) },
+			func(n *Noise[float32]) float32 { return n.Worley3(x, y, z) },
+		} {
+			if f(a) != f(b) {
+				t.Fatalf("same seed gives different noise at (%v, %v, %v)", x, y, z)
+			}
+			if f(a) != f(c) {
+				differs = true
+			}
+		}
+	}
+	if !differs {
+		t.Error("different seeds give the same noise")
+	}
+}
+
+func TestPerlinLattice(t *testing.T) {
+	n := NewNoise[float64](7)
+	for x := -3.0; x <= 3; x++ {
+		for y := -3.0; y <= 3; y++ {
+			if v := n.Perlin2(x, y); v != 0 {
+				t.Errorf("Perlin2(%v, %v) = %v, want 0", x, y, v)
+			}
+			if v := n.Perlin3(x, y, 2); v != 0 {
+				t.Errorf("Perlin3(%v, %v, 2) = %v, want 0", x, y, v)
+			}
+		}
+	}
+}
+
+func TestNoiseRange(t *testing.T) {
+	n := NewNoise[float64](3)
+	s := NewSampler[float64](3)
+	for i := 0; i < 10000; i++ {
+		x, y, z := s.Range(-50, 50), s.Range(-50, 50), s.Range(-50, 50)
+		for _, v := range []float64{n.Perlin2(x, y), n.Perlin3(x, y, z), n.Simplex2(x, y), n.Simplex3(x, y, z)} {
+			if v < -1.01 || v > 1.01 {
+				t.Fatalf("noise at (%v, %v, %v) = %v, outside [-1, 1]", x, y, z, v)
+			}
+		}
+		// the nearest feature point is at most a cell diagonal away
+		if v := n.Worley2(x, y); v < 0 || v > math.Sqrt2 {
+			t.Fatalf("Worley2(%v, %v) = %v", x, y, v)
+		}
+		if v := n.Worley3(x, y, z); v < 0 || v > math.Sqrt(3) {
+			t.Fatalf("Worley3(%v, %v, %v) = %v", x, y, z, v)
+		}
+	}
+}
+
+func TestFBm(t *testing.T) {
+	constant := func(x, y float64) float64 { return 0.5 }
+	if v := FBm2(constant, 1, 2, 5, 2, 0.5); math.Abs(v-0.5) > 1e-12 {
+		t.Errorf("FBm2 of a constant = %v, want 0.5", v)
+	}
+	if v := FBm2(constant, 1, 2, 0, 2, 0.5); v != 0 {
+		t.Errorf("FBm2 with 0 octaves = %v, want 0", v)
+	}
+
+	n := NewNoise[float64](1)
+	if got, want := FBm3(n.Perlin3, 0.3, 0.7, 1.9, 1, 2, 0.5), n.Perlin3(0.3, 0.7, 1.9); got != want {
+		t.Errorf("FBm3 with 1 octave = %v, want %v", got, want)
+	}
+}
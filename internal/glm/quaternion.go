@@ -25,3 +25,84 @@ func (lhs Quaternion[T]) Mul(rhs Quaternion[T]) Quaternion[T] {
 		},
 	}
 }
+
+func (lhs Quaternion[T]) Dot(rhs Quaternion[T]) T {
+	return lhs.S*rhs.S + lhs.V.Dot(rhs.V)
+}
+
+func (q Quaternion[T]) Conjugate() Quaternion[T] {
+	return Quaternion[T]{S: q.S, V: q.V.MulScalar(-1)}
+}
+
+func (q Quaternion[T]) Normalize() Quaternion[T] {
+	inv := 1 / T(math.Sqrt(float64(q.Dot(q))))
+	return Quaternion[T]{S: q.S * inv, V: q.V.MulScalar(inv)}
+}
+
+// Log returns the logarithm of a unit quaternion, a pure quaternion. The
+// angle comes from atan2 so it stays accurate near 0 and π, where acos of
+// q.S loses the small vector part. q = ±1 has no rotation axis and maps to
+// the zero vector.
+func (q Quaternion[T]) Log() Quaternion[T] {
+	sin := float64(q.V.Magnitude())
+	if sin == 0 {
+		return Quaternion[T]{}
+	}
+	theta := math.Atan2(sin, float64(q.S))
+	return Quaternion[T]{V: q.V.MulScalar(T(theta / sin))}
+}
+
+// Exp returns the exponential of a pure quaternion, a unit quaternion.
+func (q Quaternion[T]) Exp() Quaternion[T] {
+	theta := float64(q.V.Magnitude())
+	sin, cos := math.Sincos(theta)
+	if theta < 1e-6 {
+		return Quaternion[T]{S: T(cos), V: q.V}
+	}
+	return Quaternion[T]{S: T(cos), V: q.V.MulScalar(T(sin / theta))}
+}
+
+// Slerp interpolates along the shortest arc between two unit quaternions.
+func (lhs Quaternion[T]) Slerp(rhs Quaternion[T], t T) Quaternion[T] {
+	if lhs.Dot(rhs) < 0 {
+		rhs = Quaternion[T]{S: -rhs.S, V: rhs.V.MulScalar(-1)}
+	}
+	return lhs.slerp(rhs, t)
+}
+
+func (lhs Quaternion[T]) slerp(rhs Quaternion[T], t T) Quaternion[T] {
+	dot := float64(lhs.Dot(rhs))
+
+	var a, b float64
+	if math.Abs(dot) > 0.9995 {
+		// nearly parallel, fall back to normalized lerp
+		a, b = 1-float64(t), float64(t)
+	} else {
+		theta := math.Acos(math.Max(-1, math.Min(1, dot)))
+		sin := math.Sin(theta)
+		a = math.Sin((1-float64(t))*theta) / sin
+		b = math.Sin(float64(t)*theta) / sin
+	}
+
+	return Quaternion[T]{
+		S: lhs.S*T(a) + rhs.S*T(b),
+		V: lhs.V.MulScalar(T(a)).Add(rhs.V.MulScalar(T(b))),
+	}.Normalize()
+}
+
+// SquadControlPoint returns the inner control point for curr in a sequence
+// of keyframes prev, curr, next, as used by Squad.
+func SquadControlPoint[T float](prev, curr, next Quaternion[T]) Quaternion[T] {
+	inv := curr.Conjugate()
+	a := inv.Mul(next).Log()
+	b := inv.Mul(prev).Log()
+	sum := Quaternion[T]{V: a.V.Add(b.V).MulScalar(-0.25)}
+	return curr.Mul(sum.Exp())
+}
+
+// Squad smoothly interpolates between keyframes q1 and q2 using the control
+// points s1 and s2 from SquadControlPoint, giving C1 continuous rotation
+// across a sequence of keyframes.
+func Squad[T float](q1, q2, s1, s2 Quaternion[T], t T) Quaternion[T] {
+	return q1.slerp(q2, t).slerp(s1.slerp(s2, t), 2*t*(1-t))
+}
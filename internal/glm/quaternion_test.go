@@ -0,0 +1,110 @@
+package glm
+
+import (
+	"math"
+	"testing"
+)
+
+// nearRotation compares quaternions as rotations, q and -q are the same.
+func nearRotation[T float](a, b Quaternion[T]) bool {
+	if a.Dot(b) < 0 {
+		b = Quaternion[T]{S: -b.S, V: b.V.MulScalar(-1)}
+	}
+	return near(a.S, b.S) && nearVec3(a.V, b.V)
+}
+
+func rotationZ(deg float64) Quaternion[float64] {
+	return QuaternionFromAxisAngle(Vec3[float64]{0, 0, 1}, deg*math.Pi/180)
+}
+
+func TestSlerp(t *testing.T) {
+	y := Vec3[float64]{0, 1, 0}
+	q0 := QuaternionFromAxisAngle(y, 0)
+	q1 := QuaternionFromAxisAngle(y, math.Pi/2)
+	mid := QuaternionFromAxisAngle(y, math.Pi/4)
+
+	if got := q0.Slerp(q1, 0); !nearRotation(got, q0) {
+		t.Errorf("Slerp(0) = %v, want %v", got, q0)
+	}
+	if got := q0.Slerp(q1, 1); !nearRotation(got, q1) {
+		t.Errorf("Slerp(1) = %v, want %v", got, q1)
+	}
+	if got := q0.Slerp(q1, 0.5); !nearRotation(got, mid) {
+		t.Errorf("Slerp(0.5) = %v, want %v", got, mid)
+	}
+
+	// -q1 is the same rotation, Slerp takes the short way to it
+	neg := Quaternion[float64]{S: -q1.S, V: q1.V.MulScalar(-1)}
+	if got := q0.Slerp(neg, 0.5); !nearRotation(got, mid) {
+		t.Errorf("Slerp(0.5) towards -q1 = %v, want %v", got, mid)
+	}
+}
+
+func TestLogExp(t *testing.T) {
+	axis := Vec3[float64]{1, 2, -2}.Normalize()
+	for _, angle := range []float64{0, 1e-8, 0.3, math.Pi / 2, math.Pi, 3.5, 2*math.Pi - 1e-8} {
+		q := QuaternionFromAxisAngle(axis, angle)
+		if got := q.Log().Exp(); !near(got.S, q.S) || !nearVec3(got.V, q.V) {
+			t.Errorf("angle %v: Exp(Log(%v)) = %v", angle, q, got)
+		}
+	}
+
+	// Log of a rotation by angle about axis is axis * angle/2
+	for _, test := range []struct {
+		angle float64
+		want  Vec3[float64]
+	}{
+		{0, Vec3[float64]{}},
+		{math.Pi / 2, axis.MulScalar(math.Pi / 4)},
+		{math.Pi, axis.MulScalar(math.Pi / 2)},
+		// S is close to -1, the result is about π times the axis
+		{2*math.Pi - 1e-8, axis.MulScalar(math.Pi - 0.5e-8)},
+	} {
+		got := QuaternionFromAxisAngle(axis, test.angle).Log()
+		if got.S != 0 || !nearVec3(got.V, test.want) {
+			t.Errorf("angle %v: Log() = %v, want %v", test.angle, got, test.want)
+		}
+	}
+
+	// ±1 have no axis
+	for _, s := range []float64{1, -1} {
+		if got := (Quaternion[float64]{S: s}).Log(); got != (Quaternion[float64]{}) {
+			t.Errorf("Log() of %v = %v, want zero", s, got)
+		}
+	}
+}
+
+func TestSquad(t *testing.T) {
+	// keyframes evenly spaced about one axis, Squad reduces to Slerp
+	keys := []Quaternion[float64]{rotationZ(0), rotationZ(30), rotationZ(60), rotationZ(90)}
+	s1 := SquadControlPoint(keys[0], keys[1], keys[2])
+	s2 := SquadControlPoint(keys[1], keys[2], keys[3])
+
+	if !nearRotation(s1, keys[1]) || !nearRotation(s2, keys[2]) {
+		t.Errorf("control points %v, %v, want the keyframes %v, %v", s1, s2, keys[1], keys[2])
+	}
+	if got := Squad(keys[1], keys[2], s1, s2, 0); !nearRotation(got, keys[1]) {
+		t.Errorf("Squad(0) = %v, want %v", got, keys[1])
+	}
+	if got := Squad(keys[1], keys[2], s1, s2, 1); !nearRotation(got, keys[2]) {
+		t.Errorf("Squad(1) = %v, want %v", got, keys[2])
+	}
+	if got, want := Squad(keys[1], keys[2], s1, s2, 0.5), rotationZ(45); !nearRotation(got, want) {
+		t.Errorf("Squad(0.5) = %v, want %v", got, want)
+	}
+
+	// with keyframes about different axes it still hits the keyframes
+	x := QuaternionFromAxisAngle(Vec3[float64]{1, 0, 0}, math.Pi/2)
+	y := QuaternionFromAxisAngle(Vec3[float64]{0, 1, 0}, math.Pi/2)
+	s1 = SquadControlPoint(rotationZ(0), x, y)
+	s2 = SquadControlPoint(x, y, rotationZ(90))
+	if got := Squad(x, y, s1, s2, 0); !nearRotation(got, x) {
+		t.Errorf("Squad(0) = %v, want %v", got, x)
+	}
+	if got := Squad(x, y, s1, s2, 1); !nearRotation(got, y) {
+		t.Errorf("Squad(1) = %v, want %v", got, y)
+	}
+	if got := Squad(x, y, s1, s2, 0.5); !near(got.Dot(got), 1) {
+		t.Errorf("Squad(0.5) = %v is not a unit quaternion", got)
+	}
+}
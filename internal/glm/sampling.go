@@ -0,0 +1,136 @@
+package glm
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Sampler draws uniformly distributed samples. Two Samplers created with the
+// same seed always produce the same sequence.
+type Sampler[T float] struct {
+	rng *rand.Rand
+}
+
+func NewSampler[T float](seed int64) *Sampler[T] {
+	return &Sampler[T]{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Float returns a sample in [0, 1).
+func (s *Sampler[T]) Float() T {
+	for {
+		// rounding to float32 may produce 1
+		if f := T(s.rng.Float64()); f < 1 {
+			return f
+		}
+	}
+}
+
+// Range returns a sample in [min, max).
+func (s *Sampler[T]) Range(min, max T) T {
+	return min + s.Float()*(max-min)
+}
+
+func (s *Sampler[T]) InDisk(radius T) Vec2[T] {
+	r := radius * T(math.Sqrt(float64(s.Float())))
+	sin, cos := math.Sincos(2 * math.Pi * float64(s.Float()))
+	return Vec2[T]{r * T(cos), r * T(sin)}
+}
+
+func (s *Sampler[T]) OnSphere(radius T) Vec3[T] {
+	z := 1 - 2*float64(s.Float())
+	r := math.Sqrt(math.Max(0, 1-z*z))
+	sin, cos := math.Sincos(2 * math.Pi * float64(s.Float()))
+	return Vec3[T]{T(r * cos), T(r * sin), T(z)}.MulScalar(radius)
+}
+
+// InHemisphere returns a unit vector on the hemisphere around normal.
+func (s *Sampler[T]) InHemisphere(normal Vec3[T]) Vec3[T] {
+	v := s.OnSphere(1)
+	if v.Dot(normal) < 0 {
+		return v.MulScalar(-1)
+	}
+	return v
+}
+
+// PoissonDisk returns points in [0, width) x [0, height) that are at least
+// minDist apart, using Bridson's algorithm with k candidates per point.
+func (s *Sampler[T]) PoissonDisk(width, height, minDist T, k int) []Vec2[T] {
+	if width <= 0 || height <= 0 || minDist <= 0 {
+		return nil
+	}
+
+	cellSize := minDist / math.Sqrt2
+	gridWidth := int(math.Ceil(float64(width / cellSize)))
+	gridHeight := int(math.Ceil(float64(height / cellSize)))
+
+	grid := make([]int, gridWidth*gridHeight)
+	for i := range grid {
+		grid[i] = -1
+	}
+
+	var points []Vec2[T]
+	var active []int
+
+	cell := func(p Vec2[T]) (x, y int) {
+		return clampInt(int(p[0]/cellSize), 0, gridWidth-1), clampInt(int(p[1]/cellSize), 0, gridHeight-1)
+	}
+
+	add := func(p Vec2[T]) {
+		x, y := cell(p)
+		grid[y*gridWidth+x] = len(points)
+		active = append(active, len(points))
+		points = append(points, p)
+	}
+
+	fits := func(p Vec2[T]) bool {
+		x, y := cell(p)
+		for gy := clampInt(y-2, 0, gridHeight-1); gy <= clampInt(y+2, 0, gridHeight-1); gy++ {
+			for gx := clampInt(x-2, 0, gridWidth-1); gx <= clampInt(x+2, 0, gridWidth-1); gx++ {
+				if i := grid[gy*gridWidth+gx]; i >= 0 && points[i].Sub(p).Magnitude() < minDist {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	add(Vec2[T]{s.Float() * width, s.Float() * height})
+
+	for len(active) > 0 {
+		a := s.rng.Intn(len(active))
+		origin := points[active[a]]
+
+		found := false
+		for i := 0; i < k; i++ {
+			r := minDist * (1 + s.Float())
+			sin, cos := math.Sincos(2 * math.Pi * float64(s.Float()))
+			p := origin.Add(Vec2[T]{r * T(cos), r * T(sin)})
+
+			if p[0] < 0 || p[0] >= width || p[1] < 0 || p[1] >= height {
+				continue
+			}
+			if fits(p) {
+				add(p)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			active[a] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	return points
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
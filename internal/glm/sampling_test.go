@@ -0,0 +1,74 @@
+package glm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSamplerDeterministic(t *testing.T) {
+	a, b := NewSampler[float64](1), NewSampler[float64](1)
+	for i := 0; i < 100; i++ {
+		if x, y := a.Float(), b.Float(); x != y {
+			t.Fatalf("sample %d: %v != %v", i, x, y)
+		}
+	}
+
+	// pins the sequence of seed 1
+	s := NewSampler[float64](1)
+	for _, want := range []float64{0.604660287980, 0.940509088045} {
+		if got := s.Float(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Float() = %.12f, want %.12f", got, want)
+		}
+	}
+}
+
+func TestSamplerDistributions(t *testing.T) {
+	s := NewSampler[float32](2)
+	normal := Vec3[float32]{0, 0, 1}
+	for i := 0; i < 10000; i++ {
+		if f := s.Float(); f < 0 || f >= 1 {
+			t.Fatalf("Float() = %v, outside [0, 1)", f)
+		}
+		if f := s.Range(-2, 3); f < -2 || f >= 3 {
+			t.Fatalf("Range(-2, 3) = %v", f)
+		}
+		if p := s.InDisk(2); p.Magnitude() > 2 {
+			t.Fatalf("InDisk(2) = %v", p)
+		}
+		if p := s.OnSphere(3); math.Abs(float64(p.Magnitude())-3) > 1e-5 {
+			t.Fatalf("OnSphere(3) = %v, magnitude %v", p, p.Magnitude())
+		}
+		if p := s.InHemisphere(normal); p.Dot(normal) < 0 {
+			t.Fatalf("InHemisphere(%v) = %v", normal, p)
+		}
+	}
+}
+
+func TestPoissonDisk(t *testing.T) {
+	const width, height, minDist = 10.0, 5.0, 0.5
+
+	points := NewSampler[float64](3).PoissonDisk(width, height, minDist, 30)
+	// a dense packing of 10x5 with a spacing of 0.5 holds far more
+	if len(points) < 50 {
+		t.Fatalf("got only %d points", len(points))
+	}
+	for i, p := range points {
+		if p[0] < 0 || p[0] >= width || p[1] < 0 || p[1] >= height {
+			t.Errorf("point %v outside the rectangle", p)
+		}
+		for _, q := range points[:i] {
+			if d := p.Sub(q).Magnitude(); d < minDist {
+				t.Errorf("points %v and %v are %v apart", p, q, d)
+			}
+		}
+	}
+
+	again := NewSampler[float64](3).PoissonDisk(width, height, minDist, 30)
+	if len(again) != len(points) || again[len(again)-1] != points[len(points)-1] {
+		t.Error("same seed gives different points")
+	}
+
+	if points := NewSampler[float64](3).PoissonDisk(0, height, minDist, 30); points != nil {
+		t.Errorf("empty rectangle gives %d points", len(points))
+	}
+}
@@ -47,3 +47,7 @@ func (lhs Vec3[T]) Sub(rhs Vec3[T]) Vec3[T] {
 		lhs[2] - rhs[2],
 	}
 }
+
+func (lhs Vec3[T]) Lerp(rhs Vec3[T], t T) Vec3[T] {
+	return lhs.Add(rhs.Sub(lhs).MulScalar(t))
+}
@@ -0,0 +1,217 @@
+// Package hotreload reloads WGSL shaders from disk while an example runs.
+//
+// Shader modules are created through a Reloader, which reads them from Dir
+// when it is set and falls back to the embedded source otherwise. Shaders
+// using #include or #define are run through wgsl.Process, every file they
+// include is read the same way. Poll checks the files for changes and
+// recreates the changed modules, together
+// with the pipelines and bind groups created after them in the
+// resource.Registry. Buffers and textures keep their contents. If the new
+// shader fails to compile the error is logged and the old objects stay in
+// use.
+package hotreload
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// DefaultInterval is how often Poll looks at the files.
+const DefaultInterval = 250 * time.Millisecond
+
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// file is a shader module created from files in Dir.
+type file struct {
+	// paths are the files the module was created from, stamps what Poll
+	// last saw of them.
+	paths  []string
+	stamps []stamp
+	handle *resource.Handle[*wgpu.ShaderModule]
+}
+
+// watch sets the files Poll checks.
+func (f *file) watch(paths ...string) {
+	f.paths = paths
+	f.stamps = make([]stamp, len(paths))
+	for i, path := range paths {
+		f.stamps[i] = stat(path)
+	}
+}
+
+// changed reports whether a file differs from what Poll last saw, and
+// records it.
+func (f *file) changed() bool {
+	changed := false
+	for i, path := range f.paths {
+		if s := stat(path); s != f.stamps[i] {
+			f.stamps[i] = s
+			changed = true
+		}
+	}
+	return changed
+}
+
+type Reloader struct {
+	// Dir is the directory shaders are read from, empty disables reloading.
+	Dir      string
+	Interval time.Duration
+	Logger   *slog.Logger
+
+	registry *resource.Registry
+	files    []*file
+	next     time.Time
+}
+
+func New(registry *resource.Registry, dir string) *Reloader {
+	return &Reloader{
+		Dir:      dir,
+		Interval: DefaultInterval,
+		Logger:   slog.Default(),
+		registry: registry,
+	}
+}
+
+// ShaderModule creates a shader module from the file name in Dir, or from
+// embedded if Dir is empty or doesn't contain it.
+func (r *Reloader) ShaderModule(name string, embedded string) (*resource.Handle[*wgpu.ShaderModule], error) {
+	f := &file{}
+	if r.Dir != "" {
+		f.watch(filepath.Join(r.Dir, name))
+	}
+
+	handle, err := resource.Track(r.registry, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.ShaderModule, error) {
+		code := embedded
+		if r.Dir != "" {
+			data, err := os.ReadFile(f.paths[0])
+			if err == nil {
+				code = string(data)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
+			Label:          name,
+			WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: code},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.add(f, handle)
+	return handle, nil
+}
+
+// ProcessedShaderModule creates a shader module from the file name run
+// through wgsl.Process with defines. The file and the files it includes
+// are read from Dir, or from embedded if Dir is empty or doesn't contain
+// them. Compile errors are mapped to the original files. The returned
+// Source is the one the module was first created from, e.g. to reflect it.
+func (r *Reloader) ProcessedShaderModule(name string, embedded fs.FS, defines map[string]string) (*resource.Handle[*wgpu.ShaderModule], *wgsl.Source, error) {
+	f := &file{}
+	fsys := embedded
+	if r.Dir != "" {
+		fsys = overlay{os.DirFS(r.Dir), embedded}
+	}
+
+	var first *wgsl.Source
+	handle, err := resource.Track(r.registry, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.ShaderModule, error) {
+		src, err := wgsl.Process(fsys, name, defines)
+		if err != nil {
+			return nil, err
+		}
+		if r.Dir != "" {
+			// the includes may have changed
+			f.watch(r.paths(src)...)
+		}
+		if first == nil {
+			first = src
+		}
+		module, err := device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
+			Label:          name,
+			WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: src.Code},
+		})
+		if err != nil {
+			return nil, src.MapError(err)
+		}
+		return module, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	r.add(f, handle)
+	return handle, first, nil
+}
+
+// paths returns the paths in Dir of the files src was processed from.
+func (r *Reloader) paths(src *wgsl.Source) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, line := range src.Lines {
+		if !seen[line.File] {
+			seen[line.File] = true
+			paths = append(paths, filepath.Join(r.Dir, filepath.FromSlash(line.File)))
+		}
+	}
+	return paths
+}
+
+func (r *Reloader) add(f *file, handle *resource.Handle[*wgpu.ShaderModule]) {
+	if r.Dir != "" {
+		f.handle = handle
+		r.files = append(r.files, f)
+	}
+}
+
+// overlay reads files from dir, falling back to embedded for the missing
+// ones.
+type overlay struct {
+	dir, embedded fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	f, err := o.dir.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.embedded.Open(name)
+	}
+	return f, err
+}
+
+// Poll recreates the shaders whose files changed, it only looks at the
+// files once per Interval so it can be called every frame.
+func (r *Reloader) Poll(now time.Time) {
+	if len(r.files) == 0 || now.Before(r.next) {
+		return
+	}
+	r.next = now.Add(r.Interval)
+
+	for _, f := range r.files {
+		if !f.changed() {
+			continue
+		}
+		if err := resource.Recreate(r.registry, f.handle); err != nil {
+			r.Logger.Error("shader reload failed, keeping the previous version", "file", f.paths[0], "err", err)
+			continue
+		}
+		r.Logger.Info("shader reloaded", "file", f.paths[0])
+	}
+}
+
+func stat(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{size: -1}
+	}
+	return stamp{info.ModTime(), info.Size()}
+}
@@ -0,0 +1,120 @@
+package hotreload
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func newDevice(t *testing.T) *wgpu.Device {
+	t.Helper()
+	instance := wgpu.CreateInstance(nil)
+	t.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	t.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	t.Cleanup(device.Drop)
+	return device
+}
+
+const mainWGSL = `#include "common.wgsl"
+
+@compute @workgroup_size(SIZE)
+fn main() {
+    data[0] = value();
+}
+`
+
+const commonWGSL = `@group(0) @binding(0) var<storage, read_write> data: array<u32>;
+
+fn value() -> u32 {
+    return 1u;
+}
+`
+
+var embedded = fstest.MapFS{
+	"main.wgsl":   {Data: []byte(mainWGSL)},
+	"common.wgsl": {Data: []byte(commonWGSL)},
+}
+
+// newReloader returns a Reloader reading from a temporary directory,
+// holding the files, path to contents.
+func newReloader(t *testing.T, files map[string]string) (*Reloader, string) {
+	t.Helper()
+	registry := resource.NewRegistry(newDevice(t))
+	t.Cleanup(registry.Drop)
+	dir := t.TempDir()
+	for path, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(contents), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := New(registry, dir)
+	r.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return r, dir
+}
+
+func TestProcessedShaderModule(t *testing.T) {
+	// main.wgsl only exists embedded, its include is read from the
+	// directory
+	r, dir := newReloader(t, map[string]string{"common.wgsl": commonWGSL})
+	handle, src, err := r.ProcessedShaderModule("main.wgsl", embedded, map[string]string{"SIZE": "64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := wgsl.Reflect(src.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := m.EntryPoint("main"); e == nil || len(e.WorkgroupSize) != 1 || e.WorkgroupSize[0] != "64" {
+		t.Errorf("main: got %+v, want @workgroup_size(64) from the define", e)
+	}
+
+	// a change to the include reloads the module
+	old := handle.Get()
+	changed := commonWGSL + "\nfn unused() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "common.wgsl"), []byte(changed), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	r.Poll(time.Now())
+	if handle.Get() == old {
+		t.Fatal("the module wasn't reloaded after its include changed")
+	}
+
+	// a broken include keeps the previous module
+	old = handle.Get()
+	if err := os.WriteFile(filepath.Join(dir, "common.wgsl"), []byte(commonWGSL+"\nfn broken( {\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	r.Poll(time.Now().Add(r.Interval))
+	if handle.Get() != old {
+		t.Error("a broken include replaced the module")
+	}
+}
+
+func TestProcessedShaderModuleError(t *testing.T) {
+	r, _ := newReloader(t, map[string]string{"common.wgsl": commonWGSL + "\nfn broken() -> u32 {\n    return undefined;\n}\n"})
+	_, _, err := r.ProcessedShaderModule("main.wgsl", embedded, map[string]string{"SIZE": "64"})
+	var compileErr *wgsl.CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("got %v, want a CompileError", err)
+	}
+	if want := (wgsl.Location{File: "common.wgsl", Line: 8}); compileErr.Location != want {
+		t.Errorf("error at %s, want %s", compileErr.Location, want)
+	}
+}
@@ -0,0 +1,277 @@
+// Package kernel runs a WGSL compute shader over a slice and returns its
+// results, hiding the buffers, bind groups and readback it takes.
+//
+// The entry point sees the input and output as the first two bindings of
+// group 0:
+//
+//	@group(0) @binding(0) var<storage, read> input: array<In>;
+//	@group(0) @binding(1) var<storage, read_write> output: array<Out>;
+//
+// and writes output[i] for input[i], with i = global_invocation_id.x. The
+// last workgroup may run past the end of the input, so the shader must skip
+// indices beyond arrayLength(&input).
+package kernel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/readback"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/trace"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Options are the optional settings of Run, a nil *Options uses the
+// defaults.
+type Options struct {
+	// Trace, if set, records the Setup, Dispatch and MapAsync wait spans of
+	// Run.
+	Trace *trace.Tracer
+	// MaxBatch, if positive, limits the elements of one batch below what
+	// the device allows, e.g. to bound the buffer sizes.
+	MaxBatch int
+}
+
+// Run runs the compute entry point entry of the shader code once for every
+// element of in and returns the decoded output. Inputs larger than one
+// dispatch or one storage buffer binding allows are split into batches,
+// each run and read back before the next one.
+//
+// In and Out must have the layout of the WGSL array elements, which Run
+// checks for their size against the shader's array strides.
+//
+// The readbacks give up with ctx.Err() once ctx is done, callers should
+// give it a deadline since a lost device never finishes them.
+func Run[In, Out any](ctx context.Context, device *wgpu.Device, code, entry string, in []In, opts *Options) ([]Out, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	tracer := opts.Trace
+	inStride, outStride := uint64(unsafe.Sizeof(*new(In))), uint64(unsafe.Sizeof(*new(Out)))
+	k, err := setup(device, code, entry, inStride, outStride, len(in), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer k.drop()
+
+	queue := device.GetQueue()
+	out := make([]Out, 0, len(in))
+	for start := 0; start < len(in); start += int(k.batch) {
+		chunk := in[start:min(start+int(k.batch), len(in))]
+		n := uint64(len(chunk))
+
+		bindGroup, err := device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout: k.bindGroupLayout,
+			Entries: []wgpu.BindGroupEntry{
+				{Binding: 0, Buffer: k.input, Size: n * inStride},
+				{Binding: 1, Buffer: k.output, Size: n * outStride},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		dispatch := tracer.Begin("Dispatch").Arg("elements", n)
+		queue.WriteBuffer(k.input, 0, wgpu.ToBytes(chunk))
+		encoder, err := device.CreateCommandEncoder(nil)
+		if err != nil {
+			bindGroup.Drop()
+			dispatch.End()
+			return nil, err
+		}
+		pass := encoder.BeginComputePass(nil)
+		pass.SetPipeline(k.pipeline)
+		pass.SetBindGroup(0, bindGroup, nil)
+		pass.DispatchWorkgroups(uint32((n+k.workgroupSize-1)/k.workgroupSize), 1, 1)
+		pass.End()
+		encoder.CopyBufferToBuffer(k.output, 0, k.staging, 0, n*outStride)
+		queue.Submit(encoder.Finish(nil))
+		bindGroup.Drop()
+		dispatch.End()
+
+		wait := tracer.Begin("MapAsync wait")
+		result := <-readback.ReadBuffer(ctx, device, k.staging, 0, n*outStride)
+		wait.End()
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		out = append(out, wgpu.FromBytes[Out](result.Data)...)
+	}
+	return out, nil
+}
+
+// kernel holds the objects Run reuses for every batch.
+type kernel struct {
+	batch         uint64
+	workgroupSize uint64
+
+	shader          *wgpu.ShaderModule
+	pipeline        *wgpu.ComputePipeline
+	bindGroupLayout *wgpu.BindGroupLayout
+	input           *wgpu.Buffer
+	output          *wgpu.Buffer
+	staging         *wgpu.Buffer
+}
+
+// setup checks the shader and creates the objects for batches of up to
+// elements elements, it is the Setup span of the trace.
+func setup(device *wgpu.Device, code, entry string, inStride, outStride uint64, elements int, opts *Options) (_ *kernel, err error) {
+	defer opts.Trace.Begin("Setup").End()
+
+	k := &kernel{}
+	defer func() {
+		if err != nil {
+			k.drop()
+		}
+	}()
+
+	k.workgroupSize, err = check(code, entry, inStride, outStride)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := device.GetLimits().Limits
+	batch := uint64(limits.MaxComputeWorkgroupsPerDimension) * k.workgroupSize
+	for _, stride := range []uint64{inStride, outStride} {
+		batch = min(batch, limits.MaxStorageBufferBindingSize/stride, limits.MaxBufferSize/stride)
+	}
+	if opts.MaxBatch > 0 {
+		batch = min(batch, uint64(opts.MaxBatch))
+	}
+	k.batch = min(batch, uint64(elements))
+	if k.batch == 0 {
+		return nil, fmt.Errorf("kernel: an element doesn't fit in a storage buffer binding")
+	}
+
+	k.shader, err = device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
+		Label:          entry,
+		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: code},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k.pipeline, err = device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
+		Label: entry,
+		Compute: wgpu.ProgrammableStageDescriptor{
+			Module:     k.shader,
+			EntryPoint: entry,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k.bindGroupLayout = k.pipeline.GetBindGroupLayout(0)
+
+	k.input, err = device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Kernel Input",
+		Size:  k.batch * inStride,
+		Usage: wgpu.BufferUsage_Storage | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k.output, err = device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Kernel Output",
+		Size:  k.batch * outStride,
+		Usage: wgpu.BufferUsage_Storage | wgpu.BufferUsage_CopySrc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k.staging, err = device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Kernel Staging",
+		Size:  k.batch * outStride,
+		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// drop drops the objects setup got to create.
+func (k *kernel) drop() {
+	if k.staging != nil {
+		k.staging.Drop()
+	}
+	if k.output != nil {
+		k.output.Drop()
+	}
+	if k.input != nil {
+		k.input.Drop()
+	}
+	if k.bindGroupLayout != nil {
+		k.bindGroupLayout.Drop()
+	}
+	if k.pipeline != nil {
+		k.pipeline.Drop()
+	}
+	if k.shader != nil {
+		k.shader.Drop()
+	}
+}
+
+// check returns the workgroup size of entry after checking that In and Out
+// match the element strides of the shader's input and output arrays.
+func check(code, entry string, inStride, outStride uint64) (uint64, error) {
+	if inStride == 0 || inStride%4 != 0 || outStride == 0 || outStride%4 != 0 {
+		return 0, fmt.Errorf("kernel: the sizes %d and %d of In and Out must be positive multiples of 4", inStride, outStride)
+	}
+	module, err := wgsl.Reflect(code)
+	if err != nil {
+		return 0, err
+	}
+	e := module.EntryPoint(entry)
+	if e == nil || e.Stage != wgpu.ShaderStage_Compute {
+		return 0, fmt.Errorf("kernel: no compute entry point %s", entry)
+	}
+
+	workgroupSize := uint64(1)
+	for i, s := range e.WorkgroupSize {
+		n, err := strconv.ParseUint(strings.TrimRight(s, "iu"), 0, 32)
+		if err != nil {
+			return 0, fmt.Errorf("kernel: @workgroup_size of %s must be integer literals, not %s", entry, s)
+		}
+		if i > 0 && n != 1 {
+			return 0, fmt.Errorf("kernel: the workgroups of %s must be one dimensional", entry)
+		}
+		if i == 0 {
+			workgroupSize = n
+		}
+	}
+
+	for _, want := range []struct {
+		binding uint32
+		name    string
+		stride  uint64
+	}{{0, "In", inStride}, {1, "Out", outStride}} {
+		var b *wgsl.Binding
+		for _, found := range module.Group(0) {
+			if found.Binding == want.binding {
+				b = found
+			}
+		}
+		if b == nil || b.AddressSpace != "storage" || b.Type.Name != "array" || len(b.Type.Args) != 1 {
+			return 0, fmt.Errorf("kernel: @group(0) @binding(%d) must be a runtime-sized storage array", want.binding)
+		}
+		size, align, err := module.SizeAlign(b.Type.Args[0])
+		if err != nil {
+			return 0, fmt.Errorf("kernel: %s: %w", b.Name, err)
+		}
+		if stride := uint64((size + align - 1) / align * align); stride != want.stride {
+			return 0, fmt.Errorf("kernel: %s is %d bytes but the stride of %s is %d", want.name, want.stride, b.Type, stride)
+		}
+	}
+	return workgroupSize, nil
+}
@@ -0,0 +1,129 @@
+package kernel
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/trace"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func newDevice(t *testing.T) *wgpu.Device {
+	t.Helper()
+	instance := wgpu.CreateInstance(nil)
+	t.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	t.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	t.Cleanup(device.Drop)
+	return device
+}
+
+const double = `
+@group(0) @binding(0) var<storage, read> input: array<u32>;
+@group(0) @binding(1) var<storage, read_write> output: array<u32>;
+
+@compute @workgroup_size(64)
+fn main(@builtin(global_invocation_id) id: vec3<u32>) {
+	if id.x >= arrayLength(&input) {
+		return;
+	}
+	output[id.x] = input[id.x] * 2u;
+}
+`
+
+// spans returns the names of the spans recorded by tracer.
+func spans(tracer *trace.Tracer) []string {
+	var names []string
+	for _, e := range tracer.Events() {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestRunBatches(t *testing.T) {
+	device := newDevice(t)
+	in := make([]uint32, 250)
+	for i := range in {
+		in[i] = uint32(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tracer := trace.New()
+	out, err := Run[uint32, uint32](ctx, device, double, "main", in, &Options{Trace: tracer, MaxBatch: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != len(in) {
+		t.Fatalf("got %d results, want %d", len(out), len(in))
+	}
+	for i, v := range out {
+		if v != 2*in[i] {
+			t.Fatalf("out[%d] = %d, want %d", i, v, 2*in[i])
+		}
+	}
+
+	var elements []uint64
+	for _, e := range tracer.Events() {
+		if e.Name == "Dispatch" {
+			elements = append(elements, e.Args["elements"].(uint64))
+		}
+	}
+	if len(elements) != 3 || elements[0] != 100 || elements[1] != 100 || elements[2] != 50 {
+		t.Errorf("dispatched batches of %v elements, want [100 100 50]", elements)
+	}
+}
+
+func TestRunStrideMismatch(t *testing.T) {
+	device := newDevice(t)
+	tracer := trace.New()
+
+	// the shader's elements are u32, In is 8 bytes
+	_, err := Run[[2]uint32, uint32](context.Background(), device, double, "main", make([][2]uint32, 4), &Options{Trace: tracer})
+	if err == nil || !strings.Contains(err.Error(), "In is 8 bytes but the stride of array<u32> is 4") {
+		t.Fatalf("got error %v, want a stride mismatch", err)
+	}
+	// the Setup span is ended on the early return too
+	if names := spans(tracer); len(names) != 1 || names[0] != "Setup" {
+		t.Errorf("recorded spans %v, want [Setup]", names)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, test := range []struct {
+		code      string
+		in, out   uint64
+		size      uint64
+		wantError string
+	}{
+		{code: double, in: 4, out: 4, size: 64},
+		{code: double, in: 4, out: 8, wantError: "Out is 8 bytes"},
+		{code: double, in: 6, out: 4, wantError: "positive multiples of 4"},
+		{code: strings.Replace(double, "fn main", "fn other", 1), in: 4, out: 4, wantError: "no compute entry point main"},
+		{code: strings.Replace(double, "@workgroup_size(64)", "@workgroup_size(8, 8)", 1), in: 4, out: 4, wantError: "one dimensional"},
+		{code: strings.Replace(double, "var<storage, read> input", "var<uniform> input", 1), in: 4, out: 4, wantError: "@binding(0) must be a runtime-sized storage array"},
+	} {
+		size, err := check(test.code, "main", test.in, test.out)
+		if test.wantError != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantError) {
+				t.Errorf("check(%d, %d): got error %v, want %q", test.in, test.out, err, test.wantError)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("check(%d, %d): %v", test.in, test.out, err)
+		} else if size != test.size {
+			t.Errorf("check(%d, %d) = %d, want workgroup size %d", test.in, test.out, size, test.size)
+		}
+	}
+}
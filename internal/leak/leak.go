@@ -0,0 +1,167 @@
+// Package leak tracks the lifetime of GPU objects, reporting the ones that
+// were never dropped together with the stack that created them, and the
+// ones that were dropped twice.
+//
+// A nil *Tracker is valid and tracks nothing, so tracking can be switched
+// off without changing the calling code.
+package leak
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+type Droppable interface {
+	Drop()
+}
+
+// Entry is the record of one tracked object.
+type Entry struct {
+	Kind    string
+	Stack   string
+	tracker *Tracker
+	dropped bool
+}
+
+// DoubleDrop records a second Drop of an object.
+type DoubleDrop struct {
+	Entry *Entry
+	Stack string
+}
+
+type Tracker struct {
+	mu          sync.Mutex
+	live        map[*Entry]struct{}
+	order       []*Entry
+	doubleDrops []DoubleDrop
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{live: map[*Entry]struct{}{}}
+}
+
+// Add records the creation of an object of kind, skip is the number of
+// callers to leave out of the stack, 0 being the caller of Add.
+func (t *Tracker) Add(kind string, skip int) *Entry {
+	if t == nil {
+		return nil
+	}
+
+	e := &Entry{
+		Kind:    kind,
+		Stack:   stack(skip + 1),
+		tracker: t,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.live[e] = struct{}{}
+	t.order = append(t.order, e)
+	return e
+}
+
+// Drop marks the entry as dropped. It returns false, and records a
+// DoubleDrop, if it already was, in which case the caller must not drop the
+// object again.
+func (e *Entry) Drop() bool {
+	if e == nil {
+		return true
+	}
+
+	t := e.tracker
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e.dropped {
+		t.doubleDrops = append(t.doubleDrops, DoubleDrop{Entry: e, Stack: stack(2)})
+		return false
+	}
+	e.dropped = true
+	delete(t.live, e)
+	return true
+}
+
+// Leaks returns the entries not dropped yet in creation order.
+func (t *Tracker) Leaks() []*Entry {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var leaks []*Entry
+	for _, e := range t.order {
+		if _, ok := t.live[e]; ok {
+			leaks = append(leaks, e)
+		}
+	}
+	return leaks
+}
+
+func (t *Tracker) DoubleDrops() []DoubleDrop {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]DoubleDrop(nil), t.doubleDrops...)
+}
+
+// Report writes the leaks and double drops to w and returns how many
+// problems it found.
+func (t *Tracker) Report(w io.Writer) int {
+	leaks := t.Leaks()
+	doubleDrops := t.DoubleDrops()
+
+	for _, e := range leaks {
+		fmt.Fprintf(w, "leak: %s not dropped, created at:\n%s\n", e.Kind, e.Stack)
+	}
+	for _, d := range doubleDrops {
+		fmt.Fprintf(w, "leak: %s dropped twice, created at:\n%s\ndropped again at:\n%s\n", d.Entry.Kind, d.Entry.Stack, d.Stack)
+	}
+	return len(leaks) + len(doubleDrops)
+}
+
+// Object wraps a tracked object.
+type Object[T Droppable] struct {
+	value T
+	entry *Entry
+}
+
+// Track starts tracking value, which is typically the result of a
+// wgpu.Device Create* method.
+func Track[T Droppable](t *Tracker, value T) *Object[T] {
+	return &Object[T]{
+		value: value,
+		entry: t.Add(fmt.Sprintf("%T", value), 1),
+	}
+}
+
+func (o *Object[T]) Get() T {
+	return o.value
+}
+
+// Drop drops the object unless it already was.
+func (o *Object[T]) Drop() {
+	if o.entry.Drop() {
+		o.value.Drop()
+	}
+}
+
+func stack(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var s string
+	for {
+		frame, more := frames.Next()
+		s += fmt.Sprintf("\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return s
+}
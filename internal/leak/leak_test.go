@@ -0,0 +1,94 @@
+package leak
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// object is a fake GPU object counting its drops.
+type object struct {
+	drops int
+}
+
+func (o *object) Drop() {
+	o.drops++
+}
+
+func TestReport(t *testing.T) {
+	tracker := NewTracker()
+	kept := Track(tracker, &object{})
+	dropped := Track(tracker, &object{})
+	twice := Track(tracker, &object{})
+
+	dropped.Drop()
+	twice.Drop()
+	twice.Drop()
+
+	if n := twice.Get().drops; n != 1 {
+		t.Errorf("object dropped twice reached Drop %d times, want 1", n)
+	}
+	if leaks := tracker.Leaks(); len(leaks) != 1 || leaks[0] != kept.entry {
+		t.Errorf("Leaks() = %v, want only the kept object", leaks)
+	}
+	doubleDrops := tracker.DoubleDrops()
+	if len(doubleDrops) != 1 || doubleDrops[0].Entry != twice.entry {
+		t.Fatalf("DoubleDrops() = %v, want only the object dropped twice", doubleDrops)
+	}
+
+	var b bytes.Buffer
+	if n := tracker.Report(&b); n != 2 {
+		t.Errorf("Report() = %d, want 2", n)
+	}
+	report := b.String()
+	for _, want := range []string{
+		"leak: *leak.object not dropped",
+		"leak: *leak.object dropped twice",
+		"leak.TestReport",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report doesn't contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestLeaksOrder(t *testing.T) {
+	tracker := NewTracker()
+	var objects []*Object[*object]
+	for i := 0; i < 4; i++ {
+		objects = append(objects, Track(tracker, &object{}))
+	}
+	objects[1].Drop()
+
+	leaks := tracker.Leaks()
+	want := []*Entry{objects[0].entry, objects[2].entry, objects[3].entry}
+	if len(leaks) != len(want) {
+		t.Fatalf("got %d leaks, want %d", len(leaks), len(want))
+	}
+	for i := range want {
+		if leaks[i] != want[i] {
+			t.Errorf("leak #%d is out of creation order", i)
+		}
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	o := Track(tracker, &object{})
+	o.Drop()
+	o.Drop()
+
+	if n := o.Get().drops; n != 2 {
+		t.Errorf("untracked object reached Drop %d times, want 2", n)
+	}
+	if leaks := tracker.Leaks(); leaks != nil {
+		t.Errorf("Leaks() = %v, want nil", leaks)
+	}
+	if doubleDrops := tracker.DoubleDrops(); doubleDrops != nil {
+		t.Errorf("DoubleDrops() = %v, want nil", doubleDrops)
+	}
+	var b bytes.Buffer
+	if n := tracker.Report(&b); n != 0 || b.Len() != 0 {
+		t.Errorf("Report() = %d, %q, want 0 and nothing written", n, b.String())
+	}
+}
@@ -0,0 +1,54 @@
+package parallel
+
+import (
+	"context"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// sharedBins is the number of bins histogram_shared counts in workgroup
+// memory.
+const sharedBins = 1024
+
+// histogramBlockSize is the number of values a workgroup of the histogram
+// kernels counts.
+const histogramBlockSize = 1024
+
+// Histogram returns how many of the values equal each number below bins.
+// Values of bins or more aren't counted.
+func (p *Primitives) Histogram(ctx context.Context, values []uint32, bins uint32) ([]uint32, error) {
+	if bins == 0 {
+		return nil, nil
+	}
+	if err := p.checkSize(max(len(values), int(bins))); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return make([]uint32, bins), nil
+	}
+
+	r := p.begin(ctx)
+	input := r.buffer("Histogram Values", uint64(len(values))*4, wgpu.ToBytes(values))
+	// zero initialized
+	counts := r.buffer("Histogram Counts", uint64(bins)*4, nil)
+	kernel := p.histogramGlobal
+	if bins <= sharedBins {
+		kernel = p.histogramShared
+	}
+	groups := blocks(len(values), histogramBlockSize)
+	r.dispatch(kernel, groups, params{n: uint32(len(values)), blocks: groups, a: bins}, input, counts)
+	return r.read(int(bins), counts)
+}
+
+// HistogramCPU is the CPU reference of Histogram.
+func HistogramCPU(values []uint32, bins uint32) []uint32 {
+	if bins == 0 {
+		return nil
+	}
+	counts := make([]uint32, bins)
+	for _, v := range values {
+		if v < bins {
+			counts[v]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,61 @@
+// Counts the values below params.bins, each value is its bin. With up to
+// 1024 bins histogram_shared counts every block of 1024 values in workgroup
+// memory first, so that the global counts see one atomic per bin and block.
+
+struct Params {
+    n: u32,
+    blocks: u32,
+    bins: u32,
+    _pad: u32,
+};
+
+@group(0) @binding(0) var<uniform> params: Params;
+@group(0) @binding(1) var<storage, read> values: array<u32>;
+@group(0) @binding(2) var<storage, read_write> counts: array<atomic<u32>>;
+
+var<workgroup> local_counts: array<atomic<u32>, 1024>;
+
+@compute
+@workgroup_size(256)
+fn histogram_shared(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    for (var bin = local_id.x; bin < params.bins; bin = bin + 256u) {
+        atomicStore(&local_counts[bin], 0u);
+    }
+    workgroupBarrier();
+
+    for (var k = 0u; k < 4u; k = k + 1u) {
+        let i = block * 1024u + k * 256u + local_id.x;
+        if (i < params.n && values[i] < params.bins) {
+            atomicAdd(&local_counts[values[i]], 1u);
+        }
+    }
+    workgroupBarrier();
+
+    for (var bin = local_id.x; bin < params.bins; bin = bin + 256u) {
+        let count = atomicLoad(&local_counts[bin]);
+        if (count != 0u) {
+            atomicAdd(&counts[bin], count);
+        }
+    }
+}
+
+@compute
+@workgroup_size(256)
+fn histogram_global(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    for (var k = 0u; k < 4u; k = k + 1u) {
+        let i = block * 1024u + k * 256u + local_id.x;
+        if (i < params.n && values[i] < params.bins) {
+            atomicAdd(&counts[values[i]], 1u);
+        }
+    }
+}
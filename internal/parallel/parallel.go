@@ -0,0 +1,273 @@
+// Package parallel implements GPU building blocks over uint32 slices:
+// reduction, prefix scan, radix sort with payloads and histogram.
+//
+// Every primitive has a CPU reference implementation with the same
+// semantics, named after it with a CPU suffix, to check the GPU results
+// against and to compare their speed, see the primitives example.
+//
+// The readbacks of the primitives give up with ctx.Err() once ctx is done,
+// callers should give it a deadline since a lost device never finishes
+// them.
+package parallel
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/readback"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+//go:embed *.wgsl
+var shaders embed.FS
+
+// blockSize is the number of values a workgroup of the reduce and scan
+// kernels handles, 256 invocations with two values each.
+const blockSize = 512
+
+// Primitives holds the compiled kernels for one device.
+type Primitives struct {
+	device *wgpu.Device
+	queue  *wgpu.Queue
+	// maxGroups is maxComputeWorkgroupsPerDimension, larger dispatches
+	// use a second dimension.
+	maxGroups uint32
+
+	reduce          [opCount]*wgpu.ComputePipeline
+	scanBlocks      *wgpu.ComputePipeline
+	addBlockSums    *wgpu.ComputePipeline
+	count           *wgpu.ComputePipeline
+	scatter         *wgpu.ComputePipeline
+	histogramShared *wgpu.ComputePipeline
+	histogramGlobal *wgpu.ComputePipeline
+}
+
+// New compiles the kernels on device.
+func New(device *wgpu.Device) (p *Primitives, err error) {
+	p = &Primitives{
+		device:    device,
+		queue:     device.GetQueue(),
+		maxGroups: device.GetLimits().Limits.MaxComputeWorkgroupsPerDimension,
+	}
+	defer func() {
+		if err != nil {
+			p.Drop()
+		}
+	}()
+
+	for op := Op(0); op < opCount; op++ {
+		p.reduce[op], err = p.pipeline("reduce.wgsl", "reduce", op.defines())
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range []struct {
+		pipeline **wgpu.ComputePipeline
+		file     string
+		entry    string
+	}{
+		{&p.scanBlocks, "scan.wgsl", "scan_blocks"},
+		{&p.addBlockSums, "scan.wgsl", "add_block_sums"},
+		{&p.count, "sort.wgsl", "count"},
+		{&p.scatter, "sort.wgsl", "scatter"},
+		{&p.histogramShared, "histogram.wgsl", "histogram_shared"},
+		{&p.histogramGlobal, "histogram.wgsl", "histogram_global"},
+	} {
+		*k.pipeline, err = p.pipeline(k.file, k.entry, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *Primitives) pipeline(file, entry string, defines map[string]string) (*wgpu.ComputePipeline, error) {
+	src, err := wgsl.Process(shaders, file, defines)
+	if err != nil {
+		return nil, err
+	}
+	module, err := p.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
+		Label:          file,
+		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: src.Code},
+	})
+	if err != nil {
+		return nil, src.MapError(err)
+	}
+	defer module.Drop()
+
+	return p.device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
+		Label: entry,
+		Compute: wgpu.ProgrammableStageDescriptor{
+			Module:     module,
+			EntryPoint: entry,
+		},
+	})
+}
+
+// Drop drops the kernels.
+func (p *Primitives) Drop() {
+	pipelines := append(p.reduce[:], p.scanBlocks, p.addBlockSums, p.count, p.scatter, p.histogramShared, p.histogramGlobal)
+	for _, pipeline := range pipelines {
+		if pipeline != nil {
+			pipeline.Drop()
+		}
+	}
+	*p = Primitives{}
+}
+
+// params is the uniform every kernel reads, the meaning of the last two
+// fields depends on the kernel.
+type params struct {
+	n, blocks, a, b uint32
+}
+
+// run records the dispatches of one primitive into a single submission
+// and drops the objects it created once the results are read back. The
+// first error is kept and makes the later calls do nothing.
+type run struct {
+	ctx     context.Context
+	p       *Primitives
+	encoder *wgpu.CommandEncoder
+	pass    *wgpu.ComputePassEncoder
+	drops   []interface{ Drop() }
+	err     error
+}
+
+func (p *Primitives) begin(ctx context.Context) *run {
+	r := &run{ctx: ctx, p: p}
+	r.encoder, r.err = p.device.CreateCommandEncoder(nil)
+	return r
+}
+
+// buffer creates a storage buffer of size bytes, filled with contents if
+// not nil.
+func (r *run) buffer(label string, size uint64, contents []byte) *wgpu.Buffer {
+	if r.err != nil {
+		return nil
+	}
+	// bindings can't be empty
+	size = max(size, 4)
+	usage := wgpu.BufferUsage_Storage | wgpu.BufferUsage_CopySrc | wgpu.BufferUsage_CopyDst
+	var buffer *wgpu.Buffer
+	if contents != nil {
+		buffer, r.err = r.p.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+			Label:    label,
+			Contents: contents,
+			Usage:    usage,
+		})
+	} else {
+		buffer, r.err = r.p.device.CreateBuffer(&wgpu.BufferDescriptor{
+			Label: label,
+			Size:  size,
+			Usage: usage,
+		})
+	}
+	if r.err != nil {
+		return nil
+	}
+	r.drops = append(r.drops, buffer)
+	return buffer
+}
+
+// dispatch runs pipeline over blocks workgroups with params as binding 0
+// and buffers as the following bindings, nil buffers are skipped for the
+// kernels not using their binding.
+func (r *run) dispatch(pipeline *wgpu.ComputePipeline, blocks uint32, params params, buffers ...*wgpu.Buffer) {
+	if r.err != nil || blocks == 0 {
+		return
+	}
+	uniform, err := r.p.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Label:    "Params",
+		Contents: wgpu.ToBytes([]uint32{params.n, params.blocks, params.a, params.b}),
+		Usage:    wgpu.BufferUsage_Uniform,
+	})
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.drops = append(r.drops, uniform)
+
+	entries := []wgpu.BindGroupEntry{{Binding: 0, Buffer: uniform, Size: wgpu.WholeSize}}
+	for i, buffer := range buffers {
+		if buffer != nil {
+			entries = append(entries, wgpu.BindGroupEntry{Binding: uint32(i + 1), Buffer: buffer, Size: wgpu.WholeSize})
+		}
+	}
+	layout := pipeline.GetBindGroupLayout(0)
+	defer layout.Drop()
+	bindGroup, err := r.p.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+		Layout:  layout,
+		Entries: entries,
+	})
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.drops = append(r.drops, bindGroup)
+
+	if r.pass == nil {
+		r.pass = r.encoder.BeginComputePass(nil)
+	}
+	x := min(blocks, r.p.maxGroups)
+	r.pass.SetPipeline(pipeline)
+	r.pass.SetBindGroup(0, bindGroup, nil)
+	r.pass.DispatchWorkgroups(x, (blocks+x-1)/x, 1)
+}
+
+// read submits the recorded work, reads the first n values of each buffer
+// back, one after the other, and drops everything the run created.
+func (r *run) read(n int, buffers ...*wgpu.Buffer) ([]uint32, error) {
+	defer func() {
+		for i := len(r.drops) - 1; i >= 0; i-- {
+			r.drops[i].Drop()
+		}
+		r.drops = nil
+	}()
+	if r.pass != nil {
+		r.pass.End()
+	}
+	if r.err != nil {
+		if r.encoder != nil {
+			r.encoder.Drop()
+		}
+		return nil, r.err
+	}
+
+	size := uint64(n) * 4
+	staging, err := r.p.device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label: "Staging",
+		Size:  size * uint64(len(buffers)),
+		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		r.encoder.Drop()
+		return nil, err
+	}
+	r.drops = append(r.drops, staging)
+
+	for i, buffer := range buffers {
+		r.encoder.CopyBufferToBuffer(buffer, 0, staging, uint64(i)*size, size)
+	}
+	r.p.queue.Submit(r.encoder.Finish(nil))
+	result := <-readback.ReadBuffer(r.ctx, r.p.device, staging, 0, size*uint64(len(buffers)))
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return append([]uint32(nil), wgpu.FromBytes[uint32](result.Data)...), nil
+}
+
+// checkSize returns an error if n values don't fit in a storage buffer
+// binding.
+func (p *Primitives) checkSize(n int) error {
+	limits := p.device.GetLimits().Limits
+	if limit := min(limits.MaxStorageBufferBindingSize, limits.MaxBufferSize) / 4; uint64(n) > limit {
+		return fmt.Errorf("parallel: %d values exceed the device's limit of %d", n, limit)
+	}
+	return nil
+}
+
+func blocks(n, size int) uint32 {
+	return uint32((n + size - 1) / size)
+}
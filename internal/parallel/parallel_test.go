@@ -0,0 +1,342 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/readback"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// sizes are the input lengths of the tests: empty, one partial block, not
+// a multiple of blockSize and more than blockSize² values, which takes a
+// third level of block sums.
+var sizes = []int{0, 1, 1000, blockSize*blockSize + 1000}
+
+// sequence returns 1, 2, ..., n.
+func sequence(n int) []uint32 {
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = uint32(i + 1)
+	}
+	return values
+}
+
+func random(n int, seed int64) []uint32 {
+	rng := rand.New(rand.NewSource(seed))
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = rng.Uint32()
+	}
+	return values
+}
+
+func TestReduceCPU(t *testing.T) {
+	for _, n := range sizes {
+		values := sequence(n)
+		// no values reduce to the identity
+		wantMin := uint32(math.MaxUint32)
+		if n > 0 {
+			wantMin = 1
+		}
+		for _, test := range []struct {
+			op   Op
+			want uint32
+		}{
+			{Sum, uint32(uint64(n) * uint64(n+1) / 2)},
+			{Min, wantMin},
+			{Max, uint32(n)},
+		} {
+			if got := ReduceCPU(test.op, values); got != test.want {
+				t.Errorf("%s of 1..%d: got %d, want %d", test.op, n, got, test.want)
+			}
+		}
+	}
+
+	// the sum wraps around
+	if got := ReduceCPU(Sum, []uint32{math.MaxUint32, 2}); got != 1 {
+		t.Errorf("wrapping sum: got %d, want 1", got)
+	}
+}
+
+func TestScanCPU(t *testing.T) {
+	for _, n := range sizes {
+		values := sequence(n)
+		for _, inclusive := range []bool{false, true} {
+			sums := ScanCPU(values, inclusive)
+			if len(sums) != n {
+				t.Errorf("scan of 1..%d has %d sums", n, len(sums))
+				continue
+			}
+			for i, got := range sums {
+				// the sum of 1..i, or 1..i+1 with inclusive
+				k := uint64(i)
+				if inclusive {
+					k++
+				}
+				if want := uint32(k * (k + 1) / 2); got != want {
+					t.Errorf("scan of 1..%d (inclusive %t): sum %d is %d, want %d", n, inclusive, i, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestSortCPU(t *testing.T) {
+	for _, n := range sizes {
+		// few distinct keys, so that the payload shows stability
+		keys := random(n, int64(n))
+		for i := range keys {
+			keys[i] %= 1024
+		}
+		values := sequence(n)
+
+		type pair struct{ key, value uint32 }
+		want := make([]pair, n)
+		for i := range want {
+			want[i] = pair{keys[i], values[i]}
+		}
+		sort.SliceStable(want, func(i, j int) bool { return want[i].key < want[j].key })
+
+		SortCPU(keys, values)
+		for i := range want {
+			if got := (pair{keys[i], values[i]}); got != want[i] {
+				t.Errorf("sort of %d keys: pair %d is %v, want %v", n, i, got, want[i])
+				break
+			}
+		}
+
+		keys = random(n, int64(n))
+		SortCPU(keys, nil)
+		if !slices.IsSorted(keys) {
+			t.Errorf("sort of %d keys without values isn't sorted", n)
+		}
+	}
+}
+
+func TestHistogramCPU(t *testing.T) {
+	for _, n := range sizes {
+		for _, bins := range []uint32{1, 7, sharedBins + 1} {
+			// every value appears n/(bins+1) or one more times, the
+			// values of bin number bins aren't counted
+			values := make([]uint32, n)
+			for i := range values {
+				values[i] = uint32(i) % (bins + 1)
+			}
+			counts := HistogramCPU(values, bins)
+			if len(counts) != int(bins) {
+				t.Errorf("%d values in %d bins: got %d counts", n, bins, len(counts))
+				continue
+			}
+			for bin, got := range counts {
+				want := n / int(bins+1)
+				if bin < n%int(bins+1) {
+					want++
+				}
+				if int(got) != want {
+					t.Errorf("%d values in %d bins: bin %d counts %d, want %d", n, bins, bin, got, want)
+					break
+				}
+			}
+		}
+	}
+
+	if counts := HistogramCPU([]uint32{1, 2}, 0); counts != nil {
+		t.Errorf("no bins: got %v, want nil", counts)
+	}
+}
+
+func newPrimitives(tb testing.TB) *Primitives {
+	tb.Helper()
+	instance := wgpu.CreateInstance(nil)
+	tb.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		tb.Skip("no adapter:", err)
+	}
+	tb.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		tb.Skip("no device:", err)
+	}
+	tb.Cleanup(device.Drop)
+	p, err := New(device)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(p.Drop)
+	return p
+}
+
+// testContext returns a context with readback.DefaultTimeout, cancelled
+// when tb ends.
+func testContext(tb testing.TB) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), readback.DefaultTimeout)
+	tb.Cleanup(cancel)
+	return ctx
+}
+
+func TestReduce(t *testing.T) {
+	p := newPrimitives(t)
+	ctx := testContext(t)
+	for _, n := range sizes {
+		values := random(n, 1)
+		for op := Op(0); op < opCount; op++ {
+			got, err := p.Reduce(ctx, op, values)
+			if err != nil {
+				t.Fatalf("%s of %d values: %v", op, n, err)
+			}
+			if want := ReduceCPU(op, values); got != want {
+				t.Errorf("%s of %d values: got %d, want %d", op, n, got, want)
+			}
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	p := newPrimitives(t)
+	ctx := testContext(t)
+	for _, n := range sizes {
+		values := random(n, 2)
+		for _, inclusive := range []bool{false, true} {
+			got, err := p.Scan(ctx, values, inclusive)
+			if err != nil {
+				t.Fatalf("scan of %d values: %v", n, err)
+			}
+			if want := ScanCPU(values, inclusive); !slices.Equal(got, want) {
+				t.Errorf("scan of %d values (inclusive %t) differs from ScanCPU", n, inclusive)
+			}
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	p := newPrimitives(t)
+	ctx := testContext(t)
+	for _, n := range sizes {
+		keys := random(n, 3)
+		for i := range keys {
+			keys[i] %= 1024
+		}
+		values := sequence(n)
+
+		gotKeys, gotValues := slices.Clone(keys), slices.Clone(values)
+		if err := p.Sort(ctx, gotKeys, gotValues); err != nil {
+			t.Fatalf("sort of %d keys: %v", n, err)
+		}
+		SortCPU(keys, values)
+		if !slices.Equal(gotKeys, keys) || !slices.Equal(gotValues, values) {
+			t.Errorf("sort of %d keys differs from SortCPU", n)
+		}
+	}
+
+	if err := p.Sort(ctx, make([]uint32, 2), make([]uint32, 1)); err == nil {
+		t.Error("Sort accepted fewer values than keys")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	p := newPrimitives(t)
+	ctx := testContext(t)
+	for _, n := range sizes {
+		// both the shared and the global memory kernel
+		for _, bins := range []uint32{256, sharedBins * 2} {
+			// some values fall outside the bins
+			values := random(n, 4)
+			for i := range values {
+				values[i] %= bins + bins/8
+			}
+			got, err := p.Histogram(ctx, values, bins)
+			if err != nil {
+				t.Fatalf("%d values in %d bins: %v", n, bins, err)
+			}
+			if want := HistogramCPU(values, bins); !slices.Equal(got, want) {
+				t.Errorf("%d values in %d bins differ from HistogramCPU", n, bins)
+			}
+		}
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	p := newPrimitives(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Reduce(ctx, Sum, sequence(1000)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Reduce with a cancelled context: got %v, want %v", err, context.Canceled)
+	}
+	// the primitives still work afterwards
+	got, err := p.Reduce(testContext(t), Sum, sequence(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ReduceCPU(Sum, sequence(1000)); got != want {
+		t.Errorf("Reduce after a cancelled one: got %d, want %d", got, want)
+	}
+}
+
+// benchSize is the number of keys the sort benchmarks sort.
+const benchSize = 1 << 20
+
+// sortKeys returns random keys with few distinct values and their indices
+// as values, like the primitives example sorts.
+func sortKeys() (keys, values []uint32) {
+	keys = random(benchSize, 5)
+	for i := range keys {
+		keys[i] %= 1024
+	}
+	return keys, sequence(benchSize)
+}
+
+func BenchmarkSortCPU(b *testing.B) {
+	keys, values := sortKeys()
+	k, v := make([]uint32, len(keys)), make([]uint32, len(values))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(k, keys)
+		copy(v, values)
+		SortCPU(k, v)
+	}
+}
+
+func BenchmarkSortGPU(b *testing.B) {
+	p := newPrimitives(b)
+	ctx := context.Background()
+	keys, values := sortKeys()
+	k, v := make([]uint32, len(keys)), make([]uint32, len(values))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(k, keys)
+		copy(v, values)
+		if err := p.Sort(ctx, k, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSortSlice(b *testing.B) {
+	type pair struct{ key, value uint32 }
+	keys, values := sortKeys()
+	pairs := make([]pair, len(keys))
+	for _, stable := range []bool{false, true} {
+		b.Run(fmt.Sprintf("stable=%t", stable), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j := range pairs {
+					pairs[j] = pair{keys[j], values[j]}
+				}
+				less := func(i, j int) bool { return pairs[i].key < pairs[j].key }
+				if stable {
+					sort.SliceStable(pairs, less)
+				} else {
+					sort.Slice(pairs, less)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package parallel
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Op is the operation of a reduction.
+type Op int
+
+const (
+	// Sum adds the values, wrapping around on overflow.
+	Sum Op = iota
+	Min
+	Max
+
+	opCount
+)
+
+func (op Op) String() string {
+	switch op {
+	case Sum:
+		return "sum"
+	case Min:
+		return "min"
+	case Max:
+		return "max"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// identity is the result of reducing no values.
+func (op Op) identity() uint32 {
+	if op == Min {
+		return math.MaxUint32
+	}
+	return 0
+}
+
+// defines picks the operation in reduce.wgsl.
+func (op Op) defines() map[string]string {
+	return map[string]string{
+		"OP":       "op_" + op.String(),
+		"IDENTITY": fmt.Sprintf("%du", op.identity()),
+	}
+}
+
+// Reduce combines all values with op. Each pass reduces blocks of 512
+// values to one until a single value is left.
+func (p *Primitives) Reduce(ctx context.Context, op Op, values []uint32) (uint32, error) {
+	if op < 0 || op >= opCount {
+		return 0, fmt.Errorf("parallel: unknown %s", op)
+	}
+	if len(values) == 0 {
+		return op.identity(), nil
+	}
+	if err := p.checkSize(len(values)); err != nil {
+		return 0, err
+	}
+
+	r := p.begin(ctx)
+	input := r.buffer("Reduce Input", uint64(len(values))*4, wgpu.ToBytes(values))
+	n := len(values)
+	for n > 1 {
+		groups := blocks(n, blockSize)
+		output := r.buffer("Reduce Output", uint64(groups)*4, nil)
+		r.dispatch(p.reduce[op], groups, params{n: uint32(n), blocks: groups}, input, output)
+		input, n = output, int(groups)
+	}
+	result, err := r.read(1, input)
+	if err != nil {
+		return 0, err
+	}
+	return result[0], nil
+}
+
+// ReduceCPU is the CPU reference of Reduce.
+func ReduceCPU(op Op, values []uint32) uint32 {
+	result := op.identity()
+	for _, v := range values {
+		switch op {
+		case Sum:
+			result += v
+		case Min:
+			result = min(result, v)
+		case Max:
+			result = max(result, v)
+		}
+	}
+	return result
+}
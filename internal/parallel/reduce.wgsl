@@ -0,0 +1,63 @@
+// Reduces each block of 512 values to one. OP and IDENTITY pick the
+// operation, the defaults below sum.
+#ifndef OP
+#define OP op_sum
+#define IDENTITY 0u
+#endif
+
+struct Params {
+    n: u32,
+    blocks: u32,
+    _pad0: u32,
+    _pad1: u32,
+};
+
+@group(0) @binding(0) var<uniform> params: Params;
+@group(0) @binding(1) var<storage, read> input: array<u32>;
+@group(0) @binding(2) var<storage, read_write> output: array<u32>;
+
+var<workgroup> partial: array<u32, 256>;
+
+fn op_sum(a: u32, b: u32) -> u32 {
+    return a + b;
+}
+
+fn op_min(a: u32, b: u32) -> u32 {
+    return min(a, b);
+}
+
+fn op_max(a: u32, b: u32) -> u32 {
+    return max(a, b);
+}
+
+fn load(i: u32) -> u32 {
+    if (i < params.n) {
+        return input[i];
+    }
+    return IDENTITY;
+}
+
+@compute
+@workgroup_size(256)
+fn reduce(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    // large inputs are dispatched in two dimensions
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    let i = block * 512u + local_id.x;
+    partial[local_id.x] = OP(load(i), load(i + 256u));
+    workgroupBarrier();
+
+    for (var stride = 128u; stride > 0u; stride = stride >> 1u) {
+        if (local_id.x < stride) {
+            partial[local_id.x] = OP(partial[local_id.x], partial[local_id.x + stride]);
+        }
+        workgroupBarrier();
+    }
+
+    if (local_id.x == 0u && block < params.blocks) {
+        output[block] = partial[0];
+    }
+}
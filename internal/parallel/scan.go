@@ -0,0 +1,60 @@
+package parallel
+
+import (
+	"context"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Scan returns the prefix sums of values, wrapping around on overflow.
+// With inclusive the i-th sum includes values[i], otherwise it is the sum
+// of the values before it.
+func (p *Primitives) Scan(ctx context.Context, values []uint32, inclusive bool) ([]uint32, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if err := p.checkSize(len(values)); err != nil {
+		return nil, err
+	}
+
+	r := p.begin(ctx)
+	data := r.buffer("Scan Data", uint64(len(values))*4, wgpu.ToBytes(values))
+	r.scan(data, len(values), inclusive)
+	return r.read(len(values), data)
+}
+
+// scan records the prefix sums of the first n values of data, in place.
+// The block totals are scanned by a recursive call, a level for every
+// factor of 512.
+func (r *run) scan(data *wgpu.Buffer, n int, inclusive bool) {
+	groups := blocks(n, blockSize)
+	sums := r.buffer("Scan Block Sums", uint64(groups)*4, nil)
+	flag := uint32(0)
+	if inclusive {
+		flag = 1
+	}
+	r.dispatch(r.p.scanBlocks, groups, params{n: uint32(n), blocks: groups, a: flag}, data, sums)
+	if groups == 1 {
+		return
+	}
+	r.scan(sums, int(groups), false)
+	r.dispatch(r.p.addBlockSums, groups, params{n: uint32(n), blocks: groups}, data, sums)
+}
+
+// ScanCPU is the CPU reference of Scan.
+func ScanCPU(values []uint32, inclusive bool) []uint32 {
+	if len(values) == 0 {
+		return nil
+	}
+	sums := make([]uint32, len(values))
+	sum := uint32(0)
+	for i, v := range values {
+		if inclusive {
+			sum += v
+			sums[i] = sum
+		} else {
+			sums[i] = sum
+			sum += v
+		}
+	}
+	return sums
+}
@@ -0,0 +1,86 @@
+// Prefix sums in two steps: scan_blocks scans each block of 512 values in
+// place and stores the block totals, add_block_sums adds the exclusive scan
+// of the totals to every block.
+
+struct Params {
+    n: u32,
+    blocks: u32,
+    inclusive: u32,
+    _pad: u32,
+};
+
+@group(0) @binding(0) var<uniform> params: Params;
+@group(0) @binding(1) var<storage, read_write> data: array<u32>;
+@group(0) @binding(2) var<storage, read_write> block_sums: array<u32>;
+
+var<workgroup> sums: array<u32, 256>;
+
+@compute
+@workgroup_size(256)
+fn scan_blocks(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    // every invocation scans a pair of values
+    let i = block * 512u + local_id.x * 2u;
+    var a = 0u;
+    var b = 0u;
+    if (i < params.n) {
+        a = data[i];
+    }
+    if (i + 1u < params.n) {
+        b = data[i + 1u];
+    }
+
+    sums[local_id.x] = a + b;
+    workgroupBarrier();
+    for (var offset = 1u; offset < 256u; offset = offset << 1u) {
+        var sum = sums[local_id.x];
+        if (local_id.x >= offset) {
+            sum = sum + sums[local_id.x - offset];
+        }
+        workgroupBarrier();
+        sums[local_id.x] = sum;
+        workgroupBarrier();
+    }
+
+    let before = sums[local_id.x] - a - b;
+    var first = before;
+    var second = before + a;
+    if (params.inclusive != 0u) {
+        first = before + a;
+        second = before + a + b;
+    }
+    if (i < params.n) {
+        data[i] = first;
+    }
+    if (i + 1u < params.n) {
+        data[i + 1u] = second;
+    }
+    if (local_id.x == 255u && block < params.blocks) {
+        block_sums[block] = sums[255];
+    }
+}
+
+@compute
+@workgroup_size(256)
+fn add_block_sums(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    if (block >= params.blocks) {
+        return;
+    }
+    let offset = block_sums[block];
+    let i = block * 512u + local_id.x * 2u;
+    if (i < params.n) {
+        data[i] = data[i] + offset;
+    }
+    if (i + 1u < params.n) {
+        data[i + 1u] = data[i + 1u] + offset;
+    }
+}
@@ -0,0 +1,106 @@
+package parallel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+const (
+	// radixBits is the number of key bits sorted by one pass.
+	radixBits = 4
+	radix     = 1 << radixBits
+	// sortBlockSize is the number of keys a workgroup of the sort kernels
+	// handles.
+	sortBlockSize = 256
+)
+
+// Sort sorts keys in ascending order, moving the value at the same index
+// along with every key, with a least significant digit radix sort. The sort
+// is stable. values may be nil, otherwise it must be as long as keys.
+func (p *Primitives) Sort(ctx context.Context, keys, values []uint32) error {
+	if values != nil && len(values) != len(keys) {
+		return fmt.Errorf("parallel: %d values for %d keys", len(values), len(keys))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := p.checkSize(len(keys)); err != nil {
+		return err
+	}
+
+	n := len(keys)
+	size := uint64(n) * 4
+	r := p.begin(ctx)
+	keysIn := r.buffer("Sort Keys", size, wgpu.ToBytes(keys))
+	keysOut := r.buffer("Sort Keys", size, nil)
+	valuesIn := r.buffer("Sort Values", size, nil)
+	if values != nil {
+		valuesIn = r.buffer("Sort Values", size, wgpu.ToBytes(values))
+	}
+	valuesOut := r.buffer("Sort Values", size, nil)
+
+	groups := blocks(n, sortBlockSize)
+	counts := r.buffer("Sort Counts", uint64(groups)*radix*4, nil)
+	for shift := uint32(0); shift < 32; shift += radixBits {
+		params := params{n: uint32(n), blocks: groups, a: shift}
+		r.dispatch(p.count, groups, params, keysIn, nil, counts)
+		r.scan(counts, int(groups)*radix, false)
+		r.dispatch(p.scatter, groups, params, keysIn, valuesIn, counts, keysOut, valuesOut)
+		keysIn, keysOut = keysOut, keysIn
+		valuesIn, valuesOut = valuesOut, valuesIn
+	}
+
+	if values == nil {
+		sorted, err := r.read(n, keysIn)
+		if err != nil {
+			return err
+		}
+		copy(keys, sorted)
+		return nil
+	}
+	sorted, err := r.read(n, keysIn, valuesIn)
+	if err != nil {
+		return err
+	}
+	copy(keys, sorted[:n])
+	copy(values, sorted[n:])
+	return nil
+}
+
+// SortCPU is the CPU reference of Sort, a least significant digit radix
+// sort with the same digits.
+func SortCPU(keys, values []uint32) {
+	if values != nil && len(values) != len(keys) {
+		panic(fmt.Sprintf("parallel: %d values for %d keys", len(values), len(keys)))
+	}
+	keysOut := make([]uint32, len(keys))
+	var valuesOut []uint32
+	if values != nil {
+		valuesOut = make([]uint32, len(values))
+	}
+	keysIn, valuesIn := keys, values
+	for shift := 0; shift < 32; shift += radixBits {
+		var offsets [radix]int
+		for _, k := range keysIn {
+			offsets[k>>shift&(radix-1)]++
+		}
+		sum := 0
+		for digit, count := range offsets {
+			offsets[digit] = sum
+			sum += count
+		}
+		for i, k := range keysIn {
+			digit := k >> shift & (radix - 1)
+			keysOut[offsets[digit]] = k
+			if values != nil {
+				valuesOut[offsets[digit]] = valuesIn[i]
+			}
+			offsets[digit]++
+		}
+		keysIn, keysOut = keysOut, keysIn
+		valuesIn, valuesOut = valuesOut, valuesIn
+	}
+	// an even number of passes ends in the caller's slices
+}
@@ -0,0 +1,77 @@
+// One pass of a least significant digit radix sort over 4 bits of the
+// keys. count builds the digit histogram of every block of 256 keys, stored
+// digit major so that its exclusive scan gives each block the first output
+// index of each digit, and scatter moves the keys and their values there,
+// keeping the order of equal digits.
+
+struct Params {
+    n: u32,
+    blocks: u32,
+    shift: u32,
+    _pad: u32,
+};
+
+@group(0) @binding(0) var<uniform> params: Params;
+@group(0) @binding(1) var<storage, read> keys_in: array<u32>;
+@group(0) @binding(2) var<storage, read> values_in: array<u32>;
+@group(0) @binding(3) var<storage, read_write> counts: array<u32>;
+@group(0) @binding(4) var<storage, read_write> keys_out: array<u32>;
+@group(0) @binding(5) var<storage, read_write> values_out: array<u32>;
+
+var<workgroup> histogram: array<atomic<u32>, 16>;
+var<workgroup> digits: array<u32, 256>;
+
+@compute
+@workgroup_size(256)
+fn count(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    if (local_id.x < 16u) {
+        atomicStore(&histogram[local_id.x], 0u);
+    }
+    workgroupBarrier();
+
+    let i = block * 256u + local_id.x;
+    if (i < params.n) {
+        atomicAdd(&histogram[(keys_in[i] >> params.shift) & 15u], 1u);
+    }
+    workgroupBarrier();
+
+    if (local_id.x < 16u && block < params.blocks) {
+        counts[local_id.x * params.blocks + block] = atomicLoad(&histogram[local_id.x]);
+    }
+}
+
+@compute
+@workgroup_size(256)
+fn scatter(
+    @builtin(local_invocation_id) local_id: vec3<u32>,
+    @builtin(workgroup_id) workgroup_id: vec3<u32>,
+    @builtin(num_workgroups) num_workgroups: vec3<u32>,
+) {
+    let block = workgroup_id.x + workgroup_id.y * num_workgroups.x;
+    let i = block * 256u + local_id.x;
+    // 16 marks the invocations past the end of the keys
+    var digit = 16u;
+    if (i < params.n) {
+        digit = (keys_in[i] >> params.shift) & 15u;
+    }
+    digits[local_id.x] = digit;
+    workgroupBarrier();
+
+    if (digit == 16u) {
+        return;
+    }
+    var rank = 0u;
+    for (var j = 0u; j < local_id.x; j = j + 1u) {
+        if (digits[j] == digit) {
+            rank = rank + 1u;
+        }
+    }
+    let dst = counts[digit * params.blocks + block] + rank;
+    keys_out[dst] = keys_in[i];
+    values_out[dst] = values_in[i];
+}
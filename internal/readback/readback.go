@@ -0,0 +1,91 @@
+// Package readback copies buffers back to the CPU without blocking the
+// caller: the mapping is awaited by a goroutine polling the device, and the
+// result is delivered on a channel.
+package readback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// PollInterval is how often ReadBuffer polls the device while a mapping is
+// pending.
+var PollInterval = time.Millisecond
+
+// DefaultTimeout is the deadline for callers without one of their own, a
+// mapping may never finish, e.g. when the device is lost.
+const DefaultTimeout = 10 * time.Second
+
+// ErrMapFailed is wrapped by the error of a Result when the buffer couldn't
+// be mapped.
+var ErrMapFailed = errors.New("readback: failed to map buffer")
+
+// Result is the outcome of a ReadBuffer, either a copy of the buffer's
+// contents or an error.
+type Result struct {
+	Data []byte
+	Err  error
+}
+
+// ReadBuffer maps size bytes of buffer at offset for reading once the GPU
+// is done with it and sends a copy of them on the returned channel. The
+// buffer needs wgpu.BufferUsage_MapRead, offset must be a multiple of
+// wgpu.MapAlignment and size of 4.
+//
+// The buffer is unmapped before the result is sent. If ctx is done first,
+// the pending mapping is cancelled and the result holds ctx.Err(). The
+// buffer must not be used again until the result was received.
+//
+// Polling the device runs the MapAsync callbacks of other users of the
+// device on ReadBuffer's goroutine too, they must be safe for that.
+func ReadBuffer(ctx context.Context, device *wgpu.Device, buffer *wgpu.Buffer, offset, size uint64) <-chan Result {
+	results := make(chan Result, 1)
+	if offset%wgpu.MapAlignment != 0 || size%4 != 0 {
+		// wgpu aborts the process on unaligned ranges
+		results <- Result{Err: fmt.Errorf("readback: offset %d must be a multiple of %d and size %d a multiple of 4", offset, wgpu.MapAlignment, size)}
+		return results
+	}
+	if err := ctx.Err(); err != nil {
+		results <- Result{Err: err}
+		return results
+	}
+
+	// buffered so that a callback run by someone else's Poll never blocks
+	mapped := make(chan wgpu.BufferMapAsyncStatus, 1)
+	buffer.MapAsync(wgpu.MapMode_Read, offset, size, func(status wgpu.BufferMapAsyncStatus) {
+		mapped <- status
+	})
+
+	go func() {
+		ticker := time.NewTicker(PollInterval)
+		defer ticker.Stop()
+		for {
+			device.Poll(false, nil)
+			select {
+			case status := <-mapped:
+				results <- read(buffer, offset, size, status)
+				return
+			case <-ctx.Done():
+				// unmapping a pending mapping cancels it
+				buffer.Unmap()
+				results <- Result{Err: ctx.Err()}
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return results
+}
+
+func read(buffer *wgpu.Buffer, offset, size uint64, status wgpu.BufferMapAsyncStatus) Result {
+	if status != wgpu.BufferMapAsyncStatus_Success {
+		return Result{Err: fmt.Errorf("%w: %s", ErrMapFailed, status)}
+	}
+	defer buffer.Unmap()
+	data := buffer.GetMappedRange(uint(offset), uint(size))
+	return Result{Data: append([]byte(nil), data...)}
+}
@@ -0,0 +1,128 @@
+package readback
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/staging"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func newDevice(t *testing.T) *wgpu.Device {
+	t.Helper()
+	instance := wgpu.CreateInstance(nil)
+	t.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	t.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	t.Cleanup(device.Drop)
+	return device
+}
+
+// newBuffer returns a MapRead buffer holding contents.
+func newBuffer(t *testing.T, device *wgpu.Device, contents []uint32) *wgpu.Buffer {
+	t.Helper()
+	data := wgpu.ToBytes(contents)
+	buffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
+		Size:  uint64(len(data)),
+		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(buffer.Drop)
+	queue := device.GetQueue()
+	queue.WriteBuffer(buffer, 0, data)
+	queue.Submit()
+	return buffer
+}
+
+func TestReadBuffer(t *testing.T) {
+	device := newDevice(t)
+	want := []uint32{1, 2, 3, 4, 5, 6, 7, 8}
+	buffer := newBuffer(t, device, want)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	result := <-ReadBuffer(ctx, device, buffer, 8, 16)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	got := wgpu.FromBytes[uint32](result.Data)
+	if len(got) != 4 || got[0] != 3 || got[3] != 6 {
+		t.Errorf("got %v, want %v", got, want[2:6])
+	}
+
+	// the buffer is unmapped again and can be read once more
+	if result := <-ReadBuffer(ctx, device, buffer, 0, 32); result.Err != nil {
+		t.Error(result.Err)
+	}
+}
+
+func TestReadBufferErrors(t *testing.T) {
+	device := newDevice(t)
+	buffer := newBuffer(t, device, make([]uint32, 4))
+
+	if result := <-ReadBuffer(context.Background(), device, buffer, 4, 8); result.Err == nil {
+		t.Error("got no error for an unaligned offset")
+	}
+	if result := <-ReadBuffer(context.Background(), device, buffer, 0, 6); result.Err == nil {
+		t.Error("got no error for an unaligned size")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := <-ReadBuffer(ctx, device, buffer, 0, 16); !errors.Is(result.Err, context.Canceled) {
+		t.Errorf("got %v for a cancelled context, want context.Canceled", result.Err)
+	}
+}
+
+// TestReadBufferWithBelt reads back while a staging belt of the same device
+// waits for its chunks, the readback's polling runs the belt's callbacks.
+// Run with -race.
+func TestReadBufferWithBelt(t *testing.T) {
+	device := newDevice(t)
+	queue := device.GetQueue()
+	buffer := newBuffer(t, device, make([]uint32, 64))
+	target, err := device.CreateBuffer(&wgpu.BufferDescriptor{
+		Size:  256,
+		Usage: wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Drop()
+
+	belt := staging.NewBelt(device, 256)
+	defer belt.Drop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	for frame := 0; frame < 20; frame++ {
+		results := ReadBuffer(ctx, device, buffer, 0, 256)
+
+		encoder, err := device.CreateCommandEncoder(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := belt.WriteBuffer(encoder, target, 0, make([]byte, 64)); err != nil {
+			t.Fatal(err)
+		}
+		belt.Finish()
+		belt.Fence(queue.Submit(encoder.Finish(nil)))
+
+		if result := <-results; result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+	if chunks := belt.Stats().Chunks; chunks > 3 {
+		t.Errorf("the belt has %d chunks, its chunks aren't reused", chunks)
+	}
+}
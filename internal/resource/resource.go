@@ -0,0 +1,308 @@
+// Package resource records how GPU objects were created so that they can be
+// recreated on a new device after the old one was lost.
+//
+// Objects are created through a Registry and held as *Handle values. A
+// handle's create function is rerun on Rebuild, so descriptors must refer to
+// other objects through their handles, e.g. layout.Get(), not through raw
+// values captured at creation time.
+//
+// Create functions must not have side effects beyond creating the object:
+// Rebuild and Recreate run them before knowing whether the whole rebuild
+// succeeds, and if a later one fails the new objects are dropped and the
+// old ones stay in use. State derived from an object, like a wrapper around
+// a buffer, has to be derived from Get after the rebuild instead.
+package resource
+
+import (
+	"fmt"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+type Droppable interface {
+	Drop()
+}
+
+type Handle[T Droppable] struct {
+	value   T
+	create  func(device *wgpu.Device, queue *wgpu.Queue) (T, error)
+	entry   *leak.Entry
+	dropped bool
+	// dependent is set for objects built from a shader module, see
+	// Recreate
+	dependent bool
+
+	// old is the value replaced by prepare until commit or abort
+	old      T
+	prepared bool
+}
+
+// Get returns the object for the current device, it must not be kept
+// across frames.
+func (h *Handle[T]) Get() T {
+	return h.value
+}
+
+// Drop drops the object and removes it from the registry's rebuild list.
+// A nil handle, e.g. of an App whose Init failed early, is ignored.
+func (h *Handle[T]) Drop() {
+	if h == nil {
+		return
+	}
+	if h.entry.Drop() && !h.dropped {
+		h.value.Drop()
+		h.dropped = true
+	}
+}
+
+// prepare creates a new value but keeps the old one until commit or abort.
+func (h *Handle[T]) prepare(device *wgpu.Device, queue *wgpu.Queue) error {
+	value, err := h.create(device, queue)
+	if err != nil {
+		return err
+	}
+	h.old, h.value = h.value, value
+	h.prepared = true
+	return nil
+}
+
+func (h *Handle[T]) commit() {
+	if h.prepared {
+		h.old.Drop()
+		h.old = *new(T)
+		h.prepared = false
+	}
+}
+
+func (h *Handle[T]) abort() {
+	if h.prepared {
+		h.value.Drop()
+		h.value, h.old = h.old, *new(T)
+		h.prepared = false
+	}
+}
+
+func (h *Handle[T]) isDropped() bool {
+	return h.dropped
+}
+
+func (h *Handle[T]) isDependent() bool {
+	return h.dependent
+}
+
+// dependsOnShader reports whether value has to be recreated when a shader
+// module created before it changes: pipelines are compiled from one, and
+// bind groups may use a layout taken from a pipeline.
+func dependsOnShader(value any) bool {
+	switch value.(type) {
+	case *wgpu.RenderPipeline, *wgpu.ComputePipeline, *wgpu.BindGroup:
+		return true
+	}
+	return false
+}
+
+type entry interface {
+	prepare(device *wgpu.Device, queue *wgpu.Queue) error
+	commit()
+	abort()
+	isDropped() bool
+	isDependent() bool
+	Drop()
+}
+
+// Registry keeps every live handle in creation order, which is also the
+// order dependencies have to be rebuilt in.
+type Registry struct {
+	// Leaks, if set, tracks the handles created afterwards.
+	Leaks *leak.Tracker
+
+	device  *wgpu.Device
+	queue   *wgpu.Queue
+	entries []entry
+}
+
+func NewRegistry(device *wgpu.Device) *Registry {
+	return &Registry{
+		device: device,
+		queue:  device.GetQueue(),
+	}
+}
+
+func (r *Registry) Device() *wgpu.Device {
+	return r.device
+}
+
+func (r *Registry) Queue() *wgpu.Queue {
+	return r.queue
+}
+
+// Track creates an object with create and records create for Rebuild.
+// create must not have side effects, see the package documentation.
+func Track[T Droppable](r *Registry, create func(device *wgpu.Device, queue *wgpu.Queue) (T, error)) (*Handle[T], error) {
+	return track(r, create, 1)
+}
+
+// track is Track leaving skip callers of track out of the leak stack, so
+// that it starts at the caller of the exported function.
+func track[T Droppable](r *Registry, create func(device *wgpu.Device, queue *wgpu.Queue) (T, error), skip int) (*Handle[T], error) {
+	value, err := create(r.device, r.queue)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handle[T]{
+		value:     value,
+		create:    create,
+		entry:     r.Leaks.Add(fmt.Sprintf("%T", value), skip+1),
+		dependent: dependsOnShader(value),
+	}
+	r.compact()
+	r.entries = append(r.entries, h)
+	return h, nil
+}
+
+// Rebuild recreates all live objects on device and then drops the old
+// ones. If any creation fails the new objects are dropped instead, the old
+// ones and the old device stay in the registry and are dropped only once.
+func (r *Registry) Rebuild(device *wgpu.Device) error {
+	oldDevice, oldQueue := r.device, r.queue
+	r.device = device
+	r.queue = device.GetQueue()
+
+	r.compact()
+	if err := r.replace(r.entries); err != nil {
+		r.device, r.queue = oldDevice, oldQueue
+		return err
+	}
+	return nil
+}
+
+// Recreate recreates h on the current device, e.g. because h's source
+// changed, together with the pipelines and bind groups created after it.
+// Other objects, in particular buffers and textures, keep their contents.
+// The new objects are created before the old ones are dropped, if any
+// creation fails the new ones are dropped instead and the old ones stay in
+// use.
+func Recreate[T Droppable](r *Registry, h *Handle[T]) error {
+	r.compact()
+	start := -1
+	for i, e := range r.entries {
+		if e == entry(h) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return fmt.Errorf("resource: handle is not in the registry")
+	}
+	entries := []entry{h}
+	for _, e := range r.entries[start+1:] {
+		if e.isDependent() {
+			entries = append(entries, e)
+		}
+	}
+	return r.replace(entries)
+}
+
+// replace creates new objects for entries in order and drops the old ones
+// in reverse order, or drops the new ones if a creation fails.
+func (r *Registry) replace(entries []entry) error {
+	for i, e := range entries {
+		if err := e.prepare(r.device, r.queue); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				entries[j].abort()
+			}
+			return err
+		}
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entries[i].commit()
+	}
+	return nil
+}
+
+// Drop drops all live objects in reverse creation order.
+func (r *Registry) Drop() {
+	r.compact()
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		r.entries[i].Drop()
+	}
+	r.entries = nil
+}
+
+func (r *Registry) compact() {
+	live := r.entries[:0]
+	for _, e := range r.entries {
+		if !e.isDropped() {
+			live = append(live, e)
+		}
+	}
+	r.entries = live
+}
+
+// The helpers below cover objects whose descriptors don't refer to other
+// objects, use Track with a closure for the rest.
+
+func (r *Registry) CreateBuffer(descriptor *wgpu.BufferDescriptor) (*Handle[*wgpu.Buffer], error) {
+	desc := *descriptor
+	return track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.Buffer, error) {
+		return device.CreateBuffer(&desc)
+	}, 1)
+}
+
+// CreateBufferInit keeps a copy of the initial contents so the buffer is
+// recreated with them, later writes through the queue are not recorded.
+func (r *Registry) CreateBufferInit(descriptor *wgpu.BufferInitDescriptor) (*Handle[*wgpu.Buffer], error) {
+	desc := *descriptor
+	desc.Contents = append([]byte(nil), descriptor.Contents...)
+	return track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.Buffer, error) {
+		return device.CreateBufferInit(&desc)
+	}, 1)
+}
+
+// CreateTextureInit creates a texture and uploads data to its first mip
+// level, data is kept for Rebuild.
+func (r *Registry) CreateTextureInit(descriptor *wgpu.TextureDescriptor, data []byte, layout wgpu.TextureDataLayout) (*Handle[*wgpu.Texture], error) {
+	return r.createTexture(descriptor, data, layout, 1)
+}
+
+func (r *Registry) CreateTexture(descriptor *wgpu.TextureDescriptor) (*Handle[*wgpu.Texture], error) {
+	return r.createTexture(descriptor, nil, wgpu.TextureDataLayout{}, 1)
+}
+
+func (r *Registry) createTexture(descriptor *wgpu.TextureDescriptor, data []byte, layout wgpu.TextureDataLayout, skip int) (*Handle[*wgpu.Texture], error) {
+	desc := *descriptor
+	data = append([]byte(nil), data...)
+	return track(r, func(device *wgpu.Device, queue *wgpu.Queue) (*wgpu.Texture, error) {
+		texture, err := device.CreateTexture(&desc)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			queue.WriteTexture(texture.AsImageCopy(), data, &layout, &desc.Size)
+		}
+		return texture, nil
+	}, skip+1)
+}
+
+func (r *Registry) CreateSampler(descriptor *wgpu.SamplerDescriptor) (*Handle[*wgpu.Sampler], error) {
+	desc := *descriptor
+	return track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.Sampler, error) {
+		return device.CreateSampler(&desc)
+	}, 1)
+}
+
+func (r *Registry) CreateShaderModule(descriptor *wgpu.ShaderModuleDescriptor) (*Handle[*wgpu.ShaderModule], error) {
+	desc := *descriptor
+	return track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.ShaderModule, error) {
+		return device.CreateShaderModule(&desc)
+	}, 1)
+}
+
+func (r *Registry) CreateBindGroupLayout(descriptor *wgpu.BindGroupLayoutDescriptor) (*Handle[*wgpu.BindGroupLayout], error) {
+	desc := *descriptor
+	return track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroupLayout, error) {
+		return device.CreateBindGroupLayout(&desc)
+	}, 1)
+}
@@ -0,0 +1,257 @@
+package resource
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// object is a fake GPU object counting its drops.
+type object struct {
+	name  string
+	drops int
+}
+
+func (o *object) Drop() {
+	o.drops++
+}
+
+// source creates fake objects, failing once fail is set.
+type source struct {
+	name    string
+	created []*object
+	fail    bool
+}
+
+func (s *source) create(*wgpu.Device, *wgpu.Queue) (*object, error) {
+	if s.fail {
+		return nil, errors.New(s.name + " failed")
+	}
+	o := &object{name: s.name}
+	s.created = append(s.created, o)
+	return o, nil
+}
+
+func newSources(t *testing.T, r *Registry, names ...string) ([]*source, []*Handle[*object]) {
+	t.Helper()
+	var sources []*source
+	var handles []*Handle[*object]
+	for _, name := range names {
+		s := &source{name: name}
+		h, err := Track(r, s.create)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, s)
+		handles = append(handles, h)
+	}
+	return sources, handles
+}
+
+func checkDrops(t *testing.T, sources []*source, want func(s *source, i int) int) {
+	t.Helper()
+	for _, s := range sources {
+		for i, o := range s.created {
+			if w := want(s, i); o.drops != w {
+				t.Errorf("%s #%d dropped %d times, want %d", s.name, i, o.drops, w)
+			}
+		}
+	}
+}
+
+func newDevice(t *testing.T) *wgpu.Device {
+	t.Helper()
+	instance := wgpu.CreateInstance(nil)
+	t.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	t.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	t.Cleanup(device.Drop)
+	return device
+}
+
+func TestRecreate(t *testing.T) {
+	r := &Registry{}
+	sources, handles := newSources(t, r, "a", "b", "c", "d")
+	// c stands for a buffer, d for a pipeline built from the shader b
+	handles[3].dependent = true
+
+	if err := Recreate(r, handles[1]); err != nil {
+		t.Fatal(err)
+	}
+	recreated := map[string]bool{"b": true, "d": true}
+	for i, h := range handles {
+		s := sources[i]
+		want := 1
+		if recreated[s.name] {
+			want = 2
+		}
+		if n := len(s.created); n != want {
+			t.Errorf("%s was created %d times, want %d", s.name, n, want)
+		}
+		if h.Get() != s.created[len(s.created)-1] {
+			t.Errorf("%s doesn't hold its newest object after Recreate", s.name)
+		}
+	}
+	checkDrops(t, sources, func(s *source, i int) int {
+		if recreated[s.name] && i == 0 {
+			return 1
+		}
+		return 0
+	})
+}
+
+func TestRecreateFailure(t *testing.T) {
+	r := &Registry{}
+	sources, handles := newSources(t, r, "a", "b", "c")
+	handles[1].dependent = true
+	handles[2].dependent = true
+	sources[2].fail = true
+
+	if err := Recreate(r, handles[0]); err == nil {
+		t.Fatal("Recreate succeeded, want the error of c")
+	}
+	for i, h := range handles {
+		if h.Get() != sources[i].created[0] {
+			t.Errorf("%s doesn't hold its old object after a failed Recreate", sources[i].name)
+		}
+	}
+	// the new a and b are dropped, the old objects stay in use
+	checkDrops(t, sources, func(s *source, i int) int {
+		return i
+	})
+
+	r.Drop()
+	checkDrops(t, sources, func(*source, int) int { return 1 })
+}
+
+func TestRecreateShader(t *testing.T) {
+	device := newDevice(t)
+	r := NewRegistry(device)
+	defer r.Drop()
+
+	buffer, err := r.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Contents: make([]byte, 16),
+		Usage:    wgpu.BufferUsage_Storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shader, err := r.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
+		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: `
+@group(0) @binding(0) var<storage, read_write> data: array<u32>;
+
+@compute @workgroup_size(1)
+fn main() {
+	data[0] = 1u;
+}
+`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := Track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.ComputePipeline, error) {
+		return device.CreateComputePipeline(&wgpu.ComputePipelineDescriptor{
+			Compute: wgpu.ProgrammableStageDescriptor{Module: shader.Get(), EntryPoint: "main"},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindGroup, err := Track(r, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		layout := pipeline.Get().GetBindGroupLayout(0)
+		defer layout.Drop()
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout:  layout,
+			Entries: []wgpu.BindGroupEntry{{Binding: 0, Buffer: buffer.Get(), Size: wgpu.WholeSize}},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldBuffer, oldShader, oldPipeline, oldBindGroup := buffer.Get(), shader.Get(), pipeline.Get(), bindGroup.Get()
+	if err := Recreate(r, shader); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.Get() != oldBuffer {
+		t.Error("the buffer was recreated, its contents are lost")
+	}
+	if shader.Get() == oldShader {
+		t.Error("the shader module wasn't recreated")
+	}
+	if pipeline.Get() == oldPipeline {
+		t.Error("the pipeline wasn't recreated from the new shader module")
+	}
+	if bindGroup.Get() == oldBindGroup {
+		t.Error("the bind group wasn't recreated with the new pipeline's layout")
+	}
+}
+
+func TestRebuildFailure(t *testing.T) {
+	device := newDevice(t)
+	r := NewRegistry(device)
+	sources, handles := newSources(t, r, "a", "b", "c")
+	sources[1].fail = true
+
+	if err := r.Rebuild(device); err == nil {
+		t.Fatal("Rebuild succeeded, want the error of b")
+	}
+	for i, h := range handles {
+		if h.Get() != sources[i].created[0] {
+			t.Errorf("%s doesn't hold its old object after a failed Rebuild", sources[i].name)
+		}
+	}
+
+	// every object is dropped exactly once, the new a by Rebuild and the
+	// old ones by Drop
+	r.Drop()
+	checkDrops(t, sources, func(*source, int) int { return 1 })
+
+	handles[0].Drop()
+	checkDrops(t, sources, func(*source, int) int { return 1 })
+}
+
+func TestLeakStack(t *testing.T) {
+	device := newDevice(t)
+	r := NewRegistry(device)
+	r.Leaks = leak.NewTracker()
+	defer r.Drop()
+
+	if _, err := Track(r, (&source{name: "a"}).create); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateBuffer(&wgpu.BufferDescriptor{Size: 16, Usage: wgpu.BufferUsage_CopyDst}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTexture(&wgpu.TextureDescriptor{
+		Size:          wgpu.Extent3D{Width: 1, Height: 1, DepthOrArrayLayers: 1},
+		MipLevelCount: 1,
+		SampleCount:   1,
+		Dimension:     wgpu.TextureDimension_2D,
+		Format:        wgpu.TextureFormat_RGBA8Unorm,
+		Usage:         wgpu.TextureUsage_CopyDst,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	leaks := r.Leaks.Leaks()
+	if len(leaks) != 3 {
+		t.Fatalf("got %d leaks, want 3", len(leaks))
+	}
+	for _, e := range leaks {
+		first, _, _ := strings.Cut(strings.TrimSpace(e.Stack), "\n")
+		if !strings.HasSuffix(first, ".TestLeakStack") {
+			t.Errorf("stack of %s starts at %s, want the test", e.Kind, first)
+		}
+	}
+}
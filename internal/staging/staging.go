@@ -0,0 +1,372 @@
+// Package staging uploads data to buffers and textures through a belt of
+// staging buffers.
+//
+// Instead of one queue write per upload, a Belt copies the data into a
+// mapped chunk of a staging buffer and records a copy from it in the
+// frame's command encoder. Chunks are created mapped, unmapped by Finish
+// before the frame is submitted and mapped again once the GPU is done with
+// the submission, after which they are reused.
+package staging
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// DefaultChunkSize is the size of the chunks NewBelt creates when chunkSize
+// is 0.
+const DefaultChunkSize = 1 << 20
+
+// Belt pools staging buffers. A frame writes through it while recording,
+// calls Finish before its commands are submitted and Fence with the
+// submission index afterwards, see framework.SubmitHandler.
+//
+// A Belt belongs to one device, create a new one after a device loss.
+type Belt struct {
+	// MaxSize limits the bytes of all chunks. When a new chunk would exceed
+	// it the belt waits for the oldest submission to free its chunks
+	// instead, 0 means no limit. Uploads larger than the chunk size get a
+	// chunk of their own and can exceed it.
+	MaxSize uint64
+	// Leaks, if set, tracks the chunks created afterwards.
+	Leaks *leak.Tracker
+	// Logger reports chunks that couldn't be mapped again after their
+	// submission, the belt drops them and creates new ones as needed.
+	Logger *slog.Logger
+
+	device    *wgpu.Device
+	queue     *wgpu.Queue
+	chunkSize uint64
+
+	// active chunks are mapped and written by the current frame, closed
+	// ones are unmapped by Finish and wait for Fence.
+	active   []*chunk
+	closed   []*chunk
+	inFlight []*batch
+	free     []*chunk
+
+	allocated uint64
+	chunks    int
+	written   uint64
+}
+
+type chunk struct {
+	buffer *wgpu.Buffer
+	entry  *leak.Entry
+	size   uint64
+	offset uint64
+	mapped []byte
+	// remapped receives the status of the mapping requested by Fence. The
+	// callback runs on whichever goroutine polls the device, e.g. one of
+	// package readback.
+	remapped chan wgpu.BufferMapAsyncStatus
+}
+
+// batch is the chunks used by one submission.
+type batch struct {
+	index  wgpu.SubmissionIndex
+	chunks []*chunk
+}
+
+// Stats is a Belt's memory usage.
+type Stats struct {
+	// Chunks is the number of staging buffers and Allocated their size.
+	Chunks    int
+	Allocated uint64
+	// Active is the size of the chunks written by the current frame,
+	// InFlight of those used by submissions that may not be done yet and
+	// Free of those ready to be reused.
+	Active   uint64
+	InFlight uint64
+	Free     uint64
+	// Written is the number of bytes uploaded through the belt.
+	Written uint64
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d chunks, %d KiB (active %d KiB, in flight %d KiB, free %d KiB), %d KiB written",
+		s.Chunks, s.Allocated/1024, s.Active/1024, s.InFlight/1024, s.Free/1024, s.Written/1024)
+}
+
+// NewBelt returns a belt creating chunks of chunkSize bytes on device.
+func NewBelt(device *wgpu.Device, chunkSize uint64) *Belt {
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &Belt{
+		Logger:    slog.Default(),
+		device:    device,
+		queue:     device.GetQueue(),
+		chunkSize: alignUp(chunkSize, wgpu.CopyBytesPerRowAlignment),
+	}
+}
+
+// WriteBuffer records a copy of data to target at offset in encoder.
+// target needs wgpu.BufferUsage_CopyDst, offset and the length of data must
+// be multiples of 4.
+func (b *Belt) WriteBuffer(encoder *wgpu.CommandEncoder, target *wgpu.Buffer, offset uint64, data []byte) error {
+	if offset%wgpu.CopyBufferAlignment != 0 || len(data)%wgpu.CopyBufferAlignment != 0 {
+		return fmt.Errorf("staging: offset %d and size %d must be multiples of %d", offset, len(data), wgpu.CopyBufferAlignment)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	c, at, err := b.alloc(uint64(len(data)), wgpu.CopyBufferAlignment)
+	if err != nil {
+		return err
+	}
+	copy(c.mapped[at:], data)
+	encoder.CopyBufferToBuffer(c.buffer, at, target, offset, uint64(len(data)))
+	b.written += uint64(len(data))
+	return nil
+}
+
+// WriteTexture records a copy of data to destination in encoder, taking
+// the same arguments as wgpu.Queue.WriteTexture. layout.BytesPerRow doesn't
+// need to be aligned, the belt pads the rows as buffer to texture copies
+// require. Like for wgpu it may only be left out, as 0 or
+// wgpu.CopyStrideUndefined, for a copy of a single row, which then takes
+// the rest of data. Only formats with one texel per block are supported.
+func (b *Belt) WriteTexture(encoder *wgpu.CommandEncoder, destination *wgpu.ImageCopyTexture, data []byte, layout *wgpu.TextureDataLayout, size *wgpu.Extent3D) error {
+	rows, layers := uint64(size.Height), uint64(size.DepthOrArrayLayers)
+	if rows == 0 || layers == 0 || size.Width == 0 {
+		return nil
+	}
+	rowsPerImage := rows
+	if layout.RowsPerImage != wgpu.CopyStrideUndefined {
+		rowsPerImage = uint64(layout.RowsPerImage)
+	}
+	bytesPerRow := uint64(layout.BytesPerRow)
+	if layout.BytesPerRow == 0 || layout.BytesPerRow == wgpu.CopyStrideUndefined {
+		if rows > 1 || layers > 1 {
+			return fmt.Errorf("staging: BytesPerRow is needed to copy %d rows", rows*layers)
+		}
+		if layout.Offset >= uint64(len(data)) {
+			return fmt.Errorf("staging: texture data is %d bytes, the layout starts at %d", len(data), layout.Offset)
+		}
+		bytesPerRow = uint64(len(data)) - layout.Offset
+	}
+	if need := layout.Offset + ((layers-1)*rowsPerImage+rows-1)*bytesPerRow + bytesPerRow; uint64(len(data)) < need {
+		return fmt.Errorf("staging: texture data is %d bytes, the layout needs %d", len(data), need)
+	}
+
+	paddedBytesPerRow := alignUp(bytesPerRow, wgpu.CopyBytesPerRowAlignment)
+	c, at, err := b.alloc(paddedBytesPerRow*rows*layers, wgpu.CopyBytesPerRowAlignment)
+	if err != nil {
+		return err
+	}
+	for z := uint64(0); z < layers; z++ {
+		for y := uint64(0); y < rows; y++ {
+			src := layout.Offset + (z*rowsPerImage+y)*bytesPerRow
+			dst := at + (z*rows+y)*paddedBytesPerRow
+			copy(c.mapped[dst:dst+bytesPerRow], data[src:src+bytesPerRow])
+		}
+	}
+	encoder.CopyBufferToTexture(
+		&wgpu.ImageCopyBuffer{
+			Buffer: c.buffer,
+			Layout: wgpu.TextureDataLayout{
+				Offset:       at,
+				BytesPerRow:  uint32(paddedBytesPerRow),
+				RowsPerImage: uint32(rows),
+			},
+		},
+		destination,
+		size,
+	)
+	b.written += bytesPerRow * rows * layers
+	return nil
+}
+
+// alloc returns a mapped chunk with size free bytes at an offset aligned to
+// align.
+func (b *Belt) alloc(size, align uint64) (*chunk, uint64, error) {
+	for _, c := range b.active {
+		if at := alignUp(c.offset, align); at+size <= c.size {
+			c.offset = at + size
+			return c, at, nil
+		}
+	}
+
+	c, err := b.take(size)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.offset = size
+	b.active = append(b.active, c)
+	return c, 0, nil
+}
+
+// take returns a free chunk of at least size bytes, creating one if needed.
+func (b *Belt) take(size uint64) (*chunk, error) {
+	b.recall(false)
+	if c := b.takeFree(size); c != nil {
+		return c, nil
+	}
+	chunkSize := max(b.chunkSize, alignUp(size, wgpu.CopyBytesPerRowAlignment))
+	for b.MaxSize > 0 && b.allocated+chunkSize > b.MaxSize && len(b.inFlight) > 0 {
+		n := len(b.inFlight)
+		b.recall(true)
+		if len(b.inFlight) == n {
+			break
+		}
+		if c := b.takeFree(size); c != nil {
+			return c, nil
+		}
+	}
+
+	buffer, err := b.device.CreateBuffer(&wgpu.BufferDescriptor{
+		Label:            "Staging Belt Chunk",
+		Size:             chunkSize,
+		Usage:            wgpu.BufferUsage_MapWrite | wgpu.BufferUsage_CopySrc,
+		MappedAtCreation: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.allocated += chunkSize
+	b.chunks++
+	return &chunk{
+		buffer: buffer,
+		// the stack starts at the WriteBuffer or WriteTexture call
+		entry:  b.Leaks.Add("*wgpu.Buffer", 3),
+		size:   chunkSize,
+		mapped: buffer.GetMappedRange(0, uint(chunkSize)),
+	}, nil
+}
+
+func (b *Belt) takeFree(size uint64) *chunk {
+	for i, c := range b.free {
+		if c.size >= size {
+			b.free = append(b.free[:i], b.free[i+1:]...)
+			c.offset = 0
+			return c
+		}
+	}
+	return nil
+}
+
+// Finish unmaps the chunks written since the last Finish, it must be
+// called before the encoder's commands are submitted.
+func (b *Belt) Finish() {
+	for _, c := range b.active {
+		c.mapped = nil
+		c.buffer.Unmap()
+	}
+	b.closed = append(b.closed, b.active...)
+	b.active = nil
+}
+
+// Fence records that the chunks closed by Finish are used by the
+// submission index. They are mapped again and reused once the GPU is done
+// with it.
+func (b *Belt) Fence(index wgpu.SubmissionIndex) {
+	if len(b.closed) == 0 {
+		return
+	}
+	for _, c := range b.closed {
+		remapped := make(chan wgpu.BufferMapAsyncStatus, 1)
+		c.remapped = remapped
+		c.buffer.MapAsync(wgpu.MapMode_Write, 0, c.size, func(status wgpu.BufferMapAsyncStatus) {
+			remapped <- status
+		})
+	}
+	b.inFlight = append(b.inFlight, &batch{index: index, chunks: b.closed})
+	b.closed = nil
+}
+
+// recall moves the chunks of finished submissions to the free list. With
+// wait it blocks until the oldest submission is done.
+func (b *Belt) recall(wait bool) {
+	if len(b.inFlight) == 0 {
+		return
+	}
+	if wait {
+		b.device.Poll(true, &wgpu.WrappedSubmissionIndex{
+			Queue:           b.queue,
+			SubmissionIndex: b.inFlight[0].index,
+		})
+	} else {
+		b.device.Poll(false, nil)
+	}
+
+	for len(b.inFlight) > 0 {
+		oldest := b.inFlight[0]
+		pending := oldest.chunks[:0]
+		for _, c := range oldest.chunks {
+			select {
+			case status := <-c.remapped:
+				if status != wgpu.BufferMapAsyncStatus_Success {
+					b.Logger.Warn("staging: dropping a chunk that couldn't be mapped again", "status", status)
+					b.drop(c)
+					continue
+				}
+				c.mapped = c.buffer.GetMappedRange(0, uint(c.size))
+				b.free = append(b.free, c)
+			default:
+				pending = append(pending, c)
+			}
+		}
+		oldest.chunks = pending
+		if len(pending) > 0 {
+			break
+		}
+		b.inFlight = b.inFlight[1:]
+	}
+}
+
+// Stats returns the current memory usage.
+func (b *Belt) Stats() Stats {
+	b.recall(false)
+	s := Stats{Chunks: b.chunks, Allocated: b.allocated, Written: b.written}
+	for _, c := range b.active {
+		s.Active += c.size
+	}
+	for _, c := range b.closed {
+		s.Active += c.size
+	}
+	for _, batch := range b.inFlight {
+		for _, c := range batch.chunks {
+			s.InFlight += c.size
+		}
+	}
+	for _, c := range b.free {
+		s.Free += c.size
+	}
+	return s
+}
+
+func (b *Belt) drop(c *chunk) {
+	if c.entry.Drop() {
+		c.buffer.Drop()
+	}
+	b.allocated -= c.size
+	b.chunks--
+}
+
+// Drop drops all chunks. Chunks still used by a submission are dropped
+// too, wgpu keeps them alive until the GPU is done with them.
+func (b *Belt) Drop() {
+	for _, c := range b.active {
+		b.drop(c)
+	}
+	for _, c := range b.closed {
+		b.drop(c)
+	}
+	for _, batch := range b.inFlight {
+		for _, c := range batch.chunks {
+			b.drop(c)
+		}
+	}
+	for _, c := range b.free {
+		b.drop(c)
+	}
+	b.active, b.closed, b.inFlight, b.free = nil, nil, nil, nil
+}
+
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) / align * align
+}
@@ -0,0 +1,258 @@
+package staging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/readback"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func newDevice(t *testing.T) *wgpu.Device {
+	t.Helper()
+	instance := wgpu.CreateInstance(nil)
+	t.Cleanup(instance.Drop)
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	t.Cleanup(adapter.Drop)
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	t.Cleanup(device.Drop)
+	return device
+}
+
+func newBuffer(t *testing.T, device *wgpu.Device, size uint64) *wgpu.Buffer {
+	t.Helper()
+	buffer, err := device.CreateBuffer(&wgpu.BufferDescriptor{
+		Size:  size,
+		Usage: wgpu.BufferUsage_MapRead | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(buffer.Drop)
+	return buffer
+}
+
+// frame records write with the belt and submits it like a frame of the
+// framework would, then returns the submission index.
+func frame(t *testing.T, device *wgpu.Device, belt *Belt, write func(encoder *wgpu.CommandEncoder) error) wgpu.SubmissionIndex {
+	t.Helper()
+	encoder, err := device.CreateCommandEncoder(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := write(encoder); err != nil {
+		t.Fatal(err)
+	}
+	belt.Finish()
+	index := device.GetQueue().Submit(encoder.Finish(nil))
+	belt.Fence(index)
+	return index
+}
+
+func read(t *testing.T, device *wgpu.Device, buffer *wgpu.Buffer, size uint64) []byte {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), readback.DefaultTimeout)
+	defer cancel()
+	result := <-readback.ReadBuffer(ctx, device, buffer, 0, size)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	return result.Data
+}
+
+func TestWriteBuffer(t *testing.T) {
+	device := newDevice(t)
+	target := newBuffer(t, device, 64)
+	belt := NewBelt(device, 256)
+	defer belt.Drop()
+
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	frame(t, device, belt, func(encoder *wgpu.CommandEncoder) error {
+		if err := belt.WriteBuffer(encoder, target, 0, data[:32]); err != nil {
+			return err
+		}
+		return belt.WriteBuffer(encoder, target, 32, data[32:])
+	})
+
+	if got := read(t, device, target, 64); !bytes.Equal(got, data) {
+		t.Errorf("got %v, want %v", got, data)
+	}
+	if err := belt.WriteBuffer(nil, target, 2, data[:4]); err == nil {
+		t.Error("got no error for an unaligned offset")
+	}
+}
+
+func TestChunkReuse(t *testing.T) {
+	device := newDevice(t)
+	target := newBuffer(t, device, 64)
+	belt := NewBelt(device, 256)
+	defer belt.Drop()
+
+	for i := 0; i < 10; i++ {
+		frame(t, device, belt, func(encoder *wgpu.CommandEncoder) error {
+			return belt.WriteBuffer(encoder, target, 0, make([]byte, 64))
+		})
+		// the submission is done, its chunk can be mapped again
+		device.Poll(true, nil)
+	}
+	if s := belt.Stats(); s.Chunks != 1 {
+		t.Errorf("the belt has %d chunks after 10 frames, want 1 reused chunk", s.Chunks)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	device := newDevice(t)
+	target := newBuffer(t, device, 256)
+	belt := NewBelt(device, 256)
+	belt.MaxSize = 256
+	defer belt.Drop()
+
+	// every frame fills a whole chunk and doesn't wait for the GPU, the
+	// belt has to wait for the previous frame instead of creating a chunk
+	for i := 0; i < 5; i++ {
+		frame(t, device, belt, func(encoder *wgpu.CommandEncoder) error {
+			return belt.WriteBuffer(encoder, target, 0, make([]byte, 256))
+		})
+		if s := belt.Stats(); s.Allocated > belt.MaxSize {
+			t.Fatalf("frame %d: %d bytes allocated, over MaxSize %d", i, s.Allocated, belt.MaxSize)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	device := newDevice(t)
+	target := newBuffer(t, device, 512)
+	belt := NewBelt(device, 256)
+
+	check := func(when string, want Stats) {
+		t.Helper()
+		if got := belt.Stats(); got != want {
+			t.Errorf("%s: got %+v, want %+v", when, got, want)
+		}
+	}
+
+	encoder, err := device.CreateCommandEncoder(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := belt.WriteBuffer(encoder, target, 0, make([]byte, 64)); err != nil {
+		t.Fatal(err)
+	}
+	check("after a write", Stats{Chunks: 1, Allocated: 256, Active: 256, Written: 64})
+	belt.Finish()
+	check("after Finish", Stats{Chunks: 1, Allocated: 256, Active: 256, Written: 64})
+
+	index := device.GetQueue().Submit(encoder.Finish(nil))
+	belt.Fence(index)
+	if s := belt.Stats(); s.Active != 0 || s.InFlight+s.Free != 256 {
+		t.Errorf("after Fence: got %+v, want the chunk in flight or free", s)
+	}
+	device.Poll(true, nil)
+	check("once the GPU is done", Stats{Chunks: 1, Allocated: 256, Free: 256, Written: 64})
+
+	// the free chunk is too small, the upload gets a chunk of its own
+	frame(t, device, belt, func(encoder *wgpu.CommandEncoder) error {
+		return belt.WriteBuffer(encoder, target, 0, make([]byte, 300))
+	})
+	device.Poll(true, nil)
+	check("after a large write", Stats{Chunks: 2, Allocated: 256 + 512, Free: 256 + 512, Written: 364})
+
+	belt.Drop()
+	check("after Drop", Stats{Written: 364})
+}
+
+func TestWriteTexture(t *testing.T) {
+	device := newDevice(t)
+	texture, err := device.CreateTexture(&wgpu.TextureDescriptor{
+		Size:          wgpu.Extent3D{Width: 2, Height: 2, DepthOrArrayLayers: 1},
+		MipLevelCount: 1,
+		SampleCount:   1,
+		Dimension:     wgpu.TextureDimension_2D,
+		Format:        wgpu.TextureFormat_RGBA8Unorm,
+		Usage:         wgpu.TextureUsage_CopyDst | wgpu.TextureUsage_CopySrc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer texture.Drop()
+	target := newBuffer(t, device, 2*wgpu.CopyBytesPerRowAlignment)
+	belt := NewBelt(device, 0)
+	defer belt.Drop()
+
+	// two rows of 8 bytes, which the belt pads to 256
+	data := []byte{
+		1, 2, 3, 4, 5, 6, 7, 8,
+		9, 10, 11, 12, 13, 14, 15, 16,
+	}
+	dst := &wgpu.ImageCopyTexture{Texture: texture, MipLevel: 0, Aspect: wgpu.TextureAspect_All}
+	frame(t, device, belt, func(encoder *wgpu.CommandEncoder) error {
+		err := belt.WriteTexture(encoder, dst, data,
+			&wgpu.TextureDataLayout{BytesPerRow: 8, RowsPerImage: wgpu.CopyStrideUndefined},
+			&wgpu.Extent3D{Width: 2, Height: 2, DepthOrArrayLayers: 1})
+		if err != nil {
+			return err
+		}
+		encoder.CopyTextureToBuffer(dst, &wgpu.ImageCopyBuffer{
+			Buffer: target,
+			Layout: wgpu.TextureDataLayout{BytesPerRow: wgpu.CopyBytesPerRowAlignment, RowsPerImage: 2},
+		}, &wgpu.Extent3D{Width: 2, Height: 2, DepthOrArrayLayers: 1})
+		return nil
+	})
+
+	// a single row doesn't need BytesPerRow, it is the rest of the data
+	data[8] = 42
+	frame(t, device, belt, func(encoder *wgpu.CommandEncoder) error {
+		row := &wgpu.ImageCopyTexture{Texture: texture, Origin: wgpu.Origin3D{Y: 1}, Aspect: wgpu.TextureAspect_All}
+		err := belt.WriteTexture(encoder, row, data,
+			&wgpu.TextureDataLayout{Offset: 8, RowsPerImage: wgpu.CopyStrideUndefined},
+			&wgpu.Extent3D{Width: 2, Height: 1, DepthOrArrayLayers: 1})
+		if err != nil {
+			return err
+		}
+		encoder.CopyTextureToBuffer(dst, &wgpu.ImageCopyBuffer{
+			Buffer: target,
+			Layout: wgpu.TextureDataLayout{BytesPerRow: wgpu.CopyBytesPerRowAlignment, RowsPerImage: 2},
+		}, &wgpu.Extent3D{Width: 2, Height: 2, DepthOrArrayLayers: 1})
+		return nil
+	})
+
+	got := read(t, device, target, 2*wgpu.CopyBytesPerRowAlignment)
+	for y := 0; y < 2; y++ {
+		row := got[y*wgpu.CopyBytesPerRowAlignment:][:8]
+		if !bytes.Equal(row, data[y*8:][:8]) {
+			t.Errorf("row %d: got %v, want %v", y, row, data[y*8:][:8])
+		}
+	}
+}
+
+func TestWriteTextureBytesPerRow(t *testing.T) {
+	device := newDevice(t)
+	belt := NewBelt(device, 0)
+	defer belt.Drop()
+	encoder, err := device.CreateCommandEncoder(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer encoder.Drop()
+
+	// without BytesPerRow the rows can't be told apart
+	err = belt.WriteTexture(encoder, &wgpu.ImageCopyTexture{}, make([]byte, 16),
+		&wgpu.TextureDataLayout{RowsPerImage: wgpu.CopyStrideUndefined},
+		&wgpu.Extent3D{Width: 2, Height: 2, DepthOrArrayLayers: 1})
+	if err == nil {
+		t.Error("got no error for two rows without BytesPerRow")
+	}
+	if s := belt.Stats(); s.Chunks != 0 || s.Written != 0 {
+		t.Errorf("the rejected write used the belt: %+v", s)
+	}
+}
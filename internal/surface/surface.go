@@ -0,0 +1,198 @@
+// Package surface classifies the errors returned while acquiring swap chain
+// textures and decides how a render loop should recover from them.
+package surface
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	// KindLost means the surface has to be recreated, the runner recreates
+	// the swap chain which is enough for wgpu-native.
+	KindLost
+	// KindOutdated means the surface changed, e.g. it was resized, and the
+	// swap chain must be recreated.
+	KindOutdated
+	// KindTimeout means no texture became available in time, the frame is
+	// skipped.
+	KindTimeout
+	KindOutOfMemory
+	KindDeviceLost
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindLost:
+		return "surface lost"
+	case KindOutdated:
+		return "surface outdated"
+	case KindTimeout:
+		return "surface timeout"
+	case KindOutOfMemory:
+		return "out of memory"
+	case KindDeviceLost:
+		return "device lost"
+	default:
+		return "unknown"
+	}
+}
+
+// Fatal reports whether a render loop can't recover from errors of kind k
+// by recreating the swap chain or skipping a frame.
+func (k Kind) Fatal() bool {
+	switch k {
+	case KindLost, KindOutdated, KindTimeout:
+		return false
+	default:
+		return true
+	}
+}
+
+// Error is returned by Recovery.Handle for errors it gave up on.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return "surface: " + e.Kind.String() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the Kind of an error returned by
+// SwapChain.GetCurrentTextureView or of an *Error wrapping one, a nil
+// error is KindUnknown.
+func Classify(err error) Kind {
+	if err == nil {
+		return KindUnknown
+	}
+
+	var serr *Error
+	if errors.As(err, &serr) {
+		return serr.Kind
+	}
+
+	msg := err.Error()
+
+	var werr *wgpu.Error
+	if errors.As(err, &werr) {
+		switch werr.Type {
+		case wgpu.ErrorType_OutOfMemory:
+			return KindOutOfMemory
+		case wgpu.ErrorType_DeviceLost:
+			return KindDeviceLost
+		}
+		msg = werr.Message
+	}
+
+	// wgpu-native reports the surface status only in the message
+	switch {
+	case strings.Contains(msg, "Outdated"):
+		return KindOutdated
+	case strings.Contains(msg, "Lost"):
+		return KindLost
+	case strings.Contains(msg, "Timeout"):
+		return KindTimeout
+	case strings.Contains(msg, "OutOfMemory"):
+		return KindOutOfMemory
+	}
+	return KindUnknown
+}
+
+type Policy struct {
+	// MaxAttempts is the number of consecutive failed frames tolerated
+	// before Recovery.Handle gives up.
+	MaxAttempts int
+	// InitialBackoff is waited after the second consecutive failure and
+	// doubled after each further one, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultPolicy = Policy{
+	MaxAttempts:    10,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     100 * time.Millisecond,
+}
+
+// Recovery tracks consecutive render failures of one swap chain.
+type Recovery struct {
+	Policy Policy
+	// Recreate reconfigures the swap chain, it is called for lost and
+	// outdated surfaces.
+	Recreate func() error
+	// Sleep waits for the backoff, defaults to time.Sleep.
+	Sleep func(time.Duration)
+
+	attempts int
+}
+
+func NewRecovery(recreate func() error) *Recovery {
+	return &Recovery{
+		Policy:   DefaultPolicy,
+		Recreate: recreate,
+	}
+}
+
+// Handle is called with the result of every frame. It returns nil if the
+// loop can continue, and an *Error if err is fatal or the failures
+// exceeded Policy.MaxAttempts.
+func (r *Recovery) Handle(err error) error {
+	if err == nil {
+		r.attempts = 0
+		return nil
+	}
+
+	kind := Classify(err)
+	if kind.Fatal() {
+		return &Error{Kind: kind, Err: err}
+	}
+
+	r.attempts++
+	if r.attempts > r.Policy.MaxAttempts {
+		return &Error{Kind: kind, Err: err}
+	}
+	if backoff := r.backoff(); backoff > 0 {
+		sleep := r.Sleep
+		if sleep == nil {
+			sleep = time.Sleep
+		}
+		sleep(backoff)
+	}
+
+	if kind == KindLost || kind == KindOutdated {
+		if err := r.Recreate(); err != nil {
+			return &Error{Kind: kind, Err: err}
+		}
+	}
+	return nil
+}
+
+// Attempts returns the number of consecutive failed frames.
+func (r *Recovery) Attempts() int {
+	return r.attempts
+}
+
+func (r *Recovery) backoff() time.Duration {
+	if r.attempts < 2 {
+		return 0
+	}
+	backoff := r.Policy.InitialBackoff
+	for i := 2; i < r.attempts && backoff < r.Policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.Policy.MaxBackoff {
+		backoff = r.Policy.MaxBackoff
+	}
+	return backoff
+}
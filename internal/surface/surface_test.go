@@ -0,0 +1,202 @@
+package surface
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func TestClassify(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{name: "nil", err: nil, want: KindUnknown},
+		{name: "plain unknown", err: errors.New("something else"), want: KindUnknown},
+		{name: "plain outdated", err: errors.New("Surface texture is Outdated"), want: KindOutdated},
+		{name: "plain lost", err: errors.New("Surface texture is Lost"), want: KindLost},
+		{name: "plain timeout", err: errors.New("Surface texture Timeout"), want: KindTimeout},
+		{name: "plain out of memory", err: errors.New("OutOfMemory while acquiring"), want: KindOutOfMemory},
+		{
+			name: "wgpu out of memory",
+			err:  &wgpu.Error{Type: wgpu.ErrorType_OutOfMemory, Message: "Outdated"},
+			want: KindOutOfMemory,
+		},
+		{
+			name: "wgpu device lost",
+			err:  &wgpu.Error{Type: wgpu.ErrorType_DeviceLost, Message: "Lost"},
+			want: KindDeviceLost,
+		},
+		{
+			name: "wgpu validation outdated",
+			err:  &wgpu.Error{Type: wgpu.ErrorType_Validation, Message: "Surface texture is Outdated"},
+			want: KindOutdated,
+		},
+		{
+			name: "wgpu validation lost",
+			err:  &wgpu.Error{Type: wgpu.ErrorType_Validation, Message: "Surface texture is Lost"},
+			want: KindLost,
+		},
+		{
+			name: "wgpu unknown",
+			err:  &wgpu.Error{Type: wgpu.ErrorType_Unknown, Message: "something else"},
+			want: KindUnknown,
+		},
+		{
+			name: "wrapped wgpu error",
+			err:  fmt.Errorf("render: %w", &wgpu.Error{Type: wgpu.ErrorType_DeviceLost}),
+			want: KindDeviceLost,
+		},
+		{
+			name: "wrapped plain error",
+			err:  fmt.Errorf("render: %w", errors.New("Timeout")),
+			want: KindTimeout,
+		},
+		{
+			name: "surface error",
+			err:  &Error{Kind: KindLost, Err: errors.New("Outdated")},
+			want: KindLost,
+		},
+	} {
+		if got := Classify(test.err); got != test.want {
+			t.Errorf("%s: Classify(%v) = %v, want %v", test.name, test.err, got, test.want)
+		}
+	}
+}
+
+// ok stands for a nil result of Recovery.Handle in the tests.
+const ok Kind = -1
+
+var (
+	outdated   = errors.New("Surface texture is Outdated")
+	lost       = errors.New("Surface texture is Lost")
+	timeout    = errors.New("Surface texture Timeout")
+	deviceLost = &wgpu.Error{Type: wgpu.ErrorType_DeviceLost, Message: "device lost"}
+)
+
+func TestRecoveryHandle(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		errs        []error
+		recreateErr error
+		// want holds the Kind of the *Error returned for every frame
+		want      []Kind
+		sleeps    []time.Duration
+		recreates int
+		attempts  int
+	}{
+		{
+			name:      "success resets the attempts",
+			errs:      []error{outdated, outdated, nil, outdated},
+			want:      []Kind{ok, ok, ok, ok},
+			sleeps:    []time.Duration{time.Millisecond},
+			recreates: 3,
+			attempts:  1,
+		},
+		{
+			name:     "timeout skips the frame",
+			errs:     []error{timeout, timeout},
+			want:     []Kind{ok, ok},
+			sleeps:   []time.Duration{time.Millisecond},
+			attempts: 2,
+		},
+		{
+			name:      "gives up after MaxAttempts",
+			errs:      []error{lost, lost, lost, lost},
+			want:      []Kind{ok, ok, ok, KindLost},
+			sleeps:    []time.Duration{time.Millisecond, 2 * time.Millisecond},
+			recreates: 3,
+			attempts:  4,
+		},
+		{
+			name: "device lost is fatal",
+			errs: []error{deviceLost},
+			want: []Kind{KindDeviceLost},
+		},
+		{
+			name: "unknown errors are fatal",
+			errs: []error{errors.New("something else")},
+			want: []Kind{KindUnknown},
+		},
+		{
+			name:     "fatal after recoverable",
+			errs:     []error{outdated, &wgpu.Error{Type: wgpu.ErrorType_OutOfMemory}},
+			want:     []Kind{ok, KindOutOfMemory},
+			attempts: 1,
+			// only for the outdated frame
+			recreates: 1,
+		},
+		{
+			name:        "failed recreate",
+			errs:        []error{outdated},
+			recreateErr: errors.New("configure failed"),
+			want:        []Kind{KindOutdated},
+			recreates:   1,
+			attempts:    1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var sleeps []time.Duration
+			recreates := 0
+			r := NewRecovery(func() error {
+				recreates++
+				return test.recreateErr
+			})
+			r.Policy = Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+			r.Sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+			for i, err := range test.errs {
+				got := r.Handle(err)
+				if test.want[i] == ok {
+					if got != nil {
+						t.Errorf("frame %d: Handle(%v) = %v, want nil", i, err, got)
+					}
+					continue
+				}
+				var serr *Error
+				if !errors.As(got, &serr) || serr.Kind != test.want[i] {
+					t.Errorf("frame %d: Handle(%v) = %v, want a %v error", i, err, got, test.want[i])
+				}
+			}
+			if !slices.Equal(sleeps, test.sleeps) {
+				t.Errorf("slept %v, want %v", sleeps, test.sleeps)
+			}
+			if recreates != test.recreates {
+				t.Errorf("recreated %d times, want %d", recreates, test.recreates)
+			}
+			if r.Attempts() != test.attempts {
+				t.Errorf("%d attempts, want %d", r.Attempts(), test.attempts)
+			}
+		})
+	}
+}
+
+func TestRecoveryBackoff(t *testing.T) {
+	var sleeps []time.Duration
+	r := NewRecovery(func() error { return nil })
+	r.Policy = Policy{MaxAttempts: 8, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	r.Sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	for i := 0; i < 8; i++ {
+		if err := r.Handle(timeout); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// doubled from the second failure on and capped at MaxBackoff
+	want := []time.Duration{1, 2, 4, 5, 5, 5, 5}
+	for i := range want {
+		want[i] *= time.Millisecond
+	}
+	if !slices.Equal(sleeps, want) {
+		t.Errorf("slept %v, want %v", sleeps, want)
+	}
+
+	if err := r.Handle(timeout); Classify(err) != KindTimeout {
+		t.Errorf("failure %d: got %v, want a timeout error", r.Attempts(), err)
+	}
+}
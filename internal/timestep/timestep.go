@@ -0,0 +1,143 @@
+// Package timestep turns the real time between frames into simulation
+// time: a Clock scales, pauses and single-steps it, and an Accumulator
+// splits it into fixed steps with the remainder left for interpolation.
+package timestep
+
+import "time"
+
+// DefaultMaxFrame bounds the time of one frame, so that a stall, e.g. while
+// the window is dragged, doesn't make the simulation jump.
+const DefaultMaxFrame = 250 * time.Millisecond
+
+// DefaultStep is the step of an Accumulator whose Step isn't positive.
+const DefaultStep = time.Second / 60
+
+// Clock converts real frame times into simulation time.
+type Clock struct {
+	// Scale is the simulation speed, 1 is real time and 0.5 half speed.
+	Scale float64
+	// MaxFrame clamps the real time of a frame, 0 disables the clamp.
+	MaxFrame time.Duration
+	// StepTime is the simulation time Step advances a paused clock by.
+	StepTime time.Duration
+
+	paused  bool
+	steps   int
+	last    time.Time
+	elapsed time.Duration
+}
+
+func NewClock() *Clock {
+	return &Clock{
+		Scale:    1,
+		MaxFrame: DefaultMaxFrame,
+		StepTime: DefaultStep,
+	}
+}
+
+// Tick returns the simulation time passed since the previous Tick at now,
+// the first Tick returns 0.
+func (c *Clock) Tick(now time.Time) time.Duration {
+	if c.last.IsZero() {
+		c.last = now
+		return 0
+	}
+	real := now.Sub(c.last)
+	c.last = now
+	return c.Advance(real)
+}
+
+// Advance returns the simulation time for a frame that took real, it is
+// Tick for callers that pick the frame time themselves.
+func (c *Clock) Advance(real time.Duration) time.Duration {
+	if c.paused {
+		if c.steps == 0 {
+			return 0
+		}
+		c.steps--
+		c.elapsed += c.StepTime
+		return c.StepTime
+	}
+
+	if c.MaxFrame > 0 && real > c.MaxFrame {
+		real = c.MaxFrame
+	}
+	dt := time.Duration(float64(real) * c.Scale)
+	c.elapsed += dt
+	return dt
+}
+
+// Elapsed returns the total simulation time.
+func (c *Clock) Elapsed() time.Duration {
+	return c.elapsed
+}
+
+func (c *Clock) Paused() bool {
+	return c.paused
+}
+
+func (c *Clock) SetPaused(paused bool) {
+	c.paused = paused
+	c.steps = 0
+}
+
+func (c *Clock) TogglePause() {
+	c.SetPaused(!c.paused)
+}
+
+// Step makes the next Tick of a paused clock advance by StepTime, it pauses
+// a running clock.
+func (c *Clock) Step() {
+	if !c.paused {
+		c.SetPaused(true)
+		return
+	}
+	c.steps++
+}
+
+// Accumulator runs a simulation in fixed steps however long the frames
+// are. Every frame, Advance returns how many steps to run and Alpha how far
+// the frame is into the next step, for interpolating between the last two
+// simulated states.
+type Accumulator struct {
+	// Step is the simulation time of one step, DefaultStep if it isn't
+	// positive, so that the zero Accumulator is usable.
+	Step time.Duration
+	// MaxSteps bounds the steps of one frame, so that a simulation slower
+	// than real time doesn't fall further behind every frame. The excess
+	// time is dropped. 0 means no limit.
+	MaxSteps int
+
+	acc time.Duration
+}
+
+func NewAccumulator(step time.Duration) *Accumulator {
+	return &Accumulator{
+		Step:     step,
+		MaxSteps: 8,
+	}
+}
+
+// Advance adds dt and returns the number of steps to run.
+func (a *Accumulator) Advance(dt time.Duration) int {
+	step := a.step()
+	a.acc += dt
+	steps := int(a.acc / step)
+	a.acc -= time.Duration(steps) * step
+	if a.MaxSteps > 0 && steps > a.MaxSteps {
+		steps = a.MaxSteps
+	}
+	return steps
+}
+
+// Alpha returns the fraction of a step left over, between 0 and 1.
+func (a *Accumulator) Alpha() float64 {
+	return float64(a.acc) / float64(a.step())
+}
+
+func (a *Accumulator) step() time.Duration {
+	if a.Step <= 0 {
+		return DefaultStep
+	}
+	return a.Step
+}
@@ -0,0 +1,150 @@
+package timestep
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccumulator(t *testing.T) {
+	a := NewAccumulator(10 * time.Millisecond)
+	for _, test := range []struct {
+		dt    time.Duration
+		steps int
+		alpha float64
+	}{
+		{dt: 5 * time.Millisecond, steps: 0, alpha: 0.5},
+		{dt: 5 * time.Millisecond, steps: 1, alpha: 0},
+		{dt: 25 * time.Millisecond, steps: 2, alpha: 0.5},
+		// 20 steps are due, the ones over MaxSteps are dropped
+		{dt: 195 * time.Millisecond, steps: 8, alpha: 0},
+	} {
+		if steps := a.Advance(test.dt); steps != test.steps {
+			t.Errorf("Advance(%v) = %d, want %d", test.dt, steps, test.steps)
+		}
+		if alpha := a.Alpha(); alpha != test.alpha {
+			t.Errorf("Alpha() after Advance(%v) = %v, want %v", test.dt, alpha, test.alpha)
+		}
+	}
+}
+
+func TestAccumulatorZeroStep(t *testing.T) {
+	var a Accumulator
+	if alpha := a.Alpha(); alpha != 0 {
+		t.Errorf("Alpha() = %v, want 0", alpha)
+	}
+	if steps := a.Advance(DefaultStep*3 + DefaultStep/2); steps != 3 {
+		t.Errorf("Advance() = %d, want 3", steps)
+	}
+	if alpha := a.Alpha(); alpha != 0.5 {
+		t.Errorf("Alpha() = %v, want 0.5", alpha)
+	}
+}
+
+func TestClock(t *testing.T) {
+	const ms = time.Millisecond
+	for _, test := range []struct {
+		name string
+		// setup changes the clock before the frames
+		setup func(c *Clock)
+		// frames are the real frame times, before each one the step ops
+		// of the same index are applied
+		frames []time.Duration
+		ops    map[int]func(c *Clock)
+		want   []time.Duration
+	}{{
+		name:   "real time",
+		frames: []time.Duration{16 * ms, 20 * ms},
+		want:   []time.Duration{16 * ms, 20 * ms},
+	}, {
+		name:   "scale",
+		setup:  func(c *Clock) { c.Scale = 0.5 },
+		frames: []time.Duration{16 * ms, 20 * ms},
+		want:   []time.Duration{8 * ms, 10 * ms},
+	}, {
+		name:   "MaxFrame clamps before scaling",
+		setup:  func(c *Clock) { c.Scale = 2 },
+		frames: []time.Duration{time.Second, 100 * ms},
+		want:   []time.Duration{2 * DefaultMaxFrame, 200 * ms},
+	}, {
+		name:   "no clamp",
+		setup:  func(c *Clock) { c.MaxFrame = 0 },
+		frames: []time.Duration{time.Second},
+		want:   []time.Duration{time.Second},
+	}, {
+		name:   "pause",
+		frames: []time.Duration{16 * ms, 16 * ms, 16 * ms, 16 * ms},
+		ops: map[int]func(c *Clock){
+			1: (*Clock).TogglePause,
+			3: (*Clock).TogglePause,
+		},
+		want: []time.Duration{16 * ms, 0, 0, 16 * ms},
+	}, {
+		name:   "single steps while paused",
+		setup:  func(c *Clock) { c.SetPaused(true) },
+		frames: []time.Duration{16 * ms, 16 * ms, 16 * ms, 16 * ms, 16 * ms},
+		ops: map[int]func(c *Clock){
+			1: (*Clock).Step,
+			2: func(c *Clock) { c.Step(); c.Step() },
+		},
+		want: []time.Duration{0, DefaultStep, DefaultStep, DefaultStep, 0},
+	}, {
+		name:   "steps ignore Scale and MaxFrame",
+		setup:  func(c *Clock) { c.SetPaused(true); c.Scale = 0.1; c.StepTime = time.Second },
+		frames: []time.Duration{16 * ms},
+		ops:    map[int]func(c *Clock){0: (*Clock).Step},
+		want:   []time.Duration{time.Second},
+	}, {
+		name:   "Step pauses a running clock",
+		frames: []time.Duration{16 * ms, 16 * ms, 16 * ms},
+		ops: map[int]func(c *Clock){
+			1: (*Clock).Step,
+			2: (*Clock).Step,
+		},
+		want: []time.Duration{16 * ms, 0, DefaultStep},
+	}, {
+		name:   "unpausing drops pending steps",
+		setup:  func(c *Clock) { c.SetPaused(true); c.Step(); c.TogglePause(); c.TogglePause() },
+		frames: []time.Duration{16 * ms},
+		want:   []time.Duration{0},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewClock()
+			if test.setup != nil {
+				test.setup(c)
+			}
+			var elapsed time.Duration
+			for i, real := range test.frames {
+				if op := test.ops[i]; op != nil {
+					op(c)
+				}
+				dt := c.Advance(real)
+				if dt != test.want[i] {
+					t.Errorf("frame %d: Advance(%v) = %v, want %v", i, real, dt, test.want[i])
+				}
+				elapsed += test.want[i]
+			}
+			if c.Elapsed() != elapsed {
+				t.Errorf("Elapsed() = %v, want %v", c.Elapsed(), elapsed)
+			}
+		})
+	}
+}
+
+func TestClockTick(t *testing.T) {
+	c := NewClock()
+	c.Scale = 2
+	start := time.Unix(0, 0)
+	for _, test := range []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{at: 0, want: 0},
+		{at: 10 * time.Millisecond, want: 20 * time.Millisecond},
+		// a stall of a second is clamped to MaxFrame
+		{at: 1010 * time.Millisecond, want: 2 * DefaultMaxFrame},
+	} {
+		if dt := c.Tick(start.Add(test.at)); dt != test.want {
+			t.Errorf("Tick at %v = %v, want %v", test.at, dt, test.want)
+		}
+	}
+}
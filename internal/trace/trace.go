@@ -0,0 +1,219 @@
+// Package trace records spans of the render loop and writes them in the
+// Chrome Trace Event format, which Perfetto (https://ui.perfetto.dev) and
+// chrome://tracing open.
+//
+// A nil *Tracer is valid and records nothing, so tracing can be switched
+// off without changing the calling code.
+//
+// Events are recorded with the id of the goroutine that started them as
+// their thread id, so spans of goroutines running concurrently show up on
+// separate tracks instead of overlapping on one.
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLimit is the default number of events a Tracer keeps, later events
+// are dropped.
+const DefaultLimit = 1 << 20
+
+// Event is a Trace Event, the times are in microseconds since the Tracer
+// was created.
+type Event struct {
+	Name  string         `json:"name"`
+	Phase string         `json:"ph"`
+	Time  float64        `json:"ts"`
+	Dur   float64        `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+type Tracer struct {
+	// Limit is the maximum number of events kept.
+	Limit int
+
+	mu      sync.Mutex
+	start   time.Time
+	events  []Event
+	dropped int
+	// threads are the thread ids of the recorded events
+	threads map[int]bool
+}
+
+func New() *Tracer {
+	return &Tracer{
+		Limit:   DefaultLimit,
+		start:   time.Now(),
+		threads: map[int]bool{},
+	}
+}
+
+// Span is a running span, a nil *Span is valid and records nothing.
+type Span struct {
+	tracer *Tracer
+	name   string
+	tid    int
+	start  time.Time
+	args   map[string]any
+}
+
+// Begin starts a span, it is recorded once End is called.
+func (t *Tracer) Begin(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	return &Span{tracer: t, name: name, tid: goroutineID(), start: time.Now()}
+}
+
+// Arg attaches an argument that is shown with the span.
+func (s *Span) Arg(key string, value any) *Span {
+	if s == nil {
+		return nil
+	}
+	if s.args == nil {
+		s.args = map[string]any{}
+	}
+	s.args[key] = value
+	return s
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	t := s.tracer
+	t.add(Event{
+		Name:  s.name,
+		Phase: "X",
+		Time:  t.micros(s.start),
+		Dur:   float64(time.Since(s.start).Nanoseconds()) / 1e3,
+		TID:   s.tid,
+		Args:  s.args,
+	})
+}
+
+// Instant records a point in time, e.g. a dropped frame.
+func (t *Tracer) Instant(name string) {
+	if t == nil {
+		return
+	}
+	t.add(Event{
+		Name:  name,
+		Phase: "i",
+		Time:  t.micros(time.Now()),
+		TID:   goroutineID(),
+		Scope: "t",
+	})
+}
+
+func (t *Tracer) micros(at time.Time) float64 {
+	return float64(at.Sub(t.start).Nanoseconds()) / 1e3
+}
+
+// goroutineID returns the id of the calling goroutine, parsed from the
+// "goroutine 18 [running]:" header of its stack trace.
+func goroutineID() int {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := bytes.Fields(bytes.TrimPrefix(buf[:n], []byte("goroutine ")))
+	if len(fields) == 0 {
+		return 0
+	}
+	id, _ := strconv.Atoi(string(fields[0]))
+	return id
+}
+
+func (t *Tracer) add(e Event) {
+	e.PID = 1
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.events) >= t.Limit {
+		t.dropped++
+		return
+	}
+	t.events = append(t.events, e)
+	t.threads[e.TID] = true
+}
+
+// Events returns a copy of the recorded events.
+func (t *Tracer) Events() []Event {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Event(nil), t.events...)
+}
+
+// WriteJSON writes the events as a Trace Event JSON object.
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	t.mu.Lock()
+	metadata := []Event{{Name: "process_name", Phase: "M", PID: 1, TID: 1, Args: map[string]any{"name": processName()}}}
+	tids := make([]int, 0, len(t.threads))
+	for tid := range t.threads {
+		tids = append(tids, tid)
+	}
+	sort.Ints(tids)
+	for _, tid := range tids {
+		metadata = append(metadata, Event{Name: "thread_name", Phase: "M", PID: 1, TID: tid, Args: map[string]any{"name": fmt.Sprintf("goroutine %d", tid)}})
+	}
+	trace := struct {
+		TraceEvents     []Event        `json:"traceEvents"`
+		DisplayTimeUnit string         `json:"displayTimeUnit"`
+		OtherData       map[string]any `json:"otherData,omitempty"`
+	}{
+		TraceEvents:     append(metadata, t.events...),
+		DisplayTimeUnit: "ms",
+	}
+	if t.dropped > 0 {
+		trace.OtherData = map[string]any{"droppedEvents": t.dropped}
+	}
+	t.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(trace)
+}
+
+// WriteFile writes the trace to path, it does nothing for a nil Tracer.
+func (t *Tracer) WriteFile(path string) error {
+	if t == nil {
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := t.WriteJSON(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func processName() string {
+	if len(os.Args) == 0 {
+		return "go-webgpu-examples"
+	}
+	return filepath.Base(os.Args[0])
+}
@@ -0,0 +1,155 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// decode writes t as JSON and decodes it again, like a trace viewer would.
+func decode(t *testing.T, tracer *Tracer) (events []Event, otherData map[string]any) {
+	t.Helper()
+	var b bytes.Buffer
+	if err := tracer.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []Event        `json:"traceEvents"`
+		OtherData   map[string]any `json:"otherData"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	return trace.TraceEvents, trace.OtherData
+}
+
+func find(events []Event, name string) (Event, bool) {
+	for _, e := range events {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+func TestSpan(t *testing.T) {
+	tracer := New()
+	span := tracer.Begin("Frame").Arg("frame", 3)
+	time.Sleep(time.Millisecond)
+	span.End()
+
+	events, _ := decode(t, tracer)
+	e, ok := find(events, "Frame")
+	if !ok {
+		t.Fatalf("no Frame event in %v", events)
+	}
+	if e.Phase != "X" {
+		t.Errorf("ph = %q, want X", e.Phase)
+	}
+	if e.Time < 0 {
+		t.Errorf("ts = %v, want the time since New", e.Time)
+	}
+	if e.Dur < 1000 {
+		t.Errorf("dur = %vµs, want at least the 1ms slept", e.Dur)
+	}
+	// JSON numbers decode as float64
+	if got := e.Args["frame"]; got != 3.0 {
+		t.Errorf("args.frame = %v, want 3", got)
+	}
+}
+
+func TestInstant(t *testing.T) {
+	tracer := New()
+	span := tracer.Begin("Frame")
+	tracer.Instant("Surface error")
+	span.End()
+
+	events, _ := decode(t, tracer)
+	instant, ok := find(events, "Surface error")
+	if !ok {
+		t.Fatalf("no instant event in %v", events)
+	}
+	if instant.Phase != "i" || instant.Scope != "t" {
+		t.Errorf("ph = %q, s = %q, want i and t", instant.Phase, instant.Scope)
+	}
+	if instant.Dur != 0 || instant.Args != nil {
+		t.Errorf("instant has dur %v and args %v, want neither", instant.Dur, instant.Args)
+	}
+	frame, _ := find(events, "Frame")
+	if instant.Time < frame.Time || instant.Time > frame.Time+frame.Dur {
+		t.Errorf("instant at %vµs is outside the span [%v, %v]", instant.Time, frame.Time, frame.Time+frame.Dur)
+	}
+	if instant.TID != frame.TID {
+		t.Errorf("instant on thread %d, span on %d, want the same goroutine", instant.TID, frame.TID)
+	}
+}
+
+func TestGoroutineTracks(t *testing.T) {
+	tracer := New()
+	tracer.Begin("main").End()
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			tracer.Begin(name).End()
+		}(name)
+	}
+	wg.Wait()
+
+	events, _ := decode(t, tracer)
+	tids := map[int]string{}
+	for _, name := range []string{"main", "a", "b"} {
+		e, ok := find(events, name)
+		if !ok {
+			t.Fatalf("no %s event in %v", name, events)
+		}
+		if other, ok := tids[e.TID]; ok {
+			t.Errorf("%s and %s share the thread id %d", name, other, e.TID)
+		}
+		tids[e.TID] = name
+	}
+
+	named := map[int]bool{}
+	for _, e := range events {
+		if e.Name == "thread_name" && e.Phase == "M" {
+			named[e.TID] = true
+		}
+	}
+	for tid, name := range tids {
+		if !named[tid] {
+			t.Errorf("thread %d of %s has no thread_name", tid, name)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tracer := New()
+	tracer.Limit = 2
+	for i := 0; i < 5; i++ {
+		tracer.Instant("tick")
+	}
+
+	if n := len(tracer.Events()); n != 2 {
+		t.Errorf("kept %d events, want 2", n)
+	}
+	_, otherData := decode(t, tracer)
+	if got := otherData["droppedEvents"]; got != 3.0 {
+		t.Errorf("droppedEvents = %v, want 3", got)
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	tracer.Begin("Frame").Arg("frame", 1).End()
+	tracer.Instant("Surface error")
+	if events := tracer.Events(); events != nil {
+		t.Errorf("nil tracer recorded %v", events)
+	}
+	if err := tracer.WriteFile("unused"); err != nil {
+		t.Error(err)
+	}
+}
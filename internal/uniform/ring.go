@@ -0,0 +1,150 @@
+package uniform
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// ErrFull is returned by Ring.Alloc when the current frame alone needs more
+// than the ring's size.
+var ErrFull = errors.New("uniform: ring is full")
+
+// Ring sub-allocates per-draw uniforms from one large buffer bound with a
+// dynamic offset, see SetBindGroup. Each frame pushes its values, flushes
+// them before the frame is submitted and fences them with the frame's
+// submission index. Allocations continue where the previous frame stopped
+// and wrap around at the end of the buffer; when they catch up with a frame
+// the GPU may still be reading, Alloc waits for it.
+//
+// The ring only keeps the CPU side, the buffer is passed to Flush so that it
+// can be recreated after a device loss, call Reset then.
+type Ring struct {
+	// Wait blocks until the GPU finished the submission index, Alloc calls
+	// it before reusing the space of a fenced frame. Without it the space is
+	// reused right away, which is still correct since queue writes are
+	// ordered with submissions, but lets the CPU run ahead of the GPU.
+	Wait func(index wgpu.SubmissionIndex)
+
+	data  []byte
+	align uint64
+	head  uint64
+	// used counts the bytes of the in-flight frames and the current one,
+	// they end at head.
+	used    uint64
+	current uint64
+	frames  []fence
+	pending []byteRange
+}
+
+// fence is a submitted frame using size bytes of the ring.
+type fence struct {
+	size  uint64
+	index wgpu.SubmissionIndex
+}
+
+// RingAlignment returns the offset alignment the device requires for
+// dynamic uniform buffer offsets.
+func RingAlignment(device *wgpu.Device) uint64 {
+	return uint64(device.GetLimits().Limits.MinUniformBufferOffsetAlignment)
+}
+
+// NewRing returns a ring of size bytes handing out offsets aligned to align,
+// usually RingAlignment. size must be a multiple of align.
+func NewRing(size, align uint64) (*Ring, error) {
+	if align == 0 || align&(align-1) != 0 {
+		return nil, fmt.Errorf("uniform: ring alignment %d isn't a power of 2", align)
+	}
+	if size == 0 || size%align != 0 {
+		return nil, fmt.Errorf("uniform: ring size %d isn't a positive multiple of %d", size, align)
+	}
+	return &Ring{data: make([]byte, size), align: align}, nil
+}
+
+// Size returns the size of the ring, the buffer passed to Flush must be at
+// least this large.
+func (r *Ring) Size() uint64 {
+	return uint64(len(r.data))
+}
+
+// Align returns the alignment of the offsets returned by Alloc.
+func (r *Ring) Align() uint64 {
+	return r.align
+}
+
+// Alloc copies data into the ring and returns its offset, to be passed to
+// SetBindGroup. The offset stays valid until the frame is fenced and the GPU
+// finished it.
+func (r *Ring) Alloc(data []byte) (uint32, error) {
+	size := (uint64(len(data)) + r.align - 1) / r.align * r.align
+	if size == 0 {
+		size = r.align
+	}
+	if size > r.Size() {
+		return 0, fmt.Errorf("%w: %d bytes don't fit in %d", ErrFull, size, r.Size())
+	}
+	offset, needed := r.head, size
+	if offset+size > r.Size() {
+		// skip the rest of the buffer, the current frame keeps it
+		offset = 0
+		needed += r.Size() - r.head
+	}
+	for r.Size()-r.used < needed {
+		if len(r.frames) == 0 {
+			return 0, fmt.Errorf("%w: the current frame needs more than %d bytes", ErrFull, r.Size())
+		}
+		oldest := r.frames[0]
+		if r.Wait != nil {
+			r.Wait(oldest.index)
+		}
+		r.frames = r.frames[1:]
+		r.used -= oldest.size
+	}
+
+	copy(r.data[offset:], data)
+	r.head = (offset + size) % r.Size()
+	r.used += needed
+	r.current += needed
+
+	if n := len(r.pending); n > 0 && r.pending[n-1].end == int(offset) {
+		r.pending[n-1].end = int(offset + size)
+	} else {
+		r.pending = append(r.pending, byteRange{int(offset), int(offset + size)})
+	}
+	return uint32(offset), nil
+}
+
+// Push allocates v in the ring and returns its offset.
+func Push[T any](r *Ring, v T) (uint32, error) {
+	if err := check[T](); err != nil {
+		return 0, err
+	}
+	return r.Alloc(bytesOf(&v))
+}
+
+// Flush writes the values allocated since the last Flush to buffer. It must
+// be called before the commands using them are submitted.
+func (r *Ring) Flush(queue Queue, buffer *wgpu.Buffer) {
+	for _, p := range r.pending {
+		queue.WriteBuffer(buffer, uint64(p.start), r.data[p.start:p.end])
+	}
+	r.pending = r.pending[:0]
+}
+
+// Fence ends the current frame, its allocations are reused once the GPU
+// finished the submission index.
+func (r *Ring) Fence(index wgpu.SubmissionIndex) {
+	if r.current == 0 {
+		return
+	}
+	r.frames = append(r.frames, fence{size: r.current, index: index})
+	r.current = 0
+}
+
+// Reset forgets all frames, e.g. after the device was lost.
+func (r *Ring) Reset() {
+	r.head, r.used, r.current = 0, 0, 0
+	r.frames = nil
+	r.pending = r.pending[:0]
+}
@@ -0,0 +1,190 @@
+package uniform
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// newRing returns a ring of slots 16 byte slots recording the indices it
+// waits for.
+func newRing(t *testing.T, slots int) (*Ring, *[]wgpu.SubmissionIndex) {
+	t.Helper()
+	r, err := NewRing(uint64(slots)*16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waits := &[]wgpu.SubmissionIndex{}
+	r.Wait = func(index wgpu.SubmissionIndex) {
+		*waits = append(*waits, index)
+	}
+	return r, waits
+}
+
+func alloc(t *testing.T, r *Ring, data ...byte) uint32 {
+	t.Helper()
+	offset, err := r.Alloc(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return offset
+}
+
+func TestRingWrapAround(t *testing.T) {
+	r, waits := newRing(t, 4)
+
+	for i := 0; i < 3; i++ {
+		alloc(t, r, 1)
+	}
+	r.Fence(1)
+
+	// 32 bytes don't fit in the last slot, which is skipped, so the
+	// allocation needs the space of frame 1
+	offset := alloc(t, r, make([]byte, 32)...)
+	if offset != 0 {
+		t.Errorf("offset %d after the wrap around, want 0", offset)
+	}
+	if !slices.Equal(*waits, []wgpu.SubmissionIndex{1}) {
+		t.Errorf("waited for %v, want [1]", *waits)
+	}
+	r.Fence(2)
+
+	if offset := alloc(t, r, 3); offset != 32 {
+		t.Errorf("offset %d, want 32", offset)
+	}
+	// the skipped tail belongs to frame 2 and is reused once it is done
+	if offset := alloc(t, r, 4); offset != 48 {
+		t.Errorf("offset %d, want 48", offset)
+	}
+	if !slices.Equal(*waits, []wgpu.SubmissionIndex{1, 2}) {
+		t.Errorf("waited for %v, want [1 2]", *waits)
+	}
+}
+
+func TestRingWaitsForOldest(t *testing.T) {
+	r, waits := newRing(t, 4)
+
+	for index := wgpu.SubmissionIndex(1); index <= 3; index++ {
+		alloc(t, r, byte(index))
+		r.Fence(index)
+	}
+	// an empty frame isn't fenced
+	r.Fence(4)
+
+	alloc(t, r, 5)
+	if len(*waits) != 0 {
+		t.Errorf("waited for %v while the ring had space", *waits)
+	}
+	alloc(t, r, 6)
+	alloc(t, r, 7)
+	if !slices.Equal(*waits, []wgpu.SubmissionIndex{1, 2}) {
+		t.Errorf("waited for %v, want [1 2]", *waits)
+	}
+}
+
+func TestRingFull(t *testing.T) {
+	r, _ := newRing(t, 4)
+
+	if _, err := r.Alloc(make([]byte, 65)); !errors.Is(err, ErrFull) {
+		t.Errorf("Alloc of more than the ring: got %v, want ErrFull", err)
+	}
+
+	// a frame larger than the ring fails once nothing is left to wait for
+	for i := 0; i < 4; i++ {
+		alloc(t, r, byte(i))
+	}
+	if _, err := r.Alloc([]byte{5}); !errors.Is(err, ErrFull) {
+		t.Errorf("Alloc past the end of one frame: got %v, want ErrFull", err)
+	}
+
+	// the frame can still be fenced and the ring reused afterwards
+	r.Fence(1)
+	if offset := alloc(t, r, 6); offset != 0 {
+		t.Errorf("offset %d after the full frame, want 0", offset)
+	}
+}
+
+func TestRingFlushMergesRanges(t *testing.T) {
+	r, _ := newRing(t, 4)
+
+	var queue fakeQueue
+	alloc(t, r, 1)
+	alloc(t, r, 2)
+	alloc(t, r, 3)
+	r.Flush(&queue, nil)
+	if len(queue.writes) != 1 || queue.writes[0].offset != 0 || len(queue.writes[0].data) != 48 {
+		t.Fatalf("Flush wrote %+v, want one write of 48 bytes at 0", queue.writes)
+	}
+	for i, want := range []byte{1, 2, 3} {
+		if got := queue.writes[0].data[16*i]; got != want {
+			t.Errorf("slot %d holds %d, want %d", i, got, want)
+		}
+	}
+	r.Fence(1)
+
+	// the wrap around splits the writes
+	queue.writes = nil
+	alloc(t, r, 4)
+	alloc(t, r, 5)
+	alloc(t, r, 6)
+	r.Flush(&queue, nil)
+	var offsets []uint64
+	for _, w := range queue.writes {
+		offsets = append(offsets, w.offset)
+	}
+	if !slices.Equal(offsets, []uint64{48, 0}) {
+		t.Errorf("Flush wrote at %v, want [48 0]", offsets)
+	}
+
+	queue.writes = nil
+	r.Flush(&queue, nil)
+	if len(queue.writes) != 0 {
+		t.Errorf("second Flush wrote %+v", queue.writes)
+	}
+}
+
+func TestRingReset(t *testing.T) {
+	r, waits := newRing(t, 4)
+
+	for index := wgpu.SubmissionIndex(1); index <= 4; index++ {
+		alloc(t, r, byte(index))
+		r.Fence(index)
+	}
+	alloc(t, r, 5)
+	r.Reset()
+
+	var queue fakeQueue
+	r.Flush(&queue, nil)
+	if len(queue.writes) != 0 {
+		t.Errorf("Flush after Reset wrote %+v", queue.writes)
+	}
+	for i := 0; i < 4; i++ {
+		if offset := alloc(t, r, 6); offset != uint32(16*i) {
+			t.Errorf("offset %d after Reset, want %d", offset, 16*i)
+		}
+	}
+	if !slices.Equal(*waits, []wgpu.SubmissionIndex{1}) {
+		t.Errorf("waited for %v, want only the wait before Reset", *waits)
+	}
+}
+
+func TestPush(t *testing.T) {
+	r, _ := newRing(t, 4)
+
+	if _, err := Push(r, &[4]float32{}); err == nil {
+		t.Error("Push accepted a pointer")
+	}
+	offset, err := Push(r, [8]float32{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 0 {
+		t.Errorf("offset %d, want 0", offset)
+	}
+	// 32 bytes take two slots
+	if offset := alloc(t, r, 2); offset != 32 {
+		t.Errorf("offset %d after Push, want 32", offset)
+	}
+}
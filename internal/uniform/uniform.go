@@ -0,0 +1,225 @@
+// Package uniform keeps uniform buffers in sync with Go values.
+//
+// A Buffer holds a value of a plain data type, like a struct of float32
+// arrays, and the GPU buffer it is uploaded to. Set only marks the buffer
+// dirty when the value changed, and Upload writes only the byte ranges that
+// changed since the last upload, so calling both every frame costs nothing
+// while the value stays the same.
+//
+// Buffers created by New live in a resource.Registry. After a device loss
+// the registry recreates the GPU buffer with the initial value, and the
+// next Upload notices the new buffer and writes the whole current value.
+package uniform
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Queue is the part of *wgpu.Queue a Buffer uploads through, a fake one
+// can record the writes.
+type Queue interface {
+	WriteBuffer(buffer *wgpu.Buffer, offset uint64, data []byte)
+}
+
+// DefaultMergeGap is the default Buffer.MergeGap.
+const DefaultMergeGap = 16
+
+type Buffer[T any] struct {
+	// MergeGap is the number of unchanged bytes between two changed ranges
+	// below which they are uploaded by a single write.
+	MergeGap int
+
+	// handle is set by New, buffer is the GPU buffer uploaded to last
+	handle   *resource.Handle[*wgpu.Buffer]
+	buffer   *wgpu.Buffer
+	value    T
+	uploaded []byte
+	dirty    bool
+}
+
+// Size returns the size of T, which is also the size of the uploaded data.
+func Size[T any]() uint64 {
+	return uint64(unsafe.Sizeof(*new(T)))
+}
+
+// Align returns the alignment of T.
+func Align[T any]() uint64 {
+	return uint64(unsafe.Alignof(*new(T)))
+}
+
+// New creates a uniform buffer holding value in resources, its size is the
+// size of T rounded up to 16 bytes.
+func New[T any](resources *resource.Registry, label string, value T) (*Buffer[T], error) {
+	return NewWithUsage(resources, label, wgpu.BufferUsage_Uniform, value)
+}
+
+// NewWithUsage is New for a buffer with another usage, e.g. a vertex
+// buffer of instance data that changes every frame. BufferUsage_CopyDst is
+// always added.
+func NewWithUsage[T any](resources *resource.Registry, label string, usage wgpu.BufferUsage, value T) (*Buffer[T], error) {
+	if err := check[T](); err != nil {
+		return nil, err
+	}
+	b := &Buffer[T]{MergeGap: DefaultMergeGap, value: value}
+	contents := make([]byte, (Size[T]()+15)/16*16)
+	copy(contents, b.bytes())
+
+	var err error
+	b.handle, err = resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		Label:    label,
+		Contents: contents,
+		Usage:    usage | wgpu.BufferUsage_CopyDst,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.buffer = b.handle.Get()
+	b.uploaded = append([]byte(nil), b.bytes()...)
+	return b, nil
+}
+
+// Wrap returns a Buffer uploading to buffer, which must be at least Size
+// bytes and have BufferUsage_CopyDst. The buffer's contents are unknown, so
+// the first Upload writes all of value. buffer may be nil when queue is a
+// fake.
+func Wrap[T any](buffer *wgpu.Buffer, value T) (*Buffer[T], error) {
+	if err := check[T](); err != nil {
+		return nil, err
+	}
+	return &Buffer[T]{MergeGap: DefaultMergeGap, buffer: buffer, value: value, dirty: true}, nil
+}
+
+// check returns an error if T can't be uploaded as is: it must not contain
+// pointers, and WriteBuffer needs a multiple of 4 bytes.
+func check[T any]() error {
+	t := reflect.TypeOf(*new(T))
+	if t == nil {
+		return fmt.Errorf("uniform: T must not be an interface type")
+	}
+	if hasPointers(t) {
+		return fmt.Errorf("uniform: %s contains pointers", t)
+	}
+	if t.Size() == 0 || t.Size()%4 != 0 {
+		return fmt.Errorf("uniform: the size %d of %s isn't a positive multiple of 4", t.Size(), t)
+	}
+	return nil
+}
+
+func hasPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return hasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	}
+	return true
+}
+
+// Buffer returns the GPU buffer. For a Buffer created by New it changes
+// after a device loss, so bind groups must call it in their create
+// function.
+func (b *Buffer[T]) Buffer() *wgpu.Buffer {
+	if b.handle != nil {
+		return b.handle.Get()
+	}
+	return b.buffer
+}
+
+// Get returns the current value, which may not be uploaded yet.
+func (b *Buffer[T]) Get() T {
+	return b.value
+}
+
+// Set changes the value, marking the buffer dirty if it differs from the
+// uploaded one.
+func (b *Buffer[T]) Set(value T) {
+	b.value = value
+	if !b.dirty && string(b.bytes()) != string(b.uploaded) {
+		b.dirty = true
+	}
+}
+
+// Dirty reports whether the value changed since the last upload.
+func (b *Buffer[T]) Dirty() bool {
+	return b.dirty
+}
+
+func (b *Buffer[T]) bytes() []byte {
+	return bytesOf(&b.value)
+}
+
+func bytesOf[T any](v *T) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(v)), Size[T]())
+}
+
+// Upload writes the changed ranges of the value to the buffer and returns
+// the number of bytes written.
+func (b *Buffer[T]) Upload(queue Queue) int {
+	if b.handle != nil && b.handle.Get() != b.buffer {
+		// recreated by the registry, it holds the initial value
+		b.buffer = b.handle.Get()
+		b.uploaded = nil
+		b.dirty = true
+	}
+	if !b.dirty {
+		return 0
+	}
+	b.dirty = false
+
+	data := b.bytes()
+	if b.uploaded == nil {
+		queue.WriteBuffer(b.buffer, 0, data)
+		b.uploaded = append([]byte(nil), data...)
+		return len(data)
+	}
+
+	written := 0
+	for _, r := range changedRanges(b.uploaded, data, b.MergeGap) {
+		queue.WriteBuffer(b.buffer, uint64(r.start), data[r.start:r.end])
+		copy(b.uploaded[r.start:r.end], data[r.start:r.end])
+		written += r.end - r.start
+	}
+	return written
+}
+
+type byteRange struct {
+	start, end int
+}
+
+// changedRanges returns the 4 byte aligned ranges where old and new
+// differ, merging ranges less than gap bytes apart.
+func changedRanges(old, new []byte, gap int) []byteRange {
+	var ranges []byteRange
+	for i := 0; i < len(new); i += 4 {
+		if string(old[i:i+4]) == string(new[i:i+4]) {
+			continue
+		}
+		if n := len(ranges); n > 0 && i-ranges[n-1].end < max(gap, 1) {
+			ranges[n-1].end = i + 4
+		} else {
+			ranges = append(ranges, byteRange{i, i + 4})
+		}
+	}
+	return ranges
+}
+
+// Drop drops the GPU buffer if New created it.
+func (b *Buffer[T]) Drop() {
+	b.handle.Drop()
+	b.handle = nil
+	b.buffer = nil
+}
@@ -0,0 +1,226 @@
+package uniform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// write is a WriteBuffer call recorded by fakeQueue.
+type write struct {
+	offset uint64
+	data   []byte
+}
+
+type fakeQueue struct {
+	writes []write
+}
+
+func (q *fakeQueue) WriteBuffer(buffer *wgpu.Buffer, offset uint64, data []byte) {
+	q.writes = append(q.writes, write{offset, append([]byte(nil), data...)})
+}
+
+type camera struct {
+	ViewProj [16]float32
+	Position [4]float32
+}
+
+func TestWrapUploadsEverything(t *testing.T) {
+	value := camera{Position: [4]float32{1, 2, 3, 1}}
+	b, err := Wrap[camera](nil, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Dirty() {
+		t.Error("a wrapped buffer isn't dirty")
+	}
+
+	var queue fakeQueue
+	if n := b.Upload(&queue); n != int(Size[camera]()) {
+		t.Errorf("first Upload wrote %d bytes, want %d", n, Size[camera]())
+	}
+	if len(queue.writes) != 1 || queue.writes[0].offset != 0 || string(queue.writes[0].data) != string(bytesOf(&value)) {
+		t.Errorf("first Upload wrote %+v, want the whole value at offset 0", queue.writes)
+	}
+	if b.Dirty() {
+		t.Error("buffer is dirty after Upload")
+	}
+}
+
+func TestSetEqualStaysClean(t *testing.T) {
+	value := camera{Position: [4]float32{1, 2, 3, 1}}
+	b, err := Wrap[camera](nil, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queue fakeQueue
+	b.Upload(&queue)
+	queue.writes = nil
+
+	b.Set(value)
+	if b.Dirty() {
+		t.Error("Set of an equal value made the buffer dirty")
+	}
+	if n := b.Upload(&queue); n != 0 || len(queue.writes) != 0 {
+		t.Errorf("Upload of a clean buffer wrote %d bytes in %d writes", n, len(queue.writes))
+	}
+}
+
+func TestUploadChangedRanges(t *testing.T) {
+	b, err := Wrap[camera](nil, camera{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queue fakeQueue
+	b.Upload(&queue)
+	queue.writes = nil
+
+	value := b.Get()
+	value.ViewProj[0] = 1
+	value.ViewProj[15] = 1
+	value.Position[3] = 1
+	b.Set(value)
+	if !b.Dirty() {
+		t.Fatal("Set of a different value left the buffer clean")
+	}
+
+	// ViewProj[15] and Position[3] are 12 bytes apart, less than the
+	// default gap
+	if n := b.Upload(&queue); n != 8+16 {
+		t.Errorf("Upload wrote %d bytes, want 24", n)
+	}
+	want := []write{
+		{0, bytesOf(&value)[0:4]},
+		{60, bytesOf(&value)[60:80]},
+	}
+	if len(queue.writes) != len(want) {
+		t.Fatalf("Upload wrote %+v, want %+v", queue.writes, want)
+	}
+	for i, w := range want {
+		if queue.writes[i].offset != w.offset || string(queue.writes[i].data) != string(w.data) {
+			t.Errorf("write #%d is %+v, want %+v", i, queue.writes[i], w)
+		}
+	}
+}
+
+func TestChangedRanges(t *testing.T) {
+	words := func(changed ...int) ([]byte, []byte) {
+		old, new := make([]byte, 40), make([]byte, 40)
+		for _, i := range changed {
+			new[4*i] = 1
+		}
+		return old, new
+	}
+
+	for _, test := range []struct {
+		name    string
+		changed []int
+		gap     int
+		want    []byteRange
+	}{
+		{name: "nothing", changed: nil, gap: 16, want: nil},
+		{name: "one word", changed: []int{2}, gap: 16, want: []byteRange{{8, 12}}},
+		{name: "adjacent", changed: []int{2, 3}, gap: 0, want: []byteRange{{8, 16}}},
+		{name: "within gap", changed: []int{0, 4}, gap: 16, want: []byteRange{{0, 20}}},
+		{name: "gap apart", changed: []int{0, 5}, gap: 16, want: []byteRange{{0, 4}, {20, 24}}},
+		{name: "no merging", changed: []int{0, 2, 9}, gap: 1, want: []byteRange{{0, 4}, {8, 12}, {36, 40}}},
+		{name: "merging all", changed: []int{0, 2, 9}, gap: 40, want: []byteRange{{0, 40}}},
+	} {
+		old, new := words(test.changed...)
+		got := changedRanges(old, new, test.gap)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	type withPointer struct {
+		Values *[4]float32
+	}
+	type withSlice struct {
+		Values []float32
+	}
+	type short struct {
+		A uint16
+	}
+
+	for _, test := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "plain", err: check[camera]()},
+		{name: "array", err: check[[3]uint32]()},
+		{name: "pointer", err: check[withPointer](), want: "contains pointers"},
+		{name: "slice", err: check[withSlice](), want: "contains pointers"},
+		{name: "nested pointer", err: check[[2]withPointer](), want: "contains pointers"},
+		{name: "odd size", err: check[[3]uint16](), want: "isn't a positive multiple of 4"},
+		{name: "short", err: check[short](), want: "isn't a positive multiple of 4"},
+		{name: "empty", err: check[struct{}](), want: "isn't a positive multiple of 4"},
+		{name: "interface", err: check[any](), want: "must not be an interface type"},
+	} {
+		switch {
+		case test.want == "" && test.err != nil:
+			t.Errorf("%s: %v", test.name, test.err)
+		case test.want != "" && (test.err == nil || !strings.Contains(test.err.Error(), test.want)):
+			t.Errorf("%s: got %v, want %q", test.name, test.err, test.want)
+		}
+	}
+
+	if _, err := Wrap[withPointer](nil, withPointer{}); err == nil {
+		t.Error("Wrap accepted a type with pointers")
+	}
+}
+
+func TestNewAfterRebuild(t *testing.T) {
+	instance := wgpu.CreateInstance(nil)
+	defer instance.Drop()
+	adapter, err := instance.RequestAdapter(nil)
+	if err != nil {
+		t.Skip("no adapter:", err)
+	}
+	defer adapter.Drop()
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		t.Skip("no device:", err)
+	}
+	defer device.Drop()
+
+	resources := resource.NewRegistry(device)
+	defer resources.Drop()
+	b, err := New(resources, "Camera", camera{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Drop()
+
+	var queue fakeQueue
+	b.Set(camera{Position: [4]float32{1}})
+	b.Upload(&queue)
+	queue.writes = nil
+
+	old := b.Buffer()
+	if err := resources.Rebuild(device); err != nil {
+		t.Fatal(err)
+	}
+	if b.Buffer() == old {
+		t.Fatal("Buffer() returns the old buffer after Rebuild")
+	}
+	// the new buffer holds the initial value, so everything is written
+	if n := b.Upload(&queue); n != int(Size[camera]()) {
+		t.Errorf("Upload after Rebuild wrote %d bytes, want %d", n, Size[camera]())
+	}
+	if n := b.Upload(&queue); n != 0 {
+		t.Errorf("second Upload after Rebuild wrote %d bytes, want 0", n)
+	}
+}
@@ -0,0 +1,137 @@
+package wgpulog
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBurst    = 5
+	DefaultInterval = 10 * time.Second
+)
+
+// Suppressed reports how many times a message was dropped by a Limiter.
+type Suppressed struct {
+	Level   slog.Level
+	Message string
+	Count   int
+}
+
+type counter struct {
+	level      slog.Level
+	message    string
+	seen       int
+	suppressed int
+}
+
+// Limiter lets through Burst copies of the same message per Interval.
+// Messages that only differ in numbers, e.g. resource ids, count as the
+// same message. A nil *Limiter allows everything.
+type Limiter struct {
+	Burst    int
+	Interval time.Duration
+
+	mu       sync.Mutex
+	start    time.Time
+	counters map[string]*counter
+	order    []*counter
+}
+
+func NewLimiter(burst int, interval time.Duration) *Limiter {
+	return &Limiter{
+		Burst:    burst,
+		Interval: interval,
+		counters: map[string]*counter{},
+	}
+}
+
+// Allow records msg and reports whether it should be logged.
+func (l *Limiter) Allow(level slog.Level, msg string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.start.IsZero() {
+		l.start = now
+	}
+
+	key := normalize(msg)
+	c, ok := l.counters[key]
+	if !ok {
+		c = &counter{level: level, message: msg}
+		l.counters[key] = c
+		l.order = append(l.order, c)
+	}
+	c.seen++
+	if c.seen > l.Burst {
+		c.suppressed++
+		return false
+	}
+	return true
+}
+
+// Expired starts a new interval if the current one is over at now and
+// returns the messages suppressed in it.
+func (l *Limiter) Expired(now time.Time) []Suppressed {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.start.IsZero() || now.Sub(l.start) < l.Interval {
+		return nil
+	}
+	return l.reset()
+}
+
+// Drain starts a new interval and returns the messages suppressed in the
+// current one.
+func (l *Limiter) Drain() []Suppressed {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reset()
+}
+
+func (l *Limiter) reset() []Suppressed {
+	var suppressed []Suppressed
+	for _, c := range l.order {
+		if c.suppressed > 0 {
+			suppressed = append(suppressed, Suppressed{
+				Level:   c.level,
+				Message: c.message,
+				Count:   c.suppressed,
+			})
+		}
+	}
+
+	l.start = time.Time{}
+	l.counters = map[string]*counter{}
+	l.order = nil
+	return suppressed
+}
+
+// normalize replaces every run of digits with a single '#'.
+func normalize(msg string) string {
+	b := make([]byte, 0, len(msg))
+	digits := false
+	for i := 0; i < len(msg); i++ {
+		if '0' <= msg[i] && msg[i] <= '9' {
+			if !digits {
+				b = append(b, '#')
+			}
+			digits = true
+			continue
+		}
+		digits = false
+		b = append(b, msg[i])
+	}
+	return string(b)
+}
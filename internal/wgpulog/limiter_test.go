@@ -0,0 +1,102 @@
+package wgpulog
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, test := range []struct {
+		msg, want string
+	}{
+		{"", ""},
+		{"no digits", "no digits"},
+		{"Buffer (0, 1, Vulkan) is invalid", "Buffer (#, #, Vulkan) is invalid"},
+		{"id 123456 and 7", "id # and #"},
+		{"42", "#"},
+	} {
+		if got := normalize(test.msg); got != test.want {
+			t.Errorf("normalize(%q) = %q, want %q", test.msg, got, test.want)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	start := time.Unix(0, 0)
+	for _, test := range []struct {
+		name string
+		msgs []string
+		// at is the offset of each message from start
+		at   []time.Duration
+		want []bool
+	}{{
+		name: "burst",
+		msgs: []string{"a", "a", "a", "a", "a", "a", "a"},
+		want: []bool{true, true, true, true, true, false, false},
+	}, {
+		name: "messages differing in numbers are merged",
+		msgs: []string{"id 1", "id 2", "id 3", "id 40", "id 500", "id 6000"},
+		want: []bool{true, true, true, true, true, false},
+	}, {
+		name: "different messages are counted separately",
+		msgs: []string{"a", "a", "a", "a", "a", "b", "a"},
+		want: []bool{true, true, true, true, true, true, false},
+	}, {
+		name: "the count restarts after the interval",
+		msgs: []string{"a", "a", "a", "a", "a", "a", "a"},
+		at:   []time.Duration{0, 1, 2, 3, 4, 5, 10 * time.Second},
+		want: []bool{true, true, true, true, true, false, true},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			l := NewLimiter(DefaultBurst, DefaultInterval)
+			var got []bool
+			for i, msg := range test.msgs {
+				now := start
+				if test.at != nil {
+					now = now.Add(test.at[i])
+				}
+				l.Expired(now)
+				got = append(got, l.Allow(slog.LevelWarn, msg, now))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLimiterSuppressed(t *testing.T) {
+	start := time.Unix(0, 0)
+	l := NewLimiter(1, DefaultInterval)
+	for _, msg := range []string{"id 1", "id 2", "id 3", "other", "other"} {
+		l.Allow(slog.LevelError, msg, start)
+	}
+
+	if s := l.Expired(start.Add(DefaultInterval - 1)); s != nil {
+		t.Errorf("Expired before the interval is over returned %v", s)
+	}
+	want := []Suppressed{
+		{Level: slog.LevelError, Message: "id 1", Count: 2},
+		{Level: slog.LevelError, Message: "other", Count: 1},
+	}
+	if got := l.Expired(start.Add(DefaultInterval)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expired = %v, want %v", got, want)
+	}
+	if got := l.Drain(); got != nil {
+		t.Errorf("Drain after Expired = %v, want nothing", got)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	for i := 0; i < 10; i++ {
+		if !l.Allow(slog.LevelWarn, "a", time.Unix(0, 0)) {
+			t.Fatal("a nil Limiter suppressed a message")
+		}
+	}
+	if s := l.Drain(); s != nil {
+		t.Errorf("Drain = %v, want nothing", s)
+	}
+}
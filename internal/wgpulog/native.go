@@ -0,0 +1,28 @@
+package wgpulog
+
+/*
+// wgpuSetLogCallback is provided by wgpu-native, which the wgpu package
+// links. The declarations are repeated here instead of including wgpu.h
+// to keep the signatures in line with the exported Go callback.
+typedef void (*wgpulog_callback)(int level, char *message, void *userdata);
+extern void wgpuSetLogCallback(wgpulog_callback callback, void *userdata);
+extern void wgpulogCallback(int level, char *message, void *userdata);
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+//export wgpulogCallback
+func wgpulogCallback(level C.int, message *C.char, _ unsafe.Pointer) {
+	logMessage(wgpu.LogLevel(level), C.GoString(message))
+}
+
+// setLogCallback replaces the callback the wgpu package sets in its init,
+// which has run by now as this package imports it.
+func setLogCallback() {
+	C.wgpuSetLogCallback(C.wgpulog_callback(C.wgpulogCallback), nil)
+}
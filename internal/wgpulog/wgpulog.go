@@ -0,0 +1,168 @@
+// Package wgpulog routes the log messages of wgpu-native into log/slog.
+//
+// wgpu only hands over a level and the formatted message, so the component a
+// message came from (naga, wgpu-core or wgpu-hal) is guessed from its text
+// and attached as the "source" attribute. Repeated messages, typically the
+// same validation error once per frame, are rate limited.
+package wgpulog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// LevelTrace is the slog level of wgpu's trace messages, which have no slog
+// counterpart.
+const LevelTrace = slog.LevelDebug - 4
+
+// Level maps a wgpu log level to a slog level.
+func Level(level wgpu.LogLevel) slog.Level {
+	switch level {
+	case wgpu.LogLevel_Error:
+		return slog.LevelError
+	case wgpu.LogLevel_Warn:
+		return slog.LevelWarn
+	case wgpu.LogLevel_Info:
+		return slog.LevelInfo
+	case wgpu.LogLevel_Debug:
+		return slog.LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+const (
+	SourceNaga = "naga"
+	SourceCore = "wgpu-core"
+	SourceHal  = "wgpu-hal"
+)
+
+// sources are checked in order, the first rule with a matching substring
+// wins and anything unmatched is attributed to wgpu-core. The hal rules
+// include the messages of the EGL backend that don't name it, like "No
+// config found!", and the message types of the Vulkan debug messenger.
+var sources = []struct {
+	source     string
+	substrings []string
+}{
+	{SourceNaga, []string{"naga", "Naga", "WGSL", "wgsl", "GLSL", "SPIR-V", "SPV", "Shader", "shader", "Entry point"}},
+	{SourceHal, []string{"EGL", "GLES", "OpenGL", "GL_", "No config found", "native-render", "Vulkan", "VK_", "Vk", "VALIDATION [", "GENERAL [", "PERFORMANCE [", "Metal", "MTL", "D3D", "DX12", "DX11", "DXGI", "Dx12", "\tCONFORMANT", "Display", "windowing system", "Wayland", "X11"}},
+}
+
+// resourceID matches the ids wgpu-core prints for resources, e.g.
+// "(0, 1, Vulkan)", which name the backend without coming from it.
+var resourceID = regexp.MustCompile(`\(\d+, \d+, \w+\)`)
+
+// Source guesses which part of wgpu logged msg.
+func Source(msg string) string {
+	msg = resourceID.ReplaceAllString(msg, "")
+	for _, s := range sources {
+		for _, sub := range s.substrings {
+			if strings.Contains(msg, sub) {
+				return s.source
+			}
+		}
+	}
+	return SourceCore
+}
+
+// NewHandler returns a text or, if json is set, JSON handler writing to w
+// that prints LevelTrace as "TRACE".
+func NewHandler(w io.Writer, json bool, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 {
+				if l, ok := a.Value.Any().(slog.Level); ok && l <= LevelTrace {
+					a.Value = slog.StringValue("TRACE")
+				}
+			}
+			return a
+		},
+	}
+	if json {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Bridge turns wgpu log messages into slog records.
+type Bridge struct {
+	Logger  *slog.Logger
+	Limiter *Limiter
+}
+
+// NewBridge returns a bridge logging to logger in a group "wgpu", allowing
+// DefaultBurst repeats of a message per DefaultInterval.
+func NewBridge(logger *slog.Logger) *Bridge {
+	return &Bridge{
+		Logger:  logger.WithGroup("wgpu"),
+		Limiter: NewLimiter(DefaultBurst, DefaultInterval),
+	}
+}
+
+// Log logs one wgpu message.
+func (b *Bridge) Log(level wgpu.LogLevel, msg string) {
+	l := Level(level)
+	ctx := context.Background()
+	if !b.Logger.Enabled(ctx, l) {
+		return
+	}
+
+	msg = strings.TrimRight(msg, "\n")
+	now := time.Now()
+	for _, s := range b.Limiter.Expired(now) {
+		b.logSuppressed(s)
+	}
+	if b.Limiter.Allow(l, msg, now) {
+		b.Logger.LogAttrs(ctx, l, msg, slog.String("source", Source(msg)))
+	}
+}
+
+// Flush logs how often messages were suppressed since the last report.
+func (b *Bridge) Flush() {
+	for _, s := range b.Limiter.Drain() {
+		b.logSuppressed(s)
+	}
+}
+
+func (b *Bridge) logSuppressed(s Suppressed) {
+	b.Logger.LogAttrs(context.Background(), s.Level, "suppressed repeated message",
+		slog.String("source", Source(s.Message)),
+		slog.String("message", s.Message),
+		slog.Int("count", s.Count),
+	)
+}
+
+var (
+	bridge  atomic.Pointer[Bridge]
+	install sync.Once
+)
+
+// Install makes b receive all wgpu log messages in place of wgpu's own
+// callback, which prints to stderr. Once installed, a nil b discards them.
+func Install(b *Bridge) {
+	bridge.Store(b)
+	install.Do(setLogCallback)
+}
+
+// Flush flushes the installed bridge, if any.
+func Flush() {
+	if b := bridge.Load(); b != nil {
+		b.Flush()
+	}
+}
+
+func logMessage(level wgpu.LogLevel, msg string) {
+	if b := bridge.Load(); b != nil {
+		b.Log(level, msg)
+	}
+}
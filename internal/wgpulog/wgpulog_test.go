@@ -0,0 +1,24 @@
+package wgpulog
+
+import "testing"
+
+func TestSource(t *testing.T) {
+	for _, test := range []struct {
+		msg, want string
+	}{
+		{"Shader validation error: \n  ┌─ Shader:3:5", SourceNaga},
+		{"Entry point main at Vertex is invalid", SourceNaga},
+		{"No config found!", SourceHal},
+		{"EGL says: no display", SourceHal},
+		{"Trying native-render", SourceHal},
+		{"VALIDATION [VUID-vkCmdDraw-None-02699 (0x1)]\n\tinvalid descriptor", SourceHal},
+		{"Display vendor \"Mesa Project\", version (1, 4)", SourceHal},
+		{"Buffer (0, 1, Vulkan) is invalid", SourceCore},
+		{"Device::create_buffer error", SourceCore},
+		{"Created buffer Valid((1, 1, Gl)) with BufferDescriptor", SourceCore},
+	} {
+		if got := Source(test.msg); got != test.want {
+			t.Errorf("Source(%q) = %s, want %s", test.msg, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package wgsl
+
+import (
+	"slices"
+)
+
+// Function bodies are parsed to report syntax errors and the functions they
+// call, what the statements mean is left to the shader compiler.
+
+// operators are the operators spanning several punctuation tokens, longest
+// first.
+var operators = []string{
+	"<<=", ">>=",
+	"&&", "||", "<<", ">>", "<=", ">=", "==", "!=", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+}
+
+// binaryOps are the binary operators by precedence, lowest first.
+var binaryOps = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<", ">", "<=", ">="},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+var assignOps = []string{"=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>="}
+
+// peekOp returns the operator at the current token and the number of tokens
+// it spans. The lexer splits punctuation into single characters, adjacent
+// ones are joined here.
+func (p *parser) peekOp() (string, int) {
+	t := p.peek()
+	if t.kind != tokenPunct {
+		return "", 0
+	}
+	if len(t.text) > 1 {
+		return t.text, 1
+	}
+	text := t.text
+	for i := p.pos + 1; i < p.pos+3 && i < len(p.toks); i++ {
+		next := p.toks[i]
+		if next.kind != tokenPunct || len(next.text) != 1 || next.offset != p.toks[i-1].offset+1 {
+			break
+		}
+		text += next.text
+	}
+	for n := len(text); n > 1; n-- {
+		if slices.Contains(operators, text[:n]) {
+			return text[:n], n
+		}
+	}
+	return t.text, 1
+}
+
+func (p *parser) semicolon() error {
+	_, err := p.expect(";")
+	return err
+}
+
+// block parses a compound statement.
+func (p *parser) block() error {
+	open, err := p.expect("{")
+	if err != nil {
+		return err
+	}
+	for p.peek().text != "}" {
+		if p.peek().kind == tokenEOF {
+			return p.errorf(open, "unterminated block")
+		}
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) statement() error {
+	t := p.peek()
+	if t.kind == tokenPunct {
+		switch t.text {
+		case ";":
+			p.next()
+			return nil
+		case "{":
+			return p.block()
+		}
+	}
+	switch t.text {
+	case "return":
+		p.next()
+		if p.peek().text != ";" {
+			if err := p.expr(); err != nil {
+				return err
+			}
+		}
+	case "if":
+		return p.ifStatement()
+	case "switch":
+		return p.switchStatement()
+	case "loop":
+		return p.loopStatement()
+	case "for":
+		return p.forStatement()
+	case "while":
+		p.next()
+		if err := p.expr(); err != nil {
+			return err
+		}
+		return p.block()
+	case "break":
+		p.next()
+		// break if, only allowed at the end of continuing
+		if p.peek().text == "if" {
+			p.next()
+			if err := p.expr(); err != nil {
+				return err
+			}
+		}
+	case "continue", "discard":
+		p.next()
+	case "var", "let", "const":
+		if err := p.declaration(); err != nil {
+			return err
+		}
+	case "const_assert":
+		p.next()
+		if err := p.expr(); err != nil {
+			return err
+		}
+	default:
+		if err := p.simpleStatement(); err != nil {
+			return err
+		}
+	}
+	return p.semicolon()
+}
+
+func (p *parser) ifStatement() error {
+	p.next()
+	if err := p.expr(); err != nil {
+		return err
+	}
+	if err := p.block(); err != nil {
+		return err
+	}
+	if p.peek().text != "else" {
+		return nil
+	}
+	p.next()
+	if p.peek().text == "if" {
+		return p.ifStatement()
+	}
+	return p.block()
+}
+
+func (p *parser) switchStatement() error {
+	p.next()
+	if err := p.expr(); err != nil {
+		return err
+	}
+	open, err := p.expect("{")
+	if err != nil {
+		return err
+	}
+	for p.peek().text != "}" {
+		t := p.next()
+		switch t.text {
+		case "case":
+			for {
+				if p.peek().text == "default" {
+					p.next()
+				} else if err := p.expr(); err != nil {
+					return err
+				}
+				if p.peek().text != "," {
+					break
+				}
+				p.next()
+				if p.peek().text == ":" || p.peek().text == "{" {
+					break
+				}
+			}
+		case "default":
+		default:
+			if t.kind == tokenEOF {
+				return p.errorf(open, "unterminated switch")
+			}
+			return p.errorf(t, "expected \"case\" or \"default\", found %q", t.text)
+		}
+		if p.peek().text == ":" {
+			p.next()
+		}
+		if err := p.block(); err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) loopStatement() error {
+	p.next()
+	open, err := p.expect("{")
+	if err != nil {
+		return err
+	}
+	for p.peek().text != "}" {
+		switch {
+		case p.peek().kind == tokenEOF:
+			return p.errorf(open, "unterminated loop")
+		case p.peek().text == "continuing":
+			p.next()
+			if err := p.block(); err != nil {
+				return err
+			}
+			if p.peek().text != "}" {
+				return p.errorf(p.peek(), "continuing must be the last statement of a loop")
+			}
+		default:
+			if err := p.statement(); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) forStatement() error {
+	p.next()
+	if _, err := p.expect("("); err != nil {
+		return err
+	}
+	if p.peek().text != ";" {
+		var err error
+		switch p.peek().text {
+		case "var", "let", "const":
+			err = p.declaration()
+		default:
+			err = p.simpleStatement()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := p.semicolon(); err != nil {
+		return err
+	}
+	if p.peek().text != ";" {
+		if err := p.expr(); err != nil {
+			return err
+		}
+	}
+	if err := p.semicolon(); err != nil {
+		return err
+	}
+	if p.peek().text != ")" {
+		if err := p.simpleStatement(); err != nil {
+			return err
+		}
+	}
+	if _, err := p.expect(")"); err != nil {
+		return err
+	}
+	return p.block()
+}
+
+// declaration parses a var, let or const declaration without the
+// semicolon.
+func (p *parser) declaration() error {
+	keyword := p.next()
+	if keyword.text == "var" && p.peek().text == "<" {
+		p.next()
+		if _, err := p.ident(); err != nil {
+			return err
+		}
+		if p.peek().text == "," {
+			p.next()
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+		}
+		if _, err := p.expect(">"); err != nil {
+			return err
+		}
+	}
+	if _, err := p.ident(); err != nil {
+		return err
+	}
+	if p.peek().text == ":" {
+		p.next()
+		if _, err := p.typ(); err != nil {
+			return err
+		}
+	}
+	if keyword.text == "var" && p.peek().text != "=" {
+		return nil
+	}
+	if _, err := p.expect("="); err != nil {
+		return err
+	}
+	return p.expr()
+}
+
+// simpleStatement parses an assignment, an increment, a decrement or a
+// function call without the semicolon.
+func (p *parser) simpleStatement() error {
+	start := p.peek()
+	if err := p.unary(); err != nil {
+		return err
+	}
+	op, n := p.peekOp()
+	switch {
+	case slices.Contains(assignOps, op):
+		p.pos += n
+		return p.expr()
+	case op == "++" || op == "--":
+		p.pos += n
+		return nil
+	case p.callEnd == p.pos:
+		return nil
+	}
+	return p.errorf(start, "expected an assignment or a function call")
+}
+
+func (p *parser) expr() error {
+	return p.binary(0)
+}
+
+func (p *parser) binary(level int) error {
+	if level == len(binaryOps) {
+		return p.unary()
+	}
+	if err := p.binary(level + 1); err != nil {
+		return err
+	}
+	for {
+		op, n := p.peekOp()
+		if !slices.Contains(binaryOps[level], op) {
+			return nil
+		}
+		p.pos += n
+		if err := p.binary(level + 1); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) unary() error {
+	switch p.peek().text {
+	case "-", "!", "~", "*", "&":
+		p.next()
+		return p.unary()
+	}
+	if err := p.primary(); err != nil {
+		return err
+	}
+	for {
+		switch p.peek().text {
+		case "[":
+			p.next()
+			if err := p.expr(); err != nil {
+				return err
+			}
+			if _, err := p.expect("]"); err != nil {
+				return err
+			}
+		case ".":
+			p.next()
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *parser) primary() error {
+	t := p.peek()
+	switch {
+	case t.kind == tokenNumber:
+		p.next()
+		return nil
+	case t.text == "(":
+		p.next()
+		if err := p.expr(); err != nil {
+			return err
+		}
+		_, err := p.expect(")")
+		return err
+	case t.kind == tokenIdent:
+		p.next()
+		if p.peek().text == "<" {
+			p.templateCall()
+		}
+		if p.peek().text == "(" {
+			p.calls = append(p.calls, t)
+			return p.args()
+		}
+		return nil
+	case t.kind == tokenEOF:
+		return p.errorf(t, "expected an expression, found end of file")
+	}
+	return p.errorf(t, "expected an expression, found %q", t.text)
+}
+
+// templateCall skips the template list of a call like vec3<f32>(...) or
+// bitcast<u32>(...). A "<" not followed by a template list and "(" is a
+// less than, then nothing is skipped.
+func (p *parser) templateCall() {
+	start := p.pos
+	p.next()
+	for p.peek().text != ">" {
+		if _, err := p.templateArg(); err != nil {
+			p.pos = start
+			return
+		}
+		if p.peek().text == "," {
+			p.next()
+		}
+	}
+	p.next()
+	if p.peek().text != "(" {
+		p.pos = start
+	}
+}
+
+func (p *parser) args() error {
+	p.next()
+	for p.peek().text != ")" {
+		if err := p.expr(); err != nil {
+			return err
+		}
+		if p.peek().text != ")" {
+			if _, err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+	p.callEnd = p.pos
+	return nil
+}
+
+// builtins are the builtin functions of WGSL.
+var builtins = map[string]bool{}
+
+func init() {
+	for _, name := range []string{
+		"bitcast", "all", "any", "select", "arrayLength",
+		"abs", "acos", "acosh", "asin", "asinh", "atan", "atanh", "atan2",
+		"ceil", "clamp", "cos", "cosh", "countLeadingZeros", "countOneBits",
+		"countTrailingZeros", "cross", "degrees", "determinant", "distance",
+		"dot", "dot4U8Packed", "dot4I8Packed", "exp", "exp2", "extractBits",
+		"faceForward", "firstLeadingBit", "firstTrailingBit", "floor", "fma",
+		"fract", "frexp", "insertBits", "inverseSqrt", "ldexp", "length",
+		"log", "log2", "max", "min", "mix", "modf", "normalize", "pow",
+		"quantizeToF16", "radians", "reflect", "refract", "reverseBits",
+		"round", "saturate", "sign", "sin", "sinh", "smoothstep", "sqrt",
+		"step", "tan", "tanh", "transpose", "trunc",
+		"dpdx", "dpdxCoarse", "dpdxFine", "dpdy", "dpdyCoarse", "dpdyFine",
+		"fwidth", "fwidthCoarse", "fwidthFine",
+		"textureDimensions", "textureGather", "textureGatherCompare",
+		"textureLoad", "textureNumLayers", "textureNumLevels",
+		"textureNumSamples", "textureSample", "textureSampleBias",
+		"textureSampleCompare", "textureSampleCompareLevel",
+		"textureSampleGrad", "textureSampleLevel",
+		"textureSampleBaseClampToEdge", "textureStore",
+		"atomicLoad", "atomicStore", "atomicAdd", "atomicSub", "atomicMax",
+		"atomicMin", "atomicAnd", "atomicOr", "atomicXor", "atomicExchange",
+		"atomicCompareExchangeWeak",
+		"pack4x8snorm", "pack4x8unorm", "pack2x16snorm", "pack2x16unorm",
+		"pack2x16float", "unpack4x8snorm", "unpack4x8unorm",
+		"unpack2x16snorm", "unpack2x16unorm", "unpack2x16float",
+		"storageBarrier", "workgroupBarrier", "textureBarrier",
+		"workgroupUniformLoad",
+		// type constructors
+		"bool", "i32", "u32", "f32", "f16", "vec2", "vec3", "vec4", "array",
+		"mat2x2", "mat2x3", "mat2x4", "mat3x2", "mat3x3", "mat3x4",
+		"mat4x2", "mat4x3", "mat4x4",
+	} {
+		builtins[name] = true
+	}
+}
+
+// undefinedCalls returns an error for every call of a name that is no
+// builtin, struct, alias or function of the module.
+func (p *parser) undefinedCalls() []error {
+	var errs []error
+	for _, t := range p.calls {
+		_, isFunc := p.funcs[t.text]
+		_, isStruct := p.m.Structs[t.text]
+		_, isAlias := p.aliases[t.text]
+		// the predeclared aliases, like vec3f
+		isPredeclared := len(p.resolve(Type{Name: t.text}).Args) > 0
+		if !builtins[t.text] && !isFunc && !isStruct && !isAlias && !isPredeclared {
+			errs = append(errs, p.errorf(t, "call of undefined function %s", t.text))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,382 @@
+package wgsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// LayoutEntry returns the bind group layout entry matching the binding.
+// Textures of f32 are assumed to be filterable, change the SampleType to
+// TextureSampleType_UnfilterableFloat for the ones that aren't. Buffers
+// have no minimum binding size, which wgpu-native spells wgpu.WholeSize.
+func (b *Binding) LayoutEntry() (wgpu.BindGroupLayoutEntry, error) {
+	entry := wgpu.BindGroupLayoutEntry{
+		Binding:    b.Binding,
+		Visibility: b.Visibility,
+	}
+
+	switch b.AddressSpace {
+	case "uniform":
+		entry.Buffer.Type = wgpu.BufferBindingType_Uniform
+		entry.Buffer.MinBindingSize = wgpu.WholeSize
+		return entry, nil
+	case "storage":
+		switch b.Access {
+		case "", "read":
+			entry.Buffer.Type = wgpu.BufferBindingType_ReadOnlyStorage
+		case "read_write":
+			entry.Buffer.Type = wgpu.BufferBindingType_Storage
+		default:
+			return entry, fmt.Errorf("%s: invalid access mode %q", b.Name, b.Access)
+		}
+		entry.Buffer.MinBindingSize = wgpu.WholeSize
+		return entry, nil
+	case "":
+	default:
+		return entry, fmt.Errorf("%s: address space %q can't be bound", b.Name, b.AddressSpace)
+	}
+
+	name := b.Type.Name
+	switch {
+	case name == "sampler":
+		entry.Sampler.Type = wgpu.SamplerBindingType_Filtering
+	case name == "sampler_comparison":
+		entry.Sampler.Type = wgpu.SamplerBindingType_Comparison
+	case strings.HasPrefix(name, "texture_storage_"):
+		dim, ok := viewDimensions[strings.TrimPrefix(name, "texture_storage_")]
+		if !ok || len(b.Type.Args) != 2 {
+			return entry, fmt.Errorf("%s: unsupported type %s", b.Name, b.Type)
+		}
+		format, ok := storageFormats[b.Type.Args[0].Name]
+		if !ok {
+			return entry, fmt.Errorf("%s: unsupported storage texture format %s", b.Name, b.Type.Args[0])
+		}
+		if b.Type.Args[1].Name != "write" {
+			return entry, fmt.Errorf("%s: only write storage textures are supported", b.Name)
+		}
+		entry.StorageTexture = wgpu.StorageTextureBindingLayout{
+			Access:        wgpu.StorageTextureAccess_WriteOnly,
+			Format:        format,
+			ViewDimension: dim,
+		}
+	case strings.HasPrefix(name, "texture_"):
+		t := strings.TrimPrefix(name, "texture_")
+		depth := strings.HasPrefix(t, "depth_")
+		t = strings.TrimPrefix(t, "depth_")
+		multisampled := strings.HasPrefix(t, "multisampled_")
+		t = strings.TrimPrefix(t, "multisampled_")
+
+		dim, ok := viewDimensions[t]
+		if !ok {
+			return entry, fmt.Errorf("%s: unsupported type %s", b.Name, b.Type)
+		}
+		entry.Texture = wgpu.TextureBindingLayout{
+			ViewDimension: dim,
+			Multisampled:  multisampled,
+		}
+		switch {
+		case depth:
+			entry.Texture.SampleType = wgpu.TextureSampleType_Depth
+		case len(b.Type.Args) != 1:
+			return entry, fmt.Errorf("%s: %s needs a sampled type", b.Name, b.Type)
+		default:
+			switch b.Type.Args[0].Name {
+			case "f32":
+				entry.Texture.SampleType = wgpu.TextureSampleType_Float
+			case "i32":
+				entry.Texture.SampleType = wgpu.TextureSampleType_Sint
+			case "u32":
+				entry.Texture.SampleType = wgpu.TextureSampleType_Uint
+			default:
+				return entry, fmt.Errorf("%s: invalid sampled type %s", b.Name, b.Type.Args[0])
+			}
+		}
+	default:
+		return entry, fmt.Errorf("%s: %s can't be bound without an address space", b.Name, b.Type)
+	}
+	return entry, nil
+}
+
+var viewDimensions = map[string]wgpu.TextureViewDimension{
+	"1d":         wgpu.TextureViewDimension_1D,
+	"2d":         wgpu.TextureViewDimension_2D,
+	"2d_array":   wgpu.TextureViewDimension_2DArray,
+	"3d":         wgpu.TextureViewDimension_3D,
+	"cube":       wgpu.TextureViewDimension_Cube,
+	"cube_array": wgpu.TextureViewDimension_CubeArray,
+}
+
+var storageFormats = map[string]wgpu.TextureFormat{
+	"rgba8unorm":  wgpu.TextureFormat_RGBA8Unorm,
+	"rgba8snorm":  wgpu.TextureFormat_RGBA8Snorm,
+	"rgba8uint":   wgpu.TextureFormat_RGBA8Uint,
+	"rgba8sint":   wgpu.TextureFormat_RGBA8Sint,
+	"rgba16uint":  wgpu.TextureFormat_RGBA16Uint,
+	"rgba16sint":  wgpu.TextureFormat_RGBA16Sint,
+	"rgba16float": wgpu.TextureFormat_RGBA16Float,
+	"r32uint":     wgpu.TextureFormat_R32Uint,
+	"r32sint":     wgpu.TextureFormat_R32Sint,
+	"r32float":    wgpu.TextureFormat_R32Float,
+	"rg32uint":    wgpu.TextureFormat_RG32Uint,
+	"rg32sint":    wgpu.TextureFormat_RG32Sint,
+	"rg32float":   wgpu.TextureFormat_RG32Float,
+	"rgba32uint":  wgpu.TextureFormat_RGBA32Uint,
+	"rgba32sint":  wgpu.TextureFormat_RGBA32Sint,
+	"rgba32float": wgpu.TextureFormat_RGBA32Float,
+	"bgra8unorm":  wgpu.TextureFormat_BGRA8Unorm,
+}
+
+// BindGroupLayoutEntries returns the layout entries of the bindings of
+// group.
+func (m *Module) BindGroupLayoutEntries(group uint32) ([]wgpu.BindGroupLayoutEntry, error) {
+	bindings := m.Group(group)
+	if len(bindings) == 0 {
+		return nil, fmt.Errorf("no bindings in @group(%d)", group)
+	}
+	entries := make([]wgpu.BindGroupLayoutEntry, len(bindings))
+	for i, b := range bindings {
+		var err error
+		if entries[i], err = b.LayoutEntry(); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// ValidateBindGroupLayout checks that entries can be used for the bindings
+// of group: every binding needs an entry of the same kind, visible to the
+// stages using it. Entries the shader doesn't use are allowed.
+func (m *Module) ValidateBindGroupLayout(group uint32, entries []wgpu.BindGroupLayoutEntry) error {
+	var errs []error
+	for _, b := range m.Group(group) {
+		want, err := b.LayoutEntry()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var got *wgpu.BindGroupLayoutEntry
+		for i := range entries {
+			if entries[i].Binding == b.Binding {
+				got = &entries[i]
+				break
+			}
+		}
+		if got == nil {
+			errs = append(errs, fmt.Errorf("%s: no layout entry for @group(%d) @binding(%d)", b.Name, group, b.Binding))
+			continue
+		}
+		if err := compatible(want, *got); err != nil {
+			errs = append(errs, fmt.Errorf("%s: @group(%d) @binding(%d): %w", b.Name, group, b.Binding, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func compatible(want, got wgpu.BindGroupLayoutEntry) error {
+	if got.Visibility&want.Visibility != want.Visibility {
+		return fmt.Errorf("used by %s but only visible to %s", stages(want.Visibility), stages(got.Visibility))
+	}
+	switch {
+	case want.Buffer.Type != wgpu.BufferBindingType_Undefined:
+		if got.Buffer.Type != want.Buffer.Type {
+			return fmt.Errorf("shader needs a %s buffer, layout has %s", want.Buffer.Type, kind(got))
+		}
+	case want.Sampler.Type != wgpu.SamplerBindingType_Undefined:
+		ok := got.Sampler.Type == want.Sampler.Type ||
+			want.Sampler.Type == wgpu.SamplerBindingType_Filtering && got.Sampler.Type == wgpu.SamplerBindingType_NonFiltering
+		if !ok {
+			return fmt.Errorf("shader needs a %s sampler, layout has %s", want.Sampler.Type, kind(got))
+		}
+	case want.StorageTexture.Access != wgpu.StorageTextureAccess_Undefined:
+		if got.StorageTexture != want.StorageTexture {
+			return fmt.Errorf("shader needs a %s %s storage texture, layout has %s",
+				want.StorageTexture.ViewDimension, want.StorageTexture.Format, kind(got))
+		}
+	default:
+		if got.Texture.SampleType == wgpu.TextureSampleType_Undefined {
+			return fmt.Errorf("shader needs a texture, layout has %s", kind(got))
+		}
+		if got.Texture.ViewDimension != want.Texture.ViewDimension || got.Texture.Multisampled != want.Texture.Multisampled {
+			return fmt.Errorf("shader needs a %s texture (multisampled %t), layout has %s (multisampled %t)",
+				want.Texture.ViewDimension, want.Texture.Multisampled, got.Texture.ViewDimension, got.Texture.Multisampled)
+		}
+		ok := got.Texture.SampleType == want.Texture.SampleType ||
+			want.Texture.SampleType == wgpu.TextureSampleType_Float &&
+				(got.Texture.SampleType == wgpu.TextureSampleType_UnfilterableFloat || got.Texture.SampleType == wgpu.TextureSampleType_Depth)
+		if !ok {
+			return fmt.Errorf("shader samples %s, layout has %s", want.Texture.SampleType, got.Texture.SampleType)
+		}
+	}
+	return nil
+}
+
+func stages(s wgpu.ShaderStage) string {
+	var names []string
+	for _, stage := range []wgpu.ShaderStage{wgpu.ShaderStage_Vertex, wgpu.ShaderStage_Fragment, wgpu.ShaderStage_Compute} {
+		if s&stage != 0 {
+			names = append(names, stage.String())
+		}
+	}
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Join(names, "|")
+}
+
+func kind(e wgpu.BindGroupLayoutEntry) string {
+	switch {
+	case e.Buffer.Type != wgpu.BufferBindingType_Undefined:
+		return "a " + e.Buffer.Type.String() + " buffer"
+	case e.Sampler.Type != wgpu.SamplerBindingType_Undefined:
+		return "a " + e.Sampler.Type.String() + " sampler"
+	case e.StorageTexture.Access != wgpu.StorageTextureAccess_Undefined:
+		return "a storage texture"
+	case e.Texture.SampleType != wgpu.TextureSampleType_Undefined:
+		return "a texture"
+	}
+	return "nothing"
+}
+
+// VertexFormat returns the vertex format matching the WGSL type t exactly,
+// e.g. Float32x3 for vec3<f32>.
+func VertexFormat(t Type) (wgpu.VertexFormat, error) {
+	scalar, n := t.Name, 1
+	if len(t.Name) == 4 && strings.HasPrefix(t.Name, "vec") && len(t.Args) == 1 {
+		scalar, n = t.Args[0].Name, int(t.Name[3]-'0')
+	}
+	for format, info := range vertexFormats {
+		if info.scalar == scalar && info.components == n && info.size == 4*n ||
+			scalar == "f16" && info.scalar == "f16" && info.components == n {
+			return format, nil
+		}
+	}
+	return wgpu.VertexFormat_Undefined, fmt.Errorf("%s can't be a vertex input", t)
+}
+
+type vertexFormat struct {
+	// scalar is the WGSL type the format is read as.
+	scalar     string
+	components int
+	size       int
+}
+
+var vertexFormats = map[wgpu.VertexFormat]vertexFormat{
+	wgpu.VertexFormat_Uint8x2:   {"u32", 2, 2},
+	wgpu.VertexFormat_Uint8x4:   {"u32", 4, 4},
+	wgpu.VertexFormat_Sint8x2:   {"i32", 2, 2},
+	wgpu.VertexFormat_Sint8x4:   {"i32", 4, 4},
+	wgpu.VertexFormat_Unorm8x2:  {"f32", 2, 2},
+	wgpu.VertexFormat_Unorm8x4:  {"f32", 4, 4},
+	wgpu.VertexFormat_Snorm8x2:  {"f32", 2, 2},
+	wgpu.VertexFormat_Snorm8x4:  {"f32", 4, 4},
+	wgpu.VertexFormat_Uint16x2:  {"u32", 2, 4},
+	wgpu.VertexFormat_Uint16x4:  {"u32", 4, 8},
+	wgpu.VertexFormat_Sint16x2:  {"i32", 2, 4},
+	wgpu.VertexFormat_Sint16x4:  {"i32", 4, 8},
+	wgpu.VertexFormat_Unorm16x2: {"f32", 2, 4},
+	wgpu.VertexFormat_Unorm16x4: {"f32", 4, 8},
+	wgpu.VertexFormat_Snorm16x2: {"f32", 2, 4},
+	wgpu.VertexFormat_Snorm16x4: {"f32", 4, 8},
+	wgpu.VertexFormat_Float16x2: {"f16", 2, 4},
+	wgpu.VertexFormat_Float16x4: {"f16", 4, 8},
+	wgpu.VertexFormat_Float32:   {"f32", 1, 4},
+	wgpu.VertexFormat_Float32x2: {"f32", 2, 8},
+	wgpu.VertexFormat_Float32x3: {"f32", 3, 12},
+	wgpu.VertexFormat_Float32x4: {"f32", 4, 16},
+	wgpu.VertexFormat_Uint32:    {"u32", 1, 4},
+	wgpu.VertexFormat_Uint32x2:  {"u32", 2, 8},
+	wgpu.VertexFormat_Uint32x3:  {"u32", 3, 12},
+	wgpu.VertexFormat_Uint32x4:  {"u32", 4, 16},
+	wgpu.VertexFormat_Sint32:    {"i32", 1, 4},
+	wgpu.VertexFormat_Sint32x2:  {"i32", 2, 8},
+	wgpu.VertexFormat_Sint32x3:  {"i32", 3, 12},
+	wgpu.VertexFormat_Sint32x4:  {"i32", 4, 16},
+}
+
+// VertexBufferLayout returns a tightly packed layout for the inputs of the
+// entry point name at locations, in the order given, using the format
+// matching each input type.
+func (m *Module) VertexBufferLayout(name string, stepMode wgpu.VertexStepMode, locations ...uint32) (wgpu.VertexBufferLayout, error) {
+	layout := wgpu.VertexBufferLayout{StepMode: stepMode}
+	inputs, err := m.VertexInputs(name)
+	if err != nil {
+		return layout, err
+	}
+	for _, location := range locations {
+		var input *Member
+		for i := range inputs {
+			if inputs[i].Location == int(location) {
+				input = &inputs[i]
+			}
+		}
+		if input == nil {
+			return layout, fmt.Errorf("%s has no @location(%d) input", name, location)
+		}
+		format, err := VertexFormat(input.Type)
+		if err != nil {
+			return layout, fmt.Errorf("%s: %w", input.Name, err)
+		}
+		layout.Attributes = append(layout.Attributes, wgpu.VertexAttribute{
+			Format:         format,
+			Offset:         layout.ArrayStride,
+			ShaderLocation: location,
+		})
+		layout.ArrayStride += uint64(vertexFormats[format].size)
+	}
+	return layout, nil
+}
+
+// ValidateVertexBuffers checks that buffers provide every @location input
+// of the entry point name exactly once, in a format read as the input's
+// scalar type, and that the attributes fit in their buffer's stride.
+func (m *Module) ValidateVertexBuffers(name string, buffers []wgpu.VertexBufferLayout) error {
+	inputs, err := m.VertexInputs(name)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	provided := map[uint32]int{}
+	for i, buffer := range buffers {
+		for _, attr := range buffer.Attributes {
+			if j, ok := provided[attr.ShaderLocation]; ok {
+				errs = append(errs, fmt.Errorf("@location(%d) is in both buffer %d and %d", attr.ShaderLocation, j, i))
+			}
+			provided[attr.ShaderLocation] = i
+
+			info, ok := vertexFormats[attr.Format]
+			if !ok {
+				errs = append(errs, fmt.Errorf("@location(%d): invalid format %s", attr.ShaderLocation, attr.Format))
+				continue
+			}
+			if buffer.ArrayStride != 0 && attr.Offset+uint64(info.size) > buffer.ArrayStride {
+				errs = append(errs, fmt.Errorf("@location(%d): %s at offset %d doesn't fit in stride %d",
+					attr.ShaderLocation, attr.Format, attr.Offset, buffer.ArrayStride))
+			}
+			for _, input := range inputs {
+				if input.Location != int(attr.ShaderLocation) {
+					continue
+				}
+				scalar := input.Type.Name
+				if len(input.Type.Args) == 1 {
+					scalar = input.Type.Args[0].Name
+				}
+				if scalar != info.scalar {
+					errs = append(errs, fmt.Errorf("%s: @location(%d) is %s but the buffer provides %s",
+						input.Name, input.Location, input.Type, attr.Format))
+				}
+			}
+		}
+	}
+	for _, input := range inputs {
+		if _, ok := provided[uint32(input.Location)]; !ok {
+			errs = append(errs, fmt.Errorf("%s: no vertex buffer provides @location(%d)", input.Name, input.Location))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %w", name, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Package wgsl holds tooling for the WGSL shaders of the examples.
+package wgsl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Preprocessor expands the directives of a WGSL file:
+//
+//	#include "file.wgsl"   insert file, relative to the including file
+//	#define NAME [value]   define NAME, replacing it in the code with value
+//	#undef NAME
+//	#ifdef NAME, #ifndef NAME, #else, #endif
+//
+// Directives must be the first thing on their line. A file is included only
+// once, so files with shared declarations can be included from several
+// places.
+type Preprocessor struct {
+	FS fs.FS
+	// Defines are the names defined before the first line, e.g. to pick a
+	// shader variant.
+	Defines map[string]string
+}
+
+// Location is a line in one of the preprocessed files, 1-based.
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return l.File + ":" + strconv.Itoa(l.Line)
+}
+
+// Source is the result of preprocessing a file.
+type Source struct {
+	Code string
+	// Lines holds the origin of every line of Code.
+	Lines []Location
+	// offsets holds the byte offset every line of Code starts at.
+	offsets []int
+}
+
+// Error is a preprocessing error.
+type Error struct {
+	Location Location
+	Msg      string
+}
+
+func (e *Error) Error() string {
+	return e.Location.String() + ": " + e.Msg
+}
+
+type state struct {
+	p        *Preprocessor
+	defines  map[string]string
+	included map[string]bool
+	out      strings.Builder
+	src      *Source
+}
+
+type cond struct {
+	active    bool
+	parent    bool
+	seenElse  bool
+	directive Location
+}
+
+// Process preprocesses the file name.
+func (p *Preprocessor) Process(name string) (*Source, error) {
+	s := &state{
+		p:        p,
+		defines:  map[string]string{},
+		included: map[string]bool{},
+		src:      &Source{},
+	}
+	for k, v := range p.Defines {
+		s.defines[k] = v
+	}
+	if err := s.file(path.Clean(name), Location{}); err != nil {
+		return nil, err
+	}
+	s.src.Code = s.out.String()
+	return s.src, nil
+}
+
+// Process preprocesses name from fsys with defines.
+func Process(fsys fs.FS, name string, defines map[string]string) (*Source, error) {
+	p := &Preprocessor{FS: fsys, Defines: defines}
+	return p.Process(name)
+}
+
+func (s *state) file(name string, from Location) error {
+	if s.included[name] {
+		return nil
+	}
+	s.included[name] = true
+
+	data, err := fs.ReadFile(s.p.FS, name)
+	if err != nil {
+		if from.File == "" {
+			return err
+		}
+		return &Error{Location: from, Msg: err.Error()}
+	}
+
+	var conds []cond
+	active := true
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		loc := Location{File: name, Line: line}
+
+		directive, arg, ok := parseDirective(text)
+		if !ok {
+			if active {
+				s.emit(s.expand(text), loc)
+			}
+			continue
+		}
+
+		switch directive {
+		case "ifdef", "ifndef":
+			_, defined := s.defines[arg]
+			conds = append(conds, cond{
+				active:    active && defined == (directive == "ifdef"),
+				parent:    active,
+				directive: loc,
+			})
+		case "else":
+			if len(conds) == 0 {
+				return &Error{Location: loc, Msg: "#else without #ifdef"}
+			}
+			c := &conds[len(conds)-1]
+			if c.seenElse {
+				return &Error{Location: loc, Msg: "duplicate #else"}
+			}
+			c.seenElse = true
+			c.active = c.parent && !c.active
+		case "endif":
+			if len(conds) == 0 {
+				return &Error{Location: loc, Msg: "#endif without #ifdef"}
+			}
+			conds = conds[:len(conds)-1]
+		default:
+			if !active {
+				break
+			}
+			if err := s.directive(directive, arg, loc); err != nil {
+				return err
+			}
+		}
+
+		active = true
+		if len(conds) > 0 {
+			active = conds[len(conds)-1].active
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(conds) > 0 {
+		return &Error{Location: conds[len(conds)-1].directive, Msg: "#ifdef without #endif"}
+	}
+	return nil
+}
+
+func (s *state) directive(directive, arg string, loc Location) error {
+	switch directive {
+	case "include":
+		name, err := strconv.Unquote(arg)
+		if err != nil {
+			return &Error{Location: loc, Msg: "#include needs a quoted file name"}
+		}
+		return s.file(path.Join(path.Dir(loc.File), name), loc)
+	case "define":
+		name, value := cutSpace(arg)
+		if !isIdent(name) {
+			return &Error{Location: loc, Msg: fmt.Sprintf("invalid name %q", name)}
+		}
+		s.defines[name] = strings.TrimSpace(value)
+	case "undef":
+		delete(s.defines, arg)
+	default:
+		return &Error{Location: loc, Msg: "unknown directive #" + directive}
+	}
+	return nil
+}
+
+func (s *state) emit(text string, loc Location) {
+	s.src.offsets = append(s.src.offsets, s.out.Len())
+	s.src.Lines = append(s.src.Lines, loc)
+	s.out.WriteString(text)
+	s.out.WriteByte('\n')
+}
+
+var ident = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// expand replaces the defined names that have a value.
+func (s *state) expand(text string) string {
+	if len(s.defines) == 0 {
+		return text
+	}
+	return ident.ReplaceAllStringFunc(text, func(name string) string {
+		if value, ok := s.defines[name]; ok && value != "" {
+			return value
+		}
+		return name
+	})
+}
+
+func parseDirective(text string) (directive, arg string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "#") {
+		return "", "", false
+	}
+	directive, arg = cutSpace(text[1:])
+	return directive, arg, true
+}
+
+// cutSpace splits text at its first run of whitespace, e.g. a tab between
+// a directive and its argument.
+func cutSpace(text string) (before, after string) {
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return text, ""
+	}
+	return text[:i], strings.TrimSpace(text[i:])
+}
+
+func isIdent(name string) bool {
+	return ident.FindString(name) == name && name != ""
+}
+
+// Line returns the origin of line, 1-based, of Code.
+func (s *Source) Line(line int) Location {
+	if line < 1 || line > len(s.Lines) {
+		return Location{}
+	}
+	return s.Lines[line-1]
+}
+
+// Offset returns the origin of the byte offset into Code.
+func (s *Source) Offset(offset int) Location {
+	i := sort.Search(len(s.offsets), func(i int) bool { return s.offsets[i] > offset })
+	return s.Line(i)
+}
+
+// CompileError is a shader compilation error mapped back to the original
+// file.
+type CompileError struct {
+	Location Location
+	Msg      string
+	Err      error
+}
+
+func (e *CompileError) Error() string {
+	return e.Location.String() + ": " + e.Msg
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	spanPattern    = regexp.MustCompile(`Span \{ start: (\d+), end: \d+ \}`)
+	messagePattern = regexp.MustCompile(`message: "((?:[^"\\]|\\.)*)"`)
+)
+
+// MapError turns a wgpu shader compilation error or a ParseError for Code
+// into a CompileError pointing at the original file. Errors that don't refer to a
+// location in Code are returned unchanged.
+func (s *Source) MapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return &CompileError{Location: s.Offset(parseErr.Offset), Msg: parseErr.Msg, Err: err}
+	}
+	m := spanPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	offset, _ := strconv.Atoi(m[1])
+
+	msg := err.Error()
+	if m := messagePattern.FindStringSubmatch(msg); m != nil {
+		if unquoted, err := strconv.Unquote(`"` + m[1] + `"`); err == nil {
+			msg = unquoted
+		}
+	}
+	return &CompileError{Location: s.Offset(offset), Msg: msg, Err: err}
+}
@@ -0,0 +1,182 @@
+package wgsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProcessIncludeOnce(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.wgsl":       {Data: []byte("#include \"common.wgsl\"\n#include \"lib/light.wgsl\"\nfn main() {}\n")},
+		"common.wgsl":     {Data: []byte("const PI = 3.14;\n")},
+		"lib/light.wgsl":  {Data: []byte("#include \"../common.wgsl\"\n#include \"shadow.wgsl\"\nfn light() {}\n")},
+		"lib/shadow.wgsl": {Data: []byte("#include\t\"../common.wgsl\"\nfn shadow() {}\n")},
+	}
+	src, err := Process(fsys, "main.wgsl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "const PI = 3.14;\nfn shadow() {}\nfn light() {}\nfn main() {}\n"
+	if src.Code != want {
+		t.Errorf("got\n%s\nwant\n%s", src.Code, want)
+	}
+}
+
+func TestProcessConditionals(t *testing.T) {
+	const code = `#ifdef A
+a
+#ifndef B
+a-not-b
+#else
+a-b
+#endif
+#else
+not-a
+#ifdef B
+not-a-b
+#endif
+#endif
+`
+	for _, test := range []struct {
+		defines map[string]string
+		want    string
+	}{
+		{defines: nil, want: "not-a\n"},
+		{defines: map[string]string{"A": ""}, want: "a\na-not-b\n"},
+		{defines: map[string]string{"A": "", "B": ""}, want: "a\na-b\n"},
+		{defines: map[string]string{"B": ""}, want: "not-a\nnot-a-b\n"},
+	} {
+		src, err := Process(fstest.MapFS{"main.wgsl": {Data: []byte(code)}}, "main.wgsl", test.defines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src.Code != test.want {
+			t.Errorf("defines %v: got %q, want %q", test.defines, src.Code, test.want)
+		}
+	}
+}
+
+func TestProcessDefine(t *testing.T) {
+	const code = "#define\tSIZE  64\n" +
+		"#define FLAG\n" +
+		"var<workgroup> data: array<f32, SIZE>;\n" +
+		"#undef SIZE\n" +
+		"#ifdef SIZE\n" +
+		"size is still defined\n" +
+		"#endif\n" +
+		"  #ifdef\tFLAG\n" +
+		"const SIZE = 1;\n" +
+		"#endif\n"
+	src, err := Process(fstest.MapFS{"main.wgsl": {Data: []byte(code)}}, "main.wgsl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "var<workgroup> data: array<f32, 64>;\nconst SIZE = 1;\n"
+	if src.Code != want {
+		t.Errorf("got %q, want %q", src.Code, want)
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		want string
+	}{
+		{code: "#else\n", want: "main.wgsl:1: #else without #ifdef"},
+		{code: "#endif\n", want: "main.wgsl:1: #endif without #ifdef"},
+		{code: "#ifdef A\n#else\n#else\n#endif\n", want: "main.wgsl:3: duplicate #else"},
+		{code: "\n#ifdef A\n", want: "main.wgsl:2: #ifdef without #endif"},
+		{code: "#pragma once\n", want: "main.wgsl:1: unknown directive #pragma"},
+		{code: "#define 1A\n", want: `main.wgsl:1: invalid name "1A"`},
+		{code: "#include common.wgsl\n", want: "main.wgsl:1: #include needs a quoted file name"},
+		{code: "\n\n#include \"missing.wgsl\"\n", want: "main.wgsl:3: open missing.wgsl"},
+	} {
+		_, err := Process(fstest.MapFS{"main.wgsl": {Data: []byte(test.code)}}, "main.wgsl", nil)
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("%q: got error %v, want %s", test.code, err, test.want)
+		}
+	}
+}
+
+func TestSourceLines(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.wgsl":   {Data: []byte("// main\n#include \"common.wgsl\"\n\nfn main() {}\n")},
+		"common.wgsl": {Data: []byte("#ifdef NOPE\nskipped\n#endif\nconst A = 1;\n")},
+	}
+	src, err := Process(fsys, "main.wgsl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Location{
+		{"main.wgsl", 1},
+		{"common.wgsl", 4},
+		{"main.wgsl", 3},
+		{"main.wgsl", 4},
+	}
+	for i, w := range want {
+		if got := src.Line(i + 1); got != w {
+			t.Errorf("Line(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := src.Line(len(want) + 1); got != (Location{}) {
+		t.Errorf("Line past the end = %v, want the zero Location", got)
+	}
+
+	// the offsets of the first and last byte of every line map to it
+	lines := strings.SplitAfter(src.Code, "\n")
+	offset := 0
+	for i, w := range want {
+		for _, o := range []int{offset, offset + len(lines[i]) - 1} {
+			if got := src.Offset(o); got != w {
+				t.Errorf("Offset(%d) = %v, want %v", o, got, w)
+			}
+		}
+		offset += len(lines[i])
+	}
+}
+
+func TestMapError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.wgsl":   {Data: []byte("#include \"common.wgsl\"\nfn main() {\n    let x: foo = 1;\n}\n")},
+		"common.wgsl": {Data: []byte("const A = 1;\n")},
+	}
+	src, err := Process(fsys, "main.wgsl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(src.Code, "foo")
+	wgpuErr := fmt.Errorf(`Validation Error
+
+Caused by:
+    In Device::create_shader_module
+    Parsing error: ParseError { message: "unknown type: \"foo\"", labels: [(Span { start: %d, end: %d }, "unknown type")] }`, start, start+3)
+
+	err = src.MapError(wgpuErr)
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("MapError returned %T %v, want a *CompileError", err, err)
+	}
+	if want := (Location{"main.wgsl", 3}); compileErr.Location != want {
+		t.Errorf("location %v, want %v", compileErr.Location, want)
+	}
+	if want := `unknown type: "foo"`; compileErr.Msg != want {
+		t.Errorf("message %q, want %q", compileErr.Msg, want)
+	}
+	if !errors.Is(err, wgpuErr) {
+		t.Error("the CompileError doesn't wrap the wgpu error")
+	}
+
+	other := errors.New("device lost")
+	if err := src.MapError(other); err != other {
+		t.Errorf("MapError(%v) = %v, want it unchanged", other, err)
+	}
+	if err := src.MapError(nil); err != nil {
+		t.Errorf("MapError(nil) = %v", err)
+	}
+}
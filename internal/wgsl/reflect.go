@@ -0,0 +1,812 @@
+package wgsl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+// Module is what Reflect found in a shader: its structs, resource bindings
+// and entry points. Function bodies are parsed for syntax errors and
+// scanned for the names they use, which is enough to tell which entry
+// points use which bindings.
+type Module struct {
+	Structs     map[string]*Struct
+	Bindings    []*Binding
+	EntryPoints []*EntryPoint
+
+	undefined []error
+}
+
+// Type is a WGSL type like f32, vec3<f32> or texture_storage_2d<rgba8unorm,
+// write>. Template arguments that aren't types, like array lengths or
+// access modes, have only a Name.
+type Type struct {
+	Name string
+	Args []Type
+}
+
+func (t Type) String() string {
+	if len(t.Args) == 0 {
+		return t.Name
+	}
+	args := make([]string, len(t.Args))
+	for i, arg := range t.Args {
+		args[i] = arg.String()
+	}
+	return t.Name + "<" + strings.Join(args, ", ") + ">"
+}
+
+type Struct struct {
+	Name    string
+	Members []Member
+	Offset  int
+}
+
+// Member is a struct member. Location is -1 for members without
+// @location, Align and Size are 0 without @align and @size.
+type Member struct {
+	Name     string
+	Type     Type
+	Location int
+	Builtin  string
+	Align    int
+	Size     int
+}
+
+// Binding is a module scope var with @group and @binding.
+type Binding struct {
+	Group   uint32
+	Binding uint32
+	Name    string
+	// AddressSpace and Access are the var template arguments, e.g. storage
+	// and read_write, empty for textures and samplers.
+	AddressSpace string
+	Access       string
+	Type         Type
+	// Visibility holds the stages of the entry points using the binding.
+	Visibility wgpu.ShaderStage
+	Offset     int
+}
+
+// EntryPoint is a function with @vertex, @fragment or @compute.
+type EntryPoint struct {
+	Name  string
+	Stage wgpu.ShaderStage
+	// Inputs are the parameters, with struct parameters flattened into
+	// their members.
+	Inputs []Member
+	// Outputs are the result, flattened like Inputs, with an empty Name
+	// for a result that isn't a struct.
+	Outputs []Member
+	// WorkgroupSize is the @workgroup_size of compute entry points, as
+	// written.
+	WorkgroupSize []string
+	Offset        int
+}
+
+// ParseError is a syntax error at a byte offset into the code.
+type ParseError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+// Reflect parses the module scope declarations of code.
+func Reflect(code string) (*Module, error) {
+	toks, err := lex(code)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		toks:      toks,
+		aliases:   map[string]Type{},
+		resolving: map[string]bool{},
+		funcs:     map[string][]string{},
+		m:         &Module{Structs: map[string]*Struct{}},
+	}
+	if err := p.module(); err != nil {
+		return nil, err
+	}
+	p.resolveTypes()
+	p.visibility()
+	p.m.undefined = p.undefinedCalls()
+	sort.Slice(p.m.Bindings, func(i, j int) bool {
+		a, b := p.m.Bindings[i], p.m.Bindings[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Binding < b.Binding
+	})
+	return p.m, nil
+}
+
+// EntryPoint returns the entry point name, or nil.
+func (m *Module) EntryPoint(name string) *EntryPoint {
+	for _, e := range m.EntryPoints {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Group returns the bindings of group, ordered by binding.
+func (m *Module) Group(group uint32) []*Binding {
+	var bindings []*Binding
+	for _, b := range m.Bindings {
+		if b.Group == group {
+			bindings = append(bindings, b)
+		}
+	}
+	return bindings
+}
+
+// UndefinedFunctions returns a ParseError for every call of a name that is
+// no builtin function, type, struct or function of the module, e.g. a
+// misspelled builtin. Reflect doesn't fail on them.
+func (m *Module) UndefinedFunctions() []error {
+	return m.undefined
+}
+
+// VertexInputs returns the @location inputs of the entry point name,
+// ordered by location.
+func (m *Module) VertexInputs(name string) ([]Member, error) {
+	e := m.EntryPoint(name)
+	if e == nil {
+		return nil, fmt.Errorf("no entry point %q", name)
+	}
+	var inputs []Member
+	for _, in := range e.Inputs {
+		if in.Location >= 0 {
+			inputs = append(inputs, in)
+		}
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Location < inputs[j].Location })
+	return inputs, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func lex(code string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(code); {
+		c := code[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(code[i:], "//"):
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(code[i:], "/*"):
+			// block comments nest
+			start, depth := i, 0
+			for {
+				if i >= len(code) {
+					return nil, &ParseError{Offset: start, Msg: "unterminated block comment"}
+				}
+				if strings.HasPrefix(code[i:], "/*") {
+					depth++
+					i += 2
+				} else if strings.HasPrefix(code[i:], "*/") {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					i++
+				}
+			}
+		case isIdentStart(c):
+			start := i
+			for i < len(code) && isIdentPart(code[i]) {
+				i++
+			}
+			toks = append(toks, token{tokenIdent, code[start:i], start})
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(code) && code[i+1] >= '0' && code[i+1] <= '9':
+			start := i
+			for i < len(code) && (isIdentPart(code[i]) || code[i] == '.' ||
+				(code[i] == '+' || code[i] == '-') && (code[i-1] == 'e' || code[i-1] == 'E' || code[i-1] == 'p' || code[i-1] == 'P')) {
+				i++
+			}
+			toks = append(toks, token{tokenNumber, code[start:i], start})
+		case strings.HasPrefix(code[i:], "->"):
+			toks = append(toks, token{tokenPunct, "->", i})
+			i += 2
+		case strings.IndexByte("{}()[]<>;:,.@=+-*/%&|^!~?", c) >= 0:
+			toks = append(toks, token{tokenPunct, string(c), i})
+			i++
+		default:
+			return nil, &ParseError{Offset: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(toks, token{tokenEOF, "", len(code)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+type attribute struct {
+	name string
+	args []string
+}
+
+type parser struct {
+	toks    []token
+	pos     int
+	aliases map[string]Type
+	// resolving holds the aliases being expanded, to stop at cycles.
+	resolving map[string]bool
+	// funcs holds the names used in the body of every function.
+	funcs map[string][]string
+	// calls holds the names of the called functions, callEnd the position
+	// after the last call parsed.
+	calls   []token
+	callEnd int
+	m       *Module
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &ParseError{Offset: t.offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(text string) (token, error) {
+	t := p.next()
+	if t.text != text || t.kind == tokenEOF {
+		if t.kind == tokenEOF {
+			return t, p.errorf(t, "expected %q, found end of file", text)
+		}
+		return t, p.errorf(t, "expected %q, found %q", text, t.text)
+	}
+	return t, nil
+}
+
+func (p *parser) ident() (token, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		if t.kind == tokenEOF {
+			return t, p.errorf(t, "expected a name, found end of file")
+		}
+		return t, p.errorf(t, "expected a name, found %q", t.text)
+	}
+	return t, nil
+}
+
+func (p *parser) module() error {
+	for p.peek().kind != tokenEOF {
+		attrs, err := p.attributes()
+		if err != nil {
+			return err
+		}
+		t := p.peek()
+		switch t.text {
+		case "struct":
+			err = p.structDecl()
+		case "var":
+			err = p.varDecl(attrs)
+		case "fn":
+			err = p.fnDecl(attrs)
+		case "alias":
+			err = p.aliasDecl()
+		case "const", "override", "let", "enable", "requires", "diagnostic", "const_assert":
+			err = p.skipStatement()
+		case ";":
+			p.next()
+		default:
+			return p.errorf(t, "unexpected %q at module scope", t.text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) attributes() ([]attribute, error) {
+	var attrs []attribute
+	for p.peek().text == "@" {
+		p.next()
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		attr := attribute{name: name.text}
+		if p.peek().text == "(" {
+			p.next()
+			var arg []string
+			for depth := 0; ; {
+				t := p.next()
+				if t.kind == tokenEOF {
+					return nil, p.errorf(t, "unterminated @%s", name.text)
+				}
+				if depth == 0 && (t.text == "," || t.text == ")") {
+					if len(arg) > 0 {
+						attr.args = append(attr.args, strings.Join(arg, ""))
+						arg = nil
+					}
+					if t.text == ")" {
+						break
+					}
+					continue
+				}
+				if t.text == "(" {
+					depth++
+				} else if t.text == ")" {
+					depth--
+				}
+				arg = append(arg, t.text)
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+func (p *parser) typ() (Type, error) {
+	name, err := p.ident()
+	if err != nil {
+		return Type{}, err
+	}
+	t := Type{Name: name.text}
+	if p.peek().text == "<" {
+		p.next()
+		for {
+			arg, err := p.templateArg()
+			if err != nil {
+				return Type{}, err
+			}
+			t.Args = append(t.Args, arg)
+			if p.peek().text == "," {
+				p.next()
+			}
+			if p.peek().text == ">" {
+				p.next()
+				break
+			}
+		}
+	}
+	return t, nil
+}
+
+// templateArg parses a template argument, a type or an expression like an
+// array length. Expressions starting with a name, like N*2, are told apart
+// from types by what follows the name.
+func (p *parser) templateArg() (Type, error) {
+	if p.peek().kind == tokenIdent {
+		start := p.pos
+		arg, err := p.typ()
+		if err == nil && (p.peek().text == "," || p.peek().text == ">") {
+			return arg, nil
+		}
+		p.pos = start
+	}
+	var text []string
+	for depth := 0; ; {
+		t := p.peek()
+		// these can't be in a template list, so a "<" before them was a less
+		// than
+		if op, _ := p.peekOp(); t.kind == tokenEOF || op == "&&" || op == "||" ||
+			t.text == ";" || t.text == "{" || t.text == "}" || t.text == ":" || t.text == "=" && op == "=" ||
+			depth == 0 && (t.text == ")" || t.text == "]") {
+			return Type{}, p.errorf(t, "unterminated template list")
+		}
+		if depth == 0 && (t.text == "," || t.text == ">") {
+			break
+		}
+		switch t.text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+		text = append(text, p.next().text)
+	}
+	if len(text) == 0 {
+		return Type{}, p.errorf(p.peek(), "expected a template argument, found %q", p.peek().text)
+	}
+	return Type{Name: strings.Join(text, "")}, nil
+}
+
+// resolve expands aliases, including the predeclared ones like vec3f.
+func (p *parser) resolve(t Type) Type {
+	if alias, ok := p.aliases[t.Name]; ok && len(t.Args) == 0 && !p.resolving[t.Name] {
+		p.resolving[t.Name] = true
+		defer delete(p.resolving, t.Name)
+		return p.resolve(alias)
+	}
+	if len(t.Args) > 0 {
+		args := make([]Type, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = p.resolve(arg)
+		}
+		return Type{Name: t.Name, Args: args}
+	}
+	n := len(t.Name)
+	if n < 2 {
+		return t
+	}
+	scalar := map[byte]string{'f': "f32", 'i': "i32", 'u': "u32", 'h': "f16"}[t.Name[n-1]]
+	base := t.Name[:n-1]
+	isVec := len(base) == 4 && strings.HasPrefix(base, "vec") && base[3] >= '2' && base[3] <= '4'
+	isMat := len(base) == 6 && strings.HasPrefix(base, "mat") && base[4] == 'x'
+	if scalar != "" && (isVec || isMat && (scalar == "f32" || scalar == "f16")) {
+		return Type{Name: base, Args: []Type{{Name: scalar}}}
+	}
+	return t
+}
+
+func (p *parser) skipStatement() error {
+	for depth := 0; ; {
+		t := p.next()
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+		if t.kind == tokenEOF {
+			return p.errorf(t, "expected \";\", found end of file")
+		}
+	}
+}
+
+func (p *parser) aliasDecl() error {
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, err := p.expect("="); err != nil {
+		return err
+	}
+	t, err := p.typ()
+	if err != nil {
+		return err
+	}
+	p.aliases[name.text] = t
+	_, err = p.expect(";")
+	return err
+}
+
+func (p *parser) structDecl() error {
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.m.Structs[name.text]; ok {
+		return p.errorf(name, "struct %s redeclared", name.text)
+	}
+	s := &Struct{Name: name.text, Offset: name.offset}
+	if _, err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek().text != "}" {
+		m, err := p.member()
+		if err != nil {
+			return err
+		}
+		s.Members = append(s.Members, m)
+		if p.peek().text != "}" {
+			if _, err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+	p.m.Structs[s.Name] = s
+	return nil
+}
+
+// member parses a struct member or function parameter.
+func (p *parser) member() (Member, error) {
+	attrs, err := p.attributes()
+	if err != nil {
+		return Member{}, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return Member{}, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return Member{}, err
+	}
+	t, err := p.typ()
+	if err != nil {
+		return Member{}, err
+	}
+	m := Member{Name: name.text, Type: t, Location: -1}
+	if err := p.memberAttributes(&m, attrs, name); err != nil {
+		return Member{}, err
+	}
+	return m, nil
+}
+
+// memberAttributes sets the fields of m from attrs, errors are reported at
+// t.
+func (p *parser) memberAttributes(m *Member, attrs []attribute, t token) error {
+	var err error
+	for _, attr := range attrs {
+		switch attr.name {
+		case "location":
+			m.Location, err = intArg(attr)
+		case "builtin":
+			if len(attr.args) == 1 {
+				m.Builtin = attr.args[0]
+			}
+		case "align":
+			m.Align, err = intArg(attr)
+		case "size":
+			m.Size, err = intArg(attr)
+		}
+		if err != nil {
+			return p.errorf(t, "%v", err)
+		}
+	}
+	return nil
+}
+
+func intArg(attr attribute) (int, error) {
+	if len(attr.args) != 1 {
+		return 0, fmt.Errorf("@%s needs one argument", attr.name)
+	}
+	n, err := strconv.ParseUint(strings.TrimRight(attr.args[0], "iu"), 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("@%s(%s) isn't a constant integer", attr.name, attr.args[0])
+	}
+	return int(n), nil
+}
+
+func (p *parser) varDecl(attrs []attribute) error {
+	p.next()
+	var space, access string
+	if p.peek().text == "<" {
+		p.next()
+		t, err := p.ident()
+		if err != nil {
+			return err
+		}
+		space = t.text
+		if p.peek().text == "," {
+			p.next()
+			if t, err = p.ident(); err != nil {
+				return err
+			}
+			access = t.text
+		}
+		if _, err := p.expect(">"); err != nil {
+			return err
+		}
+	}
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	var t Type
+	if p.peek().text == ":" {
+		p.next()
+		if t, err = p.typ(); err != nil {
+			return err
+		}
+	}
+	if err := p.skipStatement(); err != nil {
+		return err
+	}
+
+	var group, binding = -1, -1
+	for _, attr := range attrs {
+		switch attr.name {
+		case "group":
+			group, err = intArg(attr)
+		case "binding":
+			binding, err = intArg(attr)
+		}
+		if err != nil {
+			return p.errorf(name, "%v", err)
+		}
+	}
+	if group < 0 && binding < 0 {
+		return nil
+	}
+	if group < 0 || binding < 0 {
+		return p.errorf(name, "%s needs both @group and @binding", name.text)
+	}
+	for _, b := range p.m.Bindings {
+		if b.Group == uint32(group) && b.Binding == uint32(binding) {
+			return p.errorf(name, "@group(%d) @binding(%d) is used by both %s and %s", group, binding, b.Name, name.text)
+		}
+	}
+	p.m.Bindings = append(p.m.Bindings, &Binding{
+		Group:        uint32(group),
+		Binding:      uint32(binding),
+		Name:         name.text,
+		AddressSpace: space,
+		Access:       access,
+		Type:         t,
+		Offset:       name.offset,
+	})
+	return nil
+}
+
+func (p *parser) fnDecl(attrs []attribute) error {
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, err := p.expect("("); err != nil {
+		return err
+	}
+	var params []Member
+	for p.peek().text != ")" {
+		m, err := p.member()
+		if err != nil {
+			return err
+		}
+		params = append(params, m)
+		if p.peek().text != ")" {
+			if _, err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+	var results []Member
+	if arrow := p.peek(); arrow.text == "->" {
+		p.next()
+		attrs, err := p.attributes()
+		if err != nil {
+			return err
+		}
+		t, err := p.typ()
+		if err != nil {
+			return err
+		}
+		result := Member{Type: t, Location: -1}
+		if err := p.memberAttributes(&result, attrs, arrow); err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	start := p.pos
+	if err := p.block(); err != nil {
+		return err
+	}
+	var names []string
+	for _, t := range p.toks[start:p.pos] {
+		if t.kind == tokenIdent {
+			names = append(names, t.text)
+		}
+	}
+	if _, ok := p.funcs[name.text]; ok {
+		return p.errorf(name, "function %s redeclared", name.text)
+	}
+	p.funcs[name.text] = names
+
+	e := &EntryPoint{Name: name.text, Offset: name.offset}
+	for _, attr := range attrs {
+		switch attr.name {
+		case "vertex":
+			e.Stage = wgpu.ShaderStage_Vertex
+		case "fragment":
+			e.Stage = wgpu.ShaderStage_Fragment
+		case "compute":
+			e.Stage = wgpu.ShaderStage_Compute
+		case "workgroup_size":
+			e.WorkgroupSize = attr.args
+		}
+	}
+	if e.Stage == wgpu.ShaderStage_None {
+		return nil
+	}
+	// flattened by resolveTypes, the structs may be declared later
+	e.Inputs, e.Outputs = params, results
+	p.m.EntryPoints = append(p.m.EntryPoints, e)
+	return nil
+}
+
+// resolveTypes expands the aliases in all types and flattens the struct
+// parameters and results of the entry points. It runs after the whole
+// module is parsed, since WGSL declarations may be used before they are
+// declared.
+func (p *parser) resolveTypes() {
+	for _, s := range p.m.Structs {
+		for i := range s.Members {
+			s.Members[i].Type = p.resolve(s.Members[i].Type)
+		}
+	}
+	for _, b := range p.m.Bindings {
+		b.Type = p.resolve(b.Type)
+	}
+	for _, e := range p.m.EntryPoints {
+		e.Inputs = p.flatten(e.Inputs)
+		e.Outputs = p.flatten(e.Outputs)
+	}
+}
+
+// flatten resolves the types of members and replaces struct members by
+// the members of their struct.
+func (p *parser) flatten(members []Member) []Member {
+	var flat []Member
+	for _, m := range members {
+		m.Type = p.resolve(m.Type)
+		if s, ok := p.m.Structs[m.Type.Name]; ok && len(m.Type.Args) == 0 {
+			flat = append(flat, s.Members...)
+		} else {
+			flat = append(flat, m)
+		}
+	}
+	return flat
+}
+
+// visibility sets the Visibility of the bindings from the names used by
+// every entry point and the functions it calls. Local names shadowing a
+// binding count as uses, so the result may be a superset.
+func (p *parser) visibility() {
+	bindings := map[string]*Binding{}
+	for _, b := range p.m.Bindings {
+		bindings[b.Name] = b
+	}
+	for _, e := range p.m.EntryPoints {
+		seen := map[string]bool{}
+		var walk func(fn string)
+		walk = func(fn string) {
+			if seen[fn] {
+				return
+			}
+			seen[fn] = true
+			for _, name := range p.funcs[fn] {
+				if b, ok := bindings[name]; ok {
+					b.Visibility |= e.Stage
+				}
+				if _, ok := p.funcs[name]; ok {
+					walk(name)
+				}
+			}
+		}
+		walk(e.Name)
+	}
+}
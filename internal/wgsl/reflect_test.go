@@ -0,0 +1,478 @@
+package wgsl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func TestReflectAliases(t *testing.T) {
+	m, err := Reflect(`
+alias Position = vec3f;
+alias Colors = array<vec4<f32>, 4>;
+
+struct VertexInput {
+    @location(0) position: Position,
+    @location(1) normal: vec3h,
+    @location(2) id: u32,
+}
+
+@group(0) @binding(0) var<storage, read> colors: Colors;
+@group(0) @binding(1) var<uniform> transform: mat4x4f;
+
+@vertex
+fn vs_main(in: VertexInput) -> @builtin(position) vec4f {
+    return transform * vec4f(in.position, 1.0) + colors[in.id];
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := m.VertexInputs("vs_main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"vec3<f32>", "vec3<f16>", "u32"}
+	if len(inputs) != len(want) {
+		t.Fatalf("got %d inputs, want %d", len(inputs), len(want))
+	}
+	for i, input := range inputs {
+		if got := input.Type.String(); got != want[i] {
+			t.Errorf("%s: type %s, want %s", input.Name, got, want[i])
+		}
+	}
+
+	bindings := m.Group(0)
+	if len(bindings) != 2 {
+		t.Fatalf("got %d bindings, want 2", len(bindings))
+	}
+	if got := bindings[0].Type.String(); got != "array<vec4<f32>, 4>" {
+		t.Errorf("colors: type %s, want array<vec4<f32>, 4>", got)
+	}
+	if b := bindings[0]; b.AddressSpace != "storage" || b.Access != "read" {
+		t.Errorf("colors: var<%s, %s>, want var<storage, read>", b.AddressSpace, b.Access)
+	}
+	if got := bindings[1].Type.String(); got != "mat4x4<f32>" {
+		t.Errorf("transform: type %s, want mat4x4<f32>", got)
+	}
+}
+
+func TestReflectDeclarationOrder(t *testing.T) {
+	m, err := Reflect(`
+@vertex
+fn vs_main(in: Input) -> VertexOutput {
+    var out: VertexOutput;
+    out.position = vec4f(in.position, 1.0);
+    out.color = in.color;
+    return out;
+}
+
+alias Input = VertexInput;
+
+struct VertexInput {
+    @location(0) position: Position,
+    @location(1) color: vec3f,
+}
+
+struct VertexOutput {
+    @builtin(position) position: vec4f,
+    @location(0) color: Position,
+}
+
+alias Position = vec3f;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := m.VertexInputs("vs_main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("got %d inputs, want 2", len(inputs))
+	}
+	for _, input := range inputs {
+		if got := input.Type.String(); got != "vec3<f32>" {
+			t.Errorf("%s: type %s, want vec3<f32>", input.Name, got)
+		}
+	}
+	outputs := m.EntryPoint("vs_main").Outputs
+	if len(outputs) != 2 || outputs[0].Builtin != "position" || outputs[1].Location != 0 {
+		t.Fatalf("got outputs %+v, want the members of VertexOutput", outputs)
+	}
+	if got := outputs[1].Type.String(); got != "vec3<f32>" {
+		t.Errorf("color output: type %s, want vec3<f32>", got)
+	}
+}
+
+func TestReflectTemplateExpressions(t *testing.T) {
+	m, err := Reflect(`
+const N = 4u;
+@group(0) @binding(0) var<storage, read> a: array<f32, N*2>;
+@group(0) @binding(1) var<storage, read> b: array<vec4f, N>;
+@group(0) @binding(2) var<storage, read> c: array<f32, (N + 1) * 2>;
+
+@fragment
+fn fs_main() -> @location(0) vec4f {
+    return vec4f(a[0], b[0].x, c[0], 1.0);
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"array<f32, N*2>", "array<vec4<f32>, N>", "array<f32, (N+1)*2>"}
+	for i, b := range m.Bindings {
+		if got := b.Type.String(); got != want[i] {
+			t.Errorf("%s: type %s, want %s", b.Name, got, want[i])
+		}
+	}
+	outputs := m.EntryPoint("fs_main").Outputs
+	if len(outputs) != 1 || outputs[0].Location != 0 || outputs[0].Type.String() != "vec4<f32>" {
+		t.Errorf("got outputs %+v, want @location(0) vec4<f32>", outputs)
+	}
+}
+
+func TestReflectBodies(t *testing.T) {
+	m, err := Reflect(`
+struct Light {
+    position: vec3f,
+    color: vec3<f32>,
+}
+
+@group(0) @binding(0) var<storage, read_write> data: array<u32>;
+@group(0) @binding(1) var<storage, read> lights: array<Light, 4>;
+
+const N = 4u;
+
+fn shade(light: Light, n: vec3f) -> vec3f {
+    return max(dot(n, normalize(light.position)), 0.0) * light.color;
+}
+
+@compute @workgroup_size(64)
+fn main(@builtin(global_invocation_id) id: vec3<u32>) {
+    var sum = vec3<f32>(0.0);
+    var<function> count: u32;
+    let n = vec3f(0.0, 1.0, 0.0);
+    const scale: f32 = 2.0;
+    for (var i = 0u; i < N; i++) {
+        sum += shade(lights[i], n) * scale;
+    }
+    var j = 0u;
+    while j < N && data[j] != 0u {
+        j = j + 1u;
+    }
+    loop {
+        if j >= N || (data[j] >> 2u) == 0u {
+            break;
+        } else if j == 2u {
+            continue;
+        } else {
+            data[j] <<= 1u;
+        }
+        continuing {
+            j++;
+            break if j > 8u;
+        }
+    }
+    switch data[0] {
+        case 0u, 1u: {
+            data[1] = bitcast<u32>(-1i);
+        }
+        case 2u, default {
+            data[2] = u32(sum.x < 0.5);
+        }
+    }
+    let p = &data;
+    (*p)[3] = select(0u, 1u, j <= N);
+    _ = arrayLength(&data);
+    workgroupBarrier();
+    data[id.x] = ~data[id.x] & 0xffu | u32(!(j == 0u));
+    {
+        let a = array<f32, N>(1.0, 2.0, 3.0, 4.0,);
+        data[4] = u32(a[1] < a[2]) + u32(a[3] > 1.0);
+    }
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := m.UndefinedFunctions(); len(errs) != 0 {
+		t.Errorf("got undefined functions %v, want none", errs)
+	}
+	if b := m.Group(0)[1]; b.Visibility != wgpu.ShaderStage_Compute {
+		t.Errorf("lights: visible to %s, want compute", stages(b.Visibility))
+	}
+}
+
+func TestReflectBodyErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		body string
+		// want is part of the error message, at is where the error is
+		want string
+		at   string
+	}{
+		{name: "missing operand", body: "let x = 1.0 + ;", want: `expected an expression, found ";"`, at: ";"},
+		{name: "missing semicolon", body: "let x = 1.0\n    let y = 2.0;", want: `expected ";", found "let"`, at: "let y"},
+		{name: "not a statement", body: "x + 1.0;", want: "expected an assignment or a function call", at: "x + 1.0"},
+		{name: "let without value", body: "let x: f32;", want: `expected "=", found ";"`, at: ";"},
+		{name: "unbalanced parentheses", body: "let x = (1.0 + 2.0;", want: `expected ")", found ";"`, at: ";"},
+		{name: "bad case", body: "switch 1 { x: {} }", want: `expected "case" or "default", found "x"`, at: "x:"},
+		{name: "unterminated block", body: "if true {", want: "unterminated block", at: "{\n    if true"},
+		{name: "missing for clause", body: "for (var i = 0; i < 4) {}", want: `expected ";", found ")"`, at: ") {}"},
+	} {
+		code := "fn f() {\n    " + test.body + "\n}"
+		_, err := Reflect(code)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: got %v, want a ParseError", test.name, err)
+			continue
+		}
+		if !strings.Contains(parseErr.Msg, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, parseErr.Msg, test.want)
+		}
+		if at := strings.LastIndex(code, test.at); parseErr.Offset != at {
+			t.Errorf("%s: error at offset %d, want %d", test.name, parseErr.Offset, at)
+		}
+	}
+}
+
+func TestUndefinedFunctions(t *testing.T) {
+	code := `
+struct S { a: f32 }
+alias V = vec3f;
+
+fn helper() -> f32 {
+    return 1.0;
+}
+
+@fragment
+fn fs_main() -> @location(0) vec4f {
+    let s = S(helper());
+    let v = V(normalise(vec3f(1.0)));
+    return vec4<f32>(v * s.a, clamp(1.0, 0.0, 1.0) + mystery());
+}
+`
+	m, err := Reflect(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, err := range m.UndefinedFunctions() {
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("got %v, want a ParseError", err)
+		}
+		got = append(got, fmt.Sprintf("%d: %s", parseErr.Offset, parseErr.Msg))
+	}
+	want := []string{
+		fmt.Sprintf("%d: call of undefined function normalise", strings.Index(code, "normalise")),
+		fmt.Sprintf("%d: call of undefined function mystery", strings.Index(code, "mystery")),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReflectBlockComments(t *testing.T) {
+	m, err := Reflect(`
+/* outer /* nested @group(0) @binding(0) var<uniform> hidden: f32; */
+   still a comment @group(0) @binding(1) var<uniform> hidden2: f32;
+*/
+@group(0) @binding(2) var<uniform> visible: f32;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Bindings) != 1 || m.Bindings[0].Name != "visible" {
+		t.Errorf("got bindings %v, want only visible", m.Bindings)
+	}
+
+	_, err = Reflect("/* /* */ @group(0) @binding(0) var<uniform> x: f32;")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Offset != 0 {
+		t.Errorf("unterminated nested comment: got %v, want a ParseError at offset 0", err)
+	}
+}
+
+func TestReflectVisibility(t *testing.T) {
+	m, err := Reflect(`
+@group(0) @binding(0) var<uniform> camera: mat4x4<f32>;
+@group(0) @binding(1) var t: texture_2d<f32>;
+@group(0) @binding(2) var s: sampler;
+@group(0) @binding(3) var<uniform> unused: f32;
+@group(0) @binding(4) var<storage, read_write> counter: u32;
+
+fn sample(uv: vec2<f32>) -> vec4<f32> {
+    return textureSample(t, s, uv);
+}
+
+// called from both stages, through a second function for fs_main
+fn project(p: vec3<f32>) -> vec4<f32> {
+    return camera * vec4<f32>(p, 1.0);
+}
+
+fn shade(uv: vec2<f32>) -> vec4<f32> {
+    return sample(uv) * project(vec3<f32>(uv, 0.0)).w;
+}
+
+@vertex
+fn vs_main(@location(0) p: vec3<f32>) -> @builtin(position) vec4<f32> {
+    return project(p);
+}
+
+@fragment
+fn fs_main(@location(0) uv: vec2<f32>) -> @location(0) vec4<f32> {
+    return shade(uv);
+}
+
+@compute @workgroup_size(64)
+fn cs_main() {
+    counter = counter + 1u;
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]wgpu.ShaderStage{
+		"camera":  wgpu.ShaderStage_Vertex | wgpu.ShaderStage_Fragment,
+		"t":       wgpu.ShaderStage_Fragment,
+		"s":       wgpu.ShaderStage_Fragment,
+		"unused":  wgpu.ShaderStage_None,
+		"counter": wgpu.ShaderStage_Compute,
+	}
+	for _, b := range m.Bindings {
+		if b.Visibility != want[b.Name] {
+			t.Errorf("%s: visible to %s, want %s", b.Name, stages(b.Visibility), stages(want[b.Name]))
+		}
+	}
+	if e := m.EntryPoint("cs_main"); e == nil || len(e.WorkgroupSize) != 1 || e.WorkgroupSize[0] != "64" {
+		t.Errorf("cs_main: got %+v, want @workgroup_size(64)", e)
+	}
+}
+
+func TestReflectErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		code string
+		// want is part of the error message, at is where the error is
+		want string
+		at   string
+	}{
+		{
+			name: "duplicate binding",
+			code: "@group(0) @binding(1) var<uniform> a: f32;\n@group(0) @binding(1) var<uniform> b: f32;",
+			want: "@group(0) @binding(1) is used by both a and b",
+			at:   "b: f32",
+		},
+		{
+			name: "missing binding",
+			code: "@group(1) var<uniform> a: f32;",
+			want: "a needs both @group and @binding",
+			at:   "a: f32",
+		},
+		{
+			name: "redeclared function",
+			code: "fn f() {}\nfn f() {}",
+			want: "function f redeclared",
+			at:   "f() {}",
+		},
+		{
+			name: "unexpected character",
+			code: "@group(0) @binding(0) var<uniform> a: f32; $",
+			want: `unexpected character '$'`,
+			at:   "$",
+		},
+	} {
+		_, err := Reflect(test.code)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: got %v, want a ParseError", test.name, err)
+			continue
+		}
+		if !strings.Contains(parseErr.Msg, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, parseErr.Msg, test.want)
+		}
+		if at := strings.LastIndex(test.code, test.at); parseErr.Offset != at {
+			t.Errorf("%s: error at offset %d, want %d", test.name, parseErr.Offset, at)
+		}
+	}
+}
+
+func TestValidateBindGroupLayout(t *testing.T) {
+	m, err := Reflect(`
+@group(0) @binding(0) var<uniform> camera: mat4x4<f32>;
+@group(0) @binding(1) var t: texture_2d<f32>;
+
+@vertex
+fn vs_main() -> @builtin(position) vec4<f32> {
+    return camera[0] + textureLoad(t, vec2<i32>(0, 0), 0);
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := m.BindGroupLayoutEntries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ValidateBindGroupLayout(0, entries); err != nil {
+		t.Errorf("derived entries: %v", err)
+	}
+
+	entries[0].Visibility = wgpu.ShaderStage_Fragment
+	entries[1].Texture.ViewDimension = wgpu.TextureViewDimension_Cube
+	err = m.ValidateBindGroupLayout(0, entries)
+	if err == nil {
+		t.Fatal("got no error for a wrong layout")
+	}
+	for _, want := range []string{"camera: @group(0) @binding(0)", "t: @group(0) @binding(1)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+	if err := m.ValidateBindGroupLayout(0, entries[:1]); err == nil || !strings.Contains(err.Error(), "no layout entry") {
+		t.Errorf("got %v, want a missing entry error", err)
+	}
+}
+
+func TestValidateVertexBuffers(t *testing.T) {
+	m, err := Reflect(`
+@vertex
+fn vs_main(@location(0) position: vec3<f32>, @location(1) id: u32) -> @builtin(position) vec4<f32> {
+    return vec4<f32>(position, f32(id));
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := m.VertexBufferLayout("vs_main", wgpu.VertexStepMode_Vertex, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.ArrayStride != 16 {
+		t.Errorf("stride %d, want 16", layout.ArrayStride)
+	}
+	if err := m.ValidateVertexBuffers("vs_main", []wgpu.VertexBufferLayout{layout}); err != nil {
+		t.Errorf("derived layout: %v", err)
+	}
+
+	layout.Attributes[1].Format = wgpu.VertexFormat_Float32
+	if err := m.ValidateVertexBuffers("vs_main", []wgpu.VertexBufferLayout{layout}); err == nil {
+		t.Error("got no error for a float attribute read as u32")
+	}
+	layout.Attributes = layout.Attributes[:1]
+	if err := m.ValidateVertexBuffers("vs_main", []wgpu.VertexBufferLayout{layout}); err == nil {
+		t.Error("got no error for a missing attribute")
+	}
+	if err := m.ValidateVertexBuffers("main", nil); err == nil {
+		t.Error("got no error for a missing entry point")
+	}
+}
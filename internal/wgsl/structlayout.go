@@ -0,0 +1,193 @@
+package wgsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is a struct member placed by the WGSL memory layout rules.
+type Field struct {
+	Name   string
+	Type   Type
+	Offset int
+	// Size includes the member's @size.
+	Size int
+}
+
+// SizeAlign returns the size and alignment of t in the uniform and storage
+// address spaces. Runtime-sized arrays have the size of one element.
+func (m *Module) SizeAlign(t Type) (size, align int, err error) {
+	return m.sizeAlign(t, map[string]bool{})
+}
+
+// sizeAlign is SizeAlign, visiting tracks the structs being laid out to
+// catch recursive ones.
+func (m *Module) sizeAlign(t Type, visiting map[string]bool) (size, align int, err error) {
+	switch t.Name {
+	case "f32", "i32", "u32":
+		return 4, 4, nil
+	case "f16":
+		return 2, 2, nil
+	case "atomic":
+		if len(t.Args) == 1 {
+			return m.sizeAlign(t.Args[0], visiting)
+		}
+	case "vec2", "vec3", "vec4":
+		if len(t.Args) != 1 {
+			break
+		}
+		s, _, err := m.sizeAlign(t.Args[0], visiting)
+		if err != nil {
+			return 0, 0, err
+		}
+		n := int(t.Name[3] - '0')
+		if n == 2 {
+			return 2 * s, 2 * s, nil
+		}
+		return n * s, 4 * s, nil
+	case "array":
+		if len(t.Args) < 1 || len(t.Args) > 2 {
+			break
+		}
+		size, align, err := m.sizeAlign(t.Args[0], visiting)
+		if err != nil {
+			return 0, 0, err
+		}
+		stride := roundUp(align, size)
+		if len(t.Args) == 1 {
+			return stride, align, nil
+		}
+		n, err := strconv.ParseUint(strings.TrimRight(t.Args[1].Name, "iu"), 0, 32)
+		if err != nil || n == 0 {
+			return 0, 0, fmt.Errorf("array length %s of %s isn't a positive integer literal", t.Args[1].Name, t)
+		}
+		return int(n) * stride, align, nil
+	}
+
+	if cols, rows, ok := matrix(t); ok {
+		size, align, err := m.sizeAlign(Type{Name: "vec" + strconv.Itoa(rows), Args: t.Args}, visiting)
+		if err != nil {
+			return 0, 0, err
+		}
+		return cols * roundUp(align, size), align, nil
+	}
+	if s, ok := m.Structs[t.Name]; ok && len(t.Args) == 0 {
+		_, size, align, err := m.structLayout(s, visiting)
+		return size, align, err
+	}
+	return 0, 0, fmt.Errorf("%s isn't host-shareable", t)
+}
+
+func matrix(t Type) (cols, rows int, ok bool) {
+	if len(t.Name) != 6 || !strings.HasPrefix(t.Name, "mat") || t.Name[4] != 'x' || len(t.Args) != 1 {
+		return 0, 0, false
+	}
+	cols, rows = int(t.Name[3]-'0'), int(t.Name[5]-'0')
+	return cols, rows, cols >= 2 && cols <= 4 && rows >= 2 && rows <= 4
+}
+
+// StructLayout returns the members of the struct name with their offsets,
+// and the size of the struct. With space "uniform" it also checks the
+// extra alignment rules of uniform buffers: array elements and nested
+// structs must be 16 byte aligned.
+func (m *Module) StructLayout(name string, space string) ([]Field, int, error) {
+	s, ok := m.Structs[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("no struct %s", name)
+	}
+	fields, size, _, err := m.structLayout(s, map[string]bool{})
+	if err != nil {
+		return nil, 0, err
+	}
+	if space == "uniform" {
+		if err := m.checkUniform(Type{Name: name}, name); err != nil {
+			return nil, 0, err
+		}
+	}
+	return fields, size, nil
+}
+
+// structLayout places the members of s.
+func (m *Module) structLayout(s *Struct, visiting map[string]bool) (fields []Field, size, align int, err error) {
+	if visiting[s.Name] {
+		return nil, 0, 0, fmt.Errorf("struct %s contains itself", s.Name)
+	}
+	visiting[s.Name] = true
+	defer delete(visiting, s.Name)
+
+	offset, align := 0, 1
+	for i, member := range s.Members {
+		size, a, err := m.sizeAlign(member.Type, visiting)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("%s.%s: %w", s.Name, member.Name, err)
+		}
+		if member.Type.Name == "array" && len(member.Type.Args) == 1 && i != len(s.Members)-1 {
+			return nil, 0, 0, fmt.Errorf("%s.%s: a runtime-sized array must be the last member", s.Name, member.Name)
+		}
+		if member.Align > 0 {
+			if member.Align&(member.Align-1) != 0 || member.Align < a {
+				return nil, 0, 0, fmt.Errorf("%s.%s: @align(%d) must be a power of 2 of at least %d", s.Name, member.Name, member.Align, a)
+			}
+			a = member.Align
+		}
+		if member.Size > 0 {
+			if member.Size < size {
+				return nil, 0, 0, fmt.Errorf("%s.%s: @size(%d) is less than the size %d of %s", s.Name, member.Name, member.Size, size, member.Type)
+			}
+			size = member.Size
+		}
+		offset = roundUp(a, offset)
+		fields = append(fields, Field{Name: member.Name, Type: member.Type, Offset: offset, Size: size})
+		offset += size
+		align = max(align, a)
+	}
+	return fields, roundUp(align, offset), align, nil
+}
+
+func (m *Module) checkUniform(t Type, path string) error {
+	switch {
+	case t.Name == "array":
+		if len(t.Args) == 1 {
+			return fmt.Errorf("%s: runtime-sized arrays can't be in the uniform address space", path)
+		}
+		size, align, err := m.SizeAlign(t.Args[0])
+		if err != nil {
+			return err
+		}
+		if stride := roundUp(align, size); stride%16 != 0 {
+			return fmt.Errorf("%s: the stride %d of %s must be a multiple of 16 in the uniform address space, use an array of vec4", path, stride, t)
+		}
+		return m.checkUniform(t.Args[0], path+"[]")
+	case m.Structs[t.Name] != nil && len(t.Args) == 0:
+		s := m.Structs[t.Name]
+		fields, _, _, err := m.structLayout(s, map[string]bool{})
+		if err != nil {
+			return err
+		}
+		for i, f := range fields {
+			fieldPath := path + "." + f.Name
+			if nested, ok := m.Structs[f.Type.Name]; ok && len(f.Type.Args) == 0 {
+				_, size, align, err := m.structLayout(nested, map[string]bool{})
+				if err != nil {
+					return err
+				}
+				if f.Offset%roundUp(16, align) != 0 {
+					return fmt.Errorf("%s: struct members must be 16 byte aligned in the uniform address space, it is at offset %d", fieldPath, f.Offset)
+				}
+				if i+1 < len(fields) && fields[i+1].Offset < f.Offset+roundUp(16, size) {
+					return fmt.Errorf("%s: the member after a struct must be at least %d bytes after it in the uniform address space",
+						path+"."+fields[i+1].Name, roundUp(16, size))
+				}
+			}
+			if err := m.checkUniform(f.Type, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func roundUp(k, n int) int {
+	return (n + k - 1) / k * k
+}
@@ -0,0 +1,190 @@
+package wgsl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStructLayout(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		code    string
+		space   string
+		offsets []int
+		size    int
+	}{
+		{
+			name:    "vec3 followed by a scalar",
+			code:    "struct S { a: vec3<f32>, b: f32 }",
+			space:   "storage",
+			offsets: []int{0, 12},
+			size:    16,
+		},
+		{
+			name:    "vec3 followed by a vec3",
+			code:    "struct S { a: vec3<f32>, b: vec3<f32> }",
+			space:   "storage",
+			offsets: []int{0, 16},
+			size:    32,
+		},
+		{
+			name:    "scalar followed by a vec3",
+			code:    "struct S { a: f32, b: vec3<f32>, c: vec2<f32> }",
+			space:   "uniform",
+			offsets: []int{0, 16, 32},
+			size:    48,
+		},
+		{
+			name:    "mat3x3 columns are padded",
+			code:    "struct S { m: mat3x3<f32>, a: f32 }",
+			space:   "uniform",
+			offsets: []int{0, 48},
+			size:    64,
+		},
+		{
+			name:    "array of vec4",
+			code:    "struct S { a: f32, colors: array<vec4<f32>, 4> }",
+			space:   "uniform",
+			offsets: []int{0, 16},
+			size:    80,
+		},
+		{
+			name:    "array of f32 in storage",
+			code:    "struct S { a: array<f32, 3>, b: f32 }",
+			space:   "storage",
+			offsets: []int{0, 12},
+			size:    16,
+		},
+		{
+			name:    "runtime-sized array",
+			code:    "struct S { count: u32, values: array<vec2<f32>> }",
+			space:   "storage",
+			offsets: []int{0, 8},
+			size:    16,
+		},
+		{
+			name:    "nested struct",
+			code:    "struct Inner { a: vec2<f32> }\nstruct S { a: f32, inner: Inner, b: f32 }",
+			space:   "storage",
+			offsets: []int{0, 8, 16},
+			size:    24,
+		},
+		{
+			name:    "nested struct aligned for uniform",
+			code:    "struct Inner { a: vec2<f32> }\nstruct S { a: f32, @align(16) inner: Inner, @align(16) b: f32 }",
+			space:   "uniform",
+			offsets: []int{0, 16, 32},
+			size:    48,
+		},
+		{
+			name:    "size and align",
+			code:    "struct S { @size(16) a: f32, @align(32) b: vec2<f32>, c: f32 }",
+			space:   "storage",
+			offsets: []int{0, 32, 40},
+			size:    64,
+		},
+	} {
+		m, err := Reflect(test.code)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		fields, size, err := m.StructLayout("S", test.space)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var offsets []int
+		for _, f := range fields {
+			offsets = append(offsets, f.Offset)
+		}
+		if !slices.Equal(offsets, test.offsets) || size != test.size {
+			t.Errorf("%s: offsets %v size %d, want %v size %d", test.name, offsets, size, test.offsets, test.size)
+		}
+	}
+}
+
+func TestStructLayoutErrors(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		code  string
+		space string
+		want  string
+	}{
+		{
+			name:  "uniform array of f32",
+			code:  "struct S { a: array<f32, 4> }",
+			space: "uniform",
+			want:  "S.a: the stride 4 of array<f32, 4> must be a multiple of 16",
+		},
+		{
+			name:  "uniform array of vec2 in a nested struct",
+			code:  "struct Inner { a: array<vec2<f32>, 2> }\nstruct S { @align(16) inner: Inner }",
+			space: "uniform",
+			want:  "S.inner.a: the stride 8",
+		},
+		{
+			name:  "uniform runtime-sized array",
+			code:  "struct S { a: array<vec4<f32>> }",
+			space: "uniform",
+			want:  "runtime-sized arrays can't be in the uniform address space",
+		},
+		{
+			name:  "uniform nested struct offset",
+			code:  "struct Inner { a: f32 }\nstruct S { a: f32, inner: Inner }",
+			space: "uniform",
+			want:  "S.inner: struct members must be 16 byte aligned in the uniform address space, it is at offset 4",
+		},
+		{
+			name:  "uniform member right after a nested struct",
+			code:  "struct Inner { a: f32 }\nstruct S { inner: Inner, b: f32 }",
+			space: "uniform",
+			want:  "S.b: the member after a struct must be at least 16 bytes after it",
+		},
+		{
+			name:  "size less than the type",
+			code:  "struct S { @size(8) a: vec4<f32> }",
+			space: "storage",
+			want:  "S.a: @size(8) is less than the size 16 of vec4<f32>",
+		},
+		{
+			name:  "align not a power of 2",
+			code:  "struct S { @align(12) a: f32 }",
+			space: "storage",
+			want:  "S.a: @align(12) must be a power of 2 of at least 4",
+		},
+		{
+			name:  "align less than the type",
+			code:  "struct S { @align(8) a: vec4<f32> }",
+			space: "storage",
+			want:  "S.a: @align(8) must be a power of 2 of at least 16",
+		},
+		{
+			name:  "runtime-sized array not last",
+			code:  "struct S { a: array<f32>, b: f32 }",
+			space: "storage",
+			want:  "S.a: a runtime-sized array must be the last member",
+		},
+		{
+			name:  "recursive struct",
+			code:  "struct S { a: f32, s: array<S, 2> }",
+			space: "storage",
+			want:  "struct S contains itself",
+		},
+		{
+			name:  "not host-shareable",
+			code:  "struct S { a: bool }",
+			space: "storage",
+			want:  "S.a: bool isn't host-shareable",
+		},
+	} {
+		m, err := Reflect(test.code)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		_, _, err = m.StructLayout("S", test.space)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %q", test.name, err, test.want)
+		}
+	}
+}
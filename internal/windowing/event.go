@@ -0,0 +1,111 @@
+package windowing
+
+type Event interface {
+	isEvent()
+}
+
+type ResizedEvent struct {
+	Width       uint32
+	Height      uint32
+	ScaleFactor float64
+}
+
+type CloseRequestedEvent struct{}
+
+type SurfaceCreatedEvent struct{}
+
+type SurfaceDestroyedEvent struct{}
+
+type KeyEvent struct {
+	Key     Key
+	Pressed bool
+}
+
+type CursorMovedEvent struct {
+	// Physical pixels relative to the top-left corner of the window
+	X, Y float64
+}
+
+type MouseButtonEvent struct {
+	Button  MouseButton
+	Pressed bool
+}
+
+type MouseScrollEvent struct {
+	DeltaX, DeltaY float64
+}
+
+func (ResizedEvent) isEvent()          {}
+func (CloseRequestedEvent) isEvent()   {}
+func (SurfaceCreatedEvent) isEvent()   {}
+func (SurfaceDestroyedEvent) isEvent() {}
+func (KeyEvent) isEvent()              {}
+func (CursorMovedEvent) isEvent()      {}
+func (MouseButtonEvent) isEvent()      {}
+func (MouseScrollEvent) isEvent()      {}
+
+type MouseButton uint8
+
+const (
+	MouseButtonLeft MouseButton = iota
+	MouseButtonRight
+	MouseButtonMiddle
+)
+
+type Key uint8
+
+const (
+	KeyUnknown Key = iota
+
+	KeyA
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+
+	Key0
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+
+	KeySpace
+	KeyEscape
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyLeft
+	KeyRight
+	KeyUp
+	KeyDown
+	KeyLeftShift
+	KeyRightShift
+	KeyLeftControl
+	KeyRightControl
+)
@@ -0,0 +1,4 @@
+// Package gamenwindow implements the "gamen" windowing backend. On android
+// the surface only exists between SurfaceCreatedEvent and
+// SurfaceDestroyedEvent.
+package gamenwindow
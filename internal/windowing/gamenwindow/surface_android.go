@@ -1,13 +1,13 @@
-//go:build android
+//go:build android && !nowindow
 
-package main
+package gamenwindow
 
 import (
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-func getSurfaceDescriptor(w display.Window) *wgpu.SurfaceDescriptor {
+func SurfaceDescriptor(w display.Window) *wgpu.SurfaceDescriptor {
 	if w, ok := w.(display.AndroidWindow); ok {
 		return &wgpu.SurfaceDescriptor{
 			AndroidNativeWindow: &wgpu.SurfaceDescriptorFromAndroidNativeWindow{
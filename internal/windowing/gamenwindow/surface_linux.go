@@ -1,13 +1,13 @@
-//go:build linux && !android
+//go:build linux && !android && !nowindow
 
-package main
+package gamenwindow
 
 import (
 	"github.com/rajveermalviya/gamen/display"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-func getSurfaceDescriptor(w display.Window) *wgpu.SurfaceDescriptor {
+func SurfaceDescriptor(w display.Window) *wgpu.SurfaceDescriptor {
 	switch w := w.(type) {
 	case display.WaylandWindow:
 		return &wgpu.SurfaceDescriptor{
@@ -1,6 +1,6 @@
-//go:build windows
+//go:build windows && !nowindow
 
-package main
+package gamenwindow
 
 import (
 	"unsafe"
@@ -9,7 +9,7 @@ import (
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-func getSurfaceDescriptor(w display.Window) *wgpu.SurfaceDescriptor {
+func SurfaceDescriptor(w display.Window) *wgpu.SurfaceDescriptor {
 	if w, ok := w.(display.Win32Window); ok {
 		return &wgpu.SurfaceDescriptor{
 			WindowsHWND: &wgpu.SurfaceDescriptorFromWindowsHWND{
@@ -0,0 +1,192 @@
+//go:build (linux || windows) && !nowindow
+
+package gamenwindow
+
+import (
+	"github.com/rajveermalviya/gamen/display"
+	"github.com/rajveermalviya/gamen/dpi"
+	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func init() {
+	windowing.Register("gamen", New)
+}
+
+type Window struct {
+	display     display.Display
+	window      display.Window
+	scaleFactor float64
+	hasSurface  bool
+	cb          func(windowing.Event)
+}
+
+func New(opts windowing.Options) (windowing.Window, error) {
+	d, err := display.NewDisplay()
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := display.NewWindow(d)
+	if err != nil {
+		d.Destroy()
+		return nil, err
+	}
+
+	window.SetTitle(opts.Title)
+	window.SetInnerSize(dpi.LogicalSize[uint32]{
+		Width:  uint32(opts.Width),
+		Height: uint32(opts.Height),
+	})
+
+	w := &Window{
+		display:     d,
+		window:      window,
+		scaleFactor: 1,
+		hasSurface:  true,
+	}
+
+	if window, ok := window.(display.AndroidWindowExt); ok {
+		w.hasSurface = false
+		window.SetSurfaceCreatedCallback(func() {
+			w.hasSurface = true
+			w.emit(windowing.SurfaceCreatedEvent{})
+		})
+		window.SetSurfaceDestroyedCallback(func() {
+			w.emit(windowing.SurfaceDestroyedEvent{})
+			w.hasSurface = false
+		})
+	}
+
+	window.SetResizedCallback(func(physicalWidth, physicalHeight uint32, scaleFactor float64) {
+		w.scaleFactor = scaleFactor
+		w.emit(windowing.ResizedEvent{
+			Width:       physicalWidth,
+			Height:      physicalHeight,
+			ScaleFactor: scaleFactor,
+		})
+	})
+	window.SetCloseRequestedCallback(func() {
+		w.emit(windowing.CloseRequestedEvent{})
+		d.Destroy()
+	})
+	window.SetKeyboardInputCallback(func(state events.ButtonState, scanCode events.ScanCode, virtualKeyCode events.VirtualKey) {
+		w.emit(windowing.KeyEvent{
+			Key:     mapKey(virtualKeyCode),
+			Pressed: state == events.ButtonStatePressed,
+		})
+	})
+	window.SetCursorMovedCallback(func(physicalX, physicalY float64) {
+		w.emit(windowing.CursorMovedEvent{X: physicalX, Y: physicalY})
+	})
+	window.SetMouseInputCallback(func(state events.ButtonState, button events.MouseButton) {
+		var b windowing.MouseButton
+		switch button {
+		case events.MouseButtonLeft:
+			b = windowing.MouseButtonLeft
+		case events.MouseButtonRight:
+			b = windowing.MouseButtonRight
+		case events.MouseButtonMiddle:
+			b = windowing.MouseButtonMiddle
+		default:
+			return
+		}
+		w.emit(windowing.MouseButtonEvent{Button: b, Pressed: state == events.ButtonStatePressed})
+	})
+	window.SetMouseScrollCallback(func(delta events.MouseScrollDelta, axis events.MouseScrollAxis, value float64) {
+		if axis == events.MouseScrollAxisHorizontal {
+			w.emit(windowing.MouseScrollEvent{DeltaX: value})
+		} else {
+			w.emit(windowing.MouseScrollEvent{DeltaY: value})
+		}
+	})
+
+	return w, nil
+}
+
+// GamenWindow returns the underlying gamen window.
+func (w *Window) GamenWindow() display.Window {
+	return w.window
+}
+
+func (w *Window) SurfaceDescriptor() *wgpu.SurfaceDescriptor {
+	if !w.hasSurface {
+		return nil
+	}
+	return SurfaceDescriptor(w.window)
+}
+
+func (w *Window) Size() (width, height uint32) {
+	size := w.window.InnerSize()
+	return size.Width, size.Height
+}
+
+func (w *Window) ScaleFactor() float64 {
+	return w.scaleFactor
+}
+
+func (w *Window) SetEventCallback(cb func(windowing.Event)) {
+	w.cb = cb
+}
+
+func (w *Window) Poll() bool {
+	return w.display.Poll()
+}
+
+func (w *Window) Destroy() {
+	if w.window != nil {
+		w.window.Destroy()
+		w.window = nil
+	}
+	if w.display != nil {
+		w.display.Destroy()
+		w.display = nil
+	}
+}
+
+func (w *Window) emit(e windowing.Event) {
+	if w.cb != nil {
+		w.cb(e)
+	}
+}
+
+func mapKey(key events.VirtualKey) windowing.Key {
+	switch {
+	case key >= events.VirtualKeyA && key <= events.VirtualKeyZ:
+		return windowing.KeyA + windowing.Key(key-events.VirtualKeyA)
+	case key >= events.VirtualKey0 && key <= events.VirtualKey9:
+		return windowing.Key0 + windowing.Key(key-events.VirtualKey0)
+	}
+
+	switch key {
+	case events.VirtualKeySpace:
+		return windowing.KeySpace
+	case events.VirtualKeyEscape:
+		return windowing.KeyEscape
+	case events.VirtualKeyReturn:
+		return windowing.KeyEnter
+	case events.VirtualKeyTab:
+		return windowing.KeyTab
+	case events.VirtualKeyBackSpace:
+		return windowing.KeyBackspace
+	case events.VirtualKeyLeft:
+		return windowing.KeyLeft
+	case events.VirtualKeyRight:
+		return windowing.KeyRight
+	case events.VirtualKeyUp:
+		return windowing.KeyUp
+	case events.VirtualKeyDown:
+		return windowing.KeyDown
+	case events.VirtualKeyLShift:
+		return windowing.KeyLeftShift
+	case events.VirtualKeyRShift:
+		return windowing.KeyRightShift
+	case events.VirtualKeyLControl:
+		return windowing.KeyLeftControl
+	case events.VirtualKeyRControl:
+		return windowing.KeyRightControl
+	}
+
+	return windowing.KeyUnknown
+}
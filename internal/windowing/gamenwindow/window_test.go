@@ -0,0 +1,41 @@
+//go:build (linux || windows) && !nowindow
+
+package gamenwindow
+
+import (
+	"testing"
+
+	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+)
+
+func TestMapKey(t *testing.T) {
+	for _, test := range []struct {
+		key  events.VirtualKey
+		want windowing.Key
+	}{
+		{events.VirtualKeyA, windowing.KeyA},
+		{events.VirtualKeyM, windowing.KeyM},
+		{events.VirtualKeyZ, windowing.KeyZ},
+		{events.VirtualKey0, windowing.Key0},
+		{events.VirtualKey9, windowing.Key9},
+		{events.VirtualKeySpace, windowing.KeySpace},
+		{events.VirtualKeyEscape, windowing.KeyEscape},
+		{events.VirtualKeyReturn, windowing.KeyEnter},
+		{events.VirtualKeyTab, windowing.KeyTab},
+		{events.VirtualKeyBackSpace, windowing.KeyBackspace},
+		{events.VirtualKeyLeft, windowing.KeyLeft},
+		{events.VirtualKeyRight, windowing.KeyRight},
+		{events.VirtualKeyUp, windowing.KeyUp},
+		{events.VirtualKeyDown, windowing.KeyDown},
+		{events.VirtualKeyLShift, windowing.KeyLeftShift},
+		{events.VirtualKeyRShift, windowing.KeyRightShift},
+		{events.VirtualKeyLControl, windowing.KeyLeftControl},
+		{events.VirtualKeyRControl, windowing.KeyRightControl},
+		{events.VirtualKeyF1, windowing.KeyUnknown},
+	} {
+		if got := mapKey(test.key); got != test.want {
+			t.Errorf("mapKey(%d) = %d, want %d", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package glfwwindow implements the "glfw" windowing backend using
+// go-glfw, which needs cgo on all platforms.
+package glfwwindow
@@ -1,6 +1,6 @@
-//go:build darwin
+//go:build darwin && !nowindow
 
-package main
+package glfwwindow
 
 import (
 	"unsafe"
@@ -29,7 +29,7 @@ CFTypeRef metalLayerFromNSWindow(CFTypeRef nsWindowRef) {
 */
 import "C"
 
-func getSurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
+func SurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
 	return &wgpu.SurfaceDescriptor{
 		MetalLayer: &wgpu.SurfaceDescriptorFromMetalLayer{
 			Layer: unsafe.Pointer(C.metalLayerFromNSWindow((C.CFTypeRef)(unsafe.Pointer(w.GetCocoaWindow())))),
@@ -1,6 +1,6 @@
-//go:build linux && !android && wayland
+//go:build linux && !android && wayland && !nowindow
 
-package main
+package glfwwindow
 
 import (
 	"unsafe"
@@ -9,7 +9,7 @@ import (
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-func getSurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
+func SurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
 	return &wgpu.SurfaceDescriptor{
 		WaylandSurface: &wgpu.SurfaceDescriptorFromWaylandSurface{
 			Display: unsafe.Pointer(glfw.GetWaylandDisplay()),
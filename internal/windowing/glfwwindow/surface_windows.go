@@ -1,4 +1,6 @@
-package main
+//go:build !nowindow
+
+package glfwwindow
 
 import (
 	"unsafe"
@@ -14,7 +16,7 @@ import (
 */
 import "C"
 
-func getSurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
+func SurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
 	return &wgpu.SurfaceDescriptor{
 		WindowsHWND: &wgpu.SurfaceDescriptorFromWindowsHWND{
 			Hwnd:      unsafe.Pointer(w.GetWin32Window()),
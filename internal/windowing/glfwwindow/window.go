@@ -0,0 +1,167 @@
+//go:build !android && !nowindow
+
+package glfwwindow
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+func init() {
+	windowing.Register("glfw", New)
+}
+
+type Window struct {
+	window *glfw.Window
+	cb     func(windowing.Event)
+}
+
+func New(opts windowing.Options) (windowing.Window, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, err
+	}
+
+	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
+	window, err := glfw.CreateWindow(opts.Width, opts.Height, opts.Title, nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, err
+	}
+
+	w := &Window{window: window}
+
+	window.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
+		w.emit(windowing.ResizedEvent{
+			Width:       uint32(width),
+			Height:      uint32(height),
+			ScaleFactor: w.ScaleFactor(),
+		})
+	})
+	window.SetContentScaleCallback(func(_ *glfw.Window, x, y float32) {
+		width, height := w.Size()
+		w.emit(windowing.ResizedEvent{
+			Width:       width,
+			Height:      height,
+			ScaleFactor: float64(x),
+		})
+	})
+	window.SetCloseCallback(func(_ *glfw.Window) {
+		w.emit(windowing.CloseRequestedEvent{})
+	})
+	window.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Repeat {
+			return
+		}
+		w.emit(windowing.KeyEvent{
+			Key:     mapKey(key),
+			Pressed: action == glfw.Press,
+		})
+	})
+	window.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
+		// glfw reports screen coordinates
+		scale := w.ScaleFactor()
+		w.emit(windowing.CursorMovedEvent{X: x * scale, Y: y * scale})
+	})
+	window.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		var b windowing.MouseButton
+		switch button {
+		case glfw.MouseButtonLeft:
+			b = windowing.MouseButtonLeft
+		case glfw.MouseButtonRight:
+			b = windowing.MouseButtonRight
+		case glfw.MouseButtonMiddle:
+			b = windowing.MouseButtonMiddle
+		default:
+			return
+		}
+		w.emit(windowing.MouseButtonEvent{Button: b, Pressed: action == glfw.Press})
+	})
+	window.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
+		w.emit(windowing.MouseScrollEvent{DeltaX: xoff, DeltaY: yoff})
+	})
+
+	return w, nil
+}
+
+// GLFWWindow returns the underlying glfw window.
+func (w *Window) GLFWWindow() *glfw.Window {
+	return w.window
+}
+
+func (w *Window) SurfaceDescriptor() *wgpu.SurfaceDescriptor {
+	return SurfaceDescriptor(w.window)
+}
+
+func (w *Window) Size() (width, height uint32) {
+	fbWidth, fbHeight := w.window.GetFramebufferSize()
+	return uint32(fbWidth), uint32(fbHeight)
+}
+
+func (w *Window) ScaleFactor() float64 {
+	x, _ := w.window.GetContentScale()
+	return float64(x)
+}
+
+func (w *Window) SetEventCallback(cb func(windowing.Event)) {
+	w.cb = cb
+}
+
+func (w *Window) Poll() bool {
+	glfw.PollEvents()
+	return !w.window.ShouldClose()
+}
+
+func (w *Window) Destroy() {
+	if w.window != nil {
+		w.window.Destroy()
+		w.window = nil
+		glfw.Terminate()
+	}
+}
+
+func (w *Window) emit(e windowing.Event) {
+	if w.cb != nil {
+		w.cb(e)
+	}
+}
+
+func mapKey(key glfw.Key) windowing.Key {
+	switch {
+	case key >= glfw.KeyA && key <= glfw.KeyZ:
+		return windowing.KeyA + windowing.Key(key-glfw.KeyA)
+	case key >= glfw.Key0 && key <= glfw.Key9:
+		return windowing.Key0 + windowing.Key(key-glfw.Key0)
+	}
+
+	switch key {
+	case glfw.KeySpace:
+		return windowing.KeySpace
+	case glfw.KeyEscape:
+		return windowing.KeyEscape
+	case glfw.KeyEnter:
+		return windowing.KeyEnter
+	case glfw.KeyTab:
+		return windowing.KeyTab
+	case glfw.KeyBackspace:
+		return windowing.KeyBackspace
+	case glfw.KeyLeft:
+		return windowing.KeyLeft
+	case glfw.KeyRight:
+		return windowing.KeyRight
+	case glfw.KeyUp:
+		return windowing.KeyUp
+	case glfw.KeyDown:
+		return windowing.KeyDown
+	case glfw.KeyLeftShift:
+		return windowing.KeyLeftShift
+	case glfw.KeyRightShift:
+		return windowing.KeyRightShift
+	case glfw.KeyLeftControl:
+		return windowing.KeyLeftControl
+	case glfw.KeyRightControl:
+		return windowing.KeyRightControl
+	}
+
+	return windowing.KeyUnknown
+}
@@ -0,0 +1,42 @@
+//go:build !android && !nowindow
+
+package glfwwindow
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+)
+
+func TestMapKey(t *testing.T) {
+	for _, test := range []struct {
+		key  glfw.Key
+		want windowing.Key
+	}{
+		{glfw.KeyA, windowing.KeyA},
+		{glfw.KeyM, windowing.KeyM},
+		{glfw.KeyZ, windowing.KeyZ},
+		{glfw.Key0, windowing.Key0},
+		{glfw.Key9, windowing.Key9},
+		{glfw.KeySpace, windowing.KeySpace},
+		{glfw.KeyEscape, windowing.KeyEscape},
+		{glfw.KeyEnter, windowing.KeyEnter},
+		{glfw.KeyTab, windowing.KeyTab},
+		{glfw.KeyBackspace, windowing.KeyBackspace},
+		{glfw.KeyLeft, windowing.KeyLeft},
+		{glfw.KeyRight, windowing.KeyRight},
+		{glfw.KeyUp, windowing.KeyUp},
+		{glfw.KeyDown, windowing.KeyDown},
+		{glfw.KeyLeftShift, windowing.KeyLeftShift},
+		{glfw.KeyRightShift, windowing.KeyRightShift},
+		{glfw.KeyLeftControl, windowing.KeyLeftControl},
+		{glfw.KeyRightControl, windowing.KeyRightControl},
+		{glfw.KeyF1, windowing.KeyUnknown},
+		{glfw.KeyUnknown, windowing.KeyUnknown},
+	} {
+		if got := mapKey(test.key); got != test.want {
+			t.Errorf("mapKey(%d) = %d, want %d", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package windowing
+
+import "github.com/rajveermalviya/go-webgpu/wgpu"
+
+func init() {
+	Register("headless", newHeadless)
+}
+
+// headless is a window without a surface, the caller is expected to render
+// into offscreen textures of Size.
+type headless struct {
+	width  uint32
+	height uint32
+	closed bool
+}
+
+func newHeadless(opts Options) (Window, error) {
+	return &headless{
+		width:  uint32(opts.Width),
+		height: uint32(opts.Height),
+	}, nil
+}
+
+func (w *headless) SurfaceDescriptor() *wgpu.SurfaceDescriptor { return nil }
+func (w *headless) Size() (width, height uint32)               { return w.width, w.height }
+func (w *headless) ScaleFactor() float64                       { return 1 }
+func (w *headless) SetEventCallback(cb func(Event))            {}
+func (w *headless) Poll() bool                                 { return !w.closed }
+func (w *headless) Destroy()                                   { w.closed = true }
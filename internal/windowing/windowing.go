@@ -0,0 +1,63 @@
+// Package windowing abstracts over the windowing toolkits the examples can
+// run on. Backends live in subpackages and register themselves on import.
+package windowing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+type Options struct {
+	Title  string
+	Width  int
+	Height int
+}
+
+type Window interface {
+	// SurfaceDescriptor returns nil while the window has nothing to render
+	// into, i.e. always for the headless backend and on android between
+	// SurfaceDestroyedEvent and SurfaceCreatedEvent.
+	SurfaceDescriptor() *wgpu.SurfaceDescriptor
+	// Size returns the size of the drawable area in physical pixels.
+	Size() (width, height uint32)
+	ScaleFactor() float64
+	SetEventCallback(cb func(Event))
+	// Poll dispatches all pending events and returns false once the window
+	// has been closed.
+	Poll() bool
+	Destroy()
+}
+
+type Backend func(opts Options) (Window, error)
+
+var backends = map[string]Backend{}
+
+// Register makes a backend available to New, it is meant to be called
+// from the init function of the backend package.
+func Register(name string, backend Backend) {
+	if _, ok := backends[name]; ok {
+		panic("windowing: backend registered twice: " + name)
+	}
+	backends[name] = backend
+}
+
+// Backends returns the names of all registered backends.
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func New(name string, opts Options) (Window, error) {
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("windowing: unknown backend %q, available: %s", name, strings.Join(Backends(), ", "))
+	}
+	return backend(opts)
+}
@@ -0,0 +1,101 @@
+package windowing
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeWindow is the Window of the fake backends.
+type fakeWindow struct {
+	headless
+	opts Options
+}
+
+// register registers a fake backend for the duration of the test.
+func register(t *testing.T, name string, backend Backend) {
+	t.Helper()
+	Register(name, backend)
+	t.Cleanup(func() { delete(backends, name) })
+}
+
+func TestRegister(t *testing.T) {
+	register(t, "fake", func(opts Options) (Window, error) {
+		return &fakeWindow{opts: opts}, nil
+	})
+	failure := errors.New("no display")
+	register(t, "broken", func(Options) (Window, error) {
+		return nil, failure
+	})
+
+	if got := Backends(); !slices.IsSorted(got) || !slices.Contains(got, "fake") || !slices.Contains(got, "headless") {
+		t.Errorf("Backends() = %v, want a sorted list with fake and headless", got)
+	}
+
+	opts := Options{Title: "test", Width: 320, Height: 240}
+	w, err := New("fake", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake, ok := w.(*fakeWindow); !ok || fake.opts != opts {
+		t.Errorf("New(fake) = %#v, want the fake window with %+v", w, opts)
+	}
+
+	if _, err := New("broken", opts); !errors.Is(err, failure) {
+		t.Errorf("New(broken): got %v, want the backend's error", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering fake twice didn't panic")
+		}
+	}()
+	Register("fake", func(Options) (Window, error) { return nil, nil })
+}
+
+func TestUnknownBackend(t *testing.T) {
+	_, err := New("nonexistent", Options{})
+	if err == nil {
+		t.Fatal("New(nonexistent) succeeded")
+	}
+	// the error lists the choices
+	for _, name := range append(Backends(), `"nonexistent"`) {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q doesn't mention %s", err, name)
+		}
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	w, err := New("headless", Options{Width: 640, Height: 480})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width, height := w.Size(); width != 640 || height != 480 {
+		t.Errorf("Size() = %d, %d, want 640, 480", width, height)
+	}
+	if w.SurfaceDescriptor() != nil {
+		t.Error("the headless window has a surface")
+	}
+	if w.ScaleFactor() != 1 {
+		t.Errorf("ScaleFactor() = %v, want 1", w.ScaleFactor())
+	}
+
+	// nothing happens to a headless window, the runner renders until it
+	// has enough frames and then destroys it
+	var events []Event
+	w.SetEventCallback(func(e Event) { events = append(events, e) })
+	for i := 0; i < 3; i++ {
+		if !w.Poll() {
+			t.Fatalf("Poll %d returned false before Destroy", i)
+		}
+	}
+	w.Destroy()
+	if w.Poll() {
+		t.Error("Poll returned true after Destroy")
+	}
+	if len(events) != 0 {
+		t.Errorf("the headless window delivered %v, want no events", events)
+	}
+}
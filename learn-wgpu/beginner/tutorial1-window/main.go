@@ -1,29 +1,33 @@
 package main
 
-import "github.com/rajveermalviya/gamen/display"
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
+
+	_ "github.com/rajveermalviya/go-webgpu-examples/internal/windowing/gamenwindow"
+	_ "github.com/rajveermalviya/go-webgpu-examples/internal/windowing/glfwwindow"
+)
+
+var windowFlag = flag.String("window", "gamen", "windowing backend, one of: "+strings.Join(windowing.Backends(), ", "))
 
 func main() {
-	d, err := display.NewDisplay()
-	if err != nil {
-		panic(err)
-	}
-	defer d.Destroy()
+	flag.Parse()
 
-	w, err := display.NewWindow(d)
+	w, err := windowing.New(*windowFlag, windowing.Options{
+		Title:  "tutorial1-window",
+		Width:  640,
+		Height: 480,
+	})
 	if err != nil {
 		panic(err)
 	}
 	defer w.Destroy()
 
-	w.SetCloseRequestedCallback(func() {
-		d.Destroy()
-	})
-
-	for {
-		if !d.Wait() {
-			break
-		}
-
-		// we will render here
+	for w.Poll() {
+		// we will render here, until then don't spin on the events
+		time.Sleep(time.Second / 60)
 	}
 }
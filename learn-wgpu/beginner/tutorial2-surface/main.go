@@ -1,97 +1,25 @@
 package main
 
 import (
-	"fmt"
-	"strings"
+	"time"
 
-	"github.com/rajveermalviya/gamen/display"
-	"github.com/rajveermalviya/gamen/dpi"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-type State struct {
-	surface   *wgpu.Surface
-	swapChain *wgpu.SwapChain
-	device    *wgpu.Device
-	queue     *wgpu.Queue
-	config    *wgpu.SwapChainDescriptor
-	size      dpi.PhysicalSize[uint32]
-}
-
-func InitState(window display.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	s.size = window.InnerSize()
-
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
-
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adaper, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		CompatibleSurface: s.surface,
-	})
-	if err != nil {
-		return s, err
-	}
-	defer adaper.Drop()
-
-	s.device, err = adaper.RequestDevice(nil)
-	if err != nil {
-		return s, err
-	}
-	s.queue = s.device.GetQueue()
-
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adaper),
-		Width:       s.size.Width,
-		Height:      s.size.Height,
-		PresentMode: wgpu.PresentMode_Fifo,
-	}
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-	if err != nil {
-		return s, err
-	}
+// State only clears the surface, the framework owns the device and the
+// swap chain.
+type State struct{}
 
-	return s, nil
-}
-
-func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
-	if newSize.Width > 0 && newSize.Height > 0 {
-		s.size = newSize
-		s.config.Width = newSize.Width
-		s.config.Height = newSize.Height
-
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-	}
+func (s *State) Init(ctx *framework.Context) error {
+	return nil
 }
 
-func (s *State) Render() error {
-	view, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer view.Drop()
+func (s *State) Update(dt time.Duration) {}
 
-	encoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
-	}
+func (s *State) Resize(width, height uint32) {}
 
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
 			View:   view,
@@ -106,83 +34,16 @@ func (s *State) Render() error {
 		}},
 	})
 	renderPass.End()
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
-func (s *State) Destroy() {
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
-}
+func (s *State) Destroy() {}
 
 func main() {
-	d, err := display.NewDisplay()
-	if err != nil {
-		panic(err)
-	}
-	defer d.Destroy()
-
-	w, err := display.NewWindow(d)
-	if err != nil {
-		panic(err)
-	}
-	defer w.Destroy()
-
-	s, err := InitState(w)
+	err := framework.Run(&State{}, framework.Options{
+		Title:  "tutorial2-surface",
+		Window: "gamen",
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer s.Destroy()
-
-	w.SetResizedCallback(func(physicalWidth, physicalHeight uint32, scaleFactor float64) {
-		s.Resize(dpi.PhysicalSize[uint32]{
-			Width:  physicalWidth,
-			Height: physicalHeight,
-		})
-	})
-
-	w.SetCloseRequestedCallback(func() {
-		d.Destroy()
-	})
-
-	for {
-		if !d.Poll() {
-			break
-		}
-
-		err := s.Render()
-		if err != nil {
-			errstr := err.Error()
-			fmt.Println(errstr)
-
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }
@@ -2,12 +2,12 @@ package main
 
 import (
 	_ "embed"
-	"fmt"
-	"strings"
+	"time"
 
-	"github.com/rajveermalviya/gamen/display"
-	"github.com/rajveermalviya/gamen/dpi"
-	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -18,184 +18,86 @@ var shaderCode string
 var challengeShaderCode string
 
 type State struct {
-	surface        *wgpu.Surface
-	swapChain      *wgpu.SwapChain
-	device         *wgpu.Device
-	queue          *wgpu.Queue
-	config         *wgpu.SwapChainDescriptor
-	size           dpi.PhysicalSize[uint32]
-	renderPipeline *wgpu.RenderPipeline
+	shader         *resource.Handle[*wgpu.ShaderModule]
+	renderPipeline *resource.Handle[*wgpu.RenderPipeline]
 
-	challengeRenderPipeline *wgpu.RenderPipeline
+	challengeShader         *resource.Handle[*wgpu.ShaderModule]
+	challengeRenderPipeline *resource.Handle[*wgpu.RenderPipeline]
 	useColor                bool
 }
 
-func InitState(window display.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	s.size = window.InnerSize()
-
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
-
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adaper, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		CompatibleSurface: s.surface,
-	})
-	if err != nil {
-		return s, err
-	}
-	defer adaper.Drop()
-
-	s.device, err = adaper.RequestDevice(nil)
-	if err != nil {
-		return s, err
-	}
-	s.queue = s.device.GetQueue()
-
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adaper),
-		Width:       s.size.Width,
-		Height:      s.size.Height,
-		PresentMode: wgpu.PresentMode_Fifo,
-	}
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-	if err != nil {
-		return s, err
-	}
-
-	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: shaderCode,
-		},
-	})
-	if err != nil {
-		return s, err
-	}
-	defer shader.Drop()
-
-	renderPipelineLayout, err := s.device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
-		Label: "Render Pipeline Layout",
-	})
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.shader, err = ctx.Shaders.ShaderModule("shader.wgsl", shaderCode)
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer renderPipelineLayout.Drop()
-
-	s.renderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "Render Pipeline",
-		Layout: renderPipelineLayout,
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{{
-				Format:    s.config.Format,
-				Blend:     &wgpu.BlendState_Replace,
-				WriteMask: wgpu.ColorWriteMask_All,
-			}},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
-	})
+	s.renderPipeline, err = createPipeline(ctx, s.shader)
 	if err != nil {
-		return s, err
-	}
-
-	challengeShader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "challenge.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: challengeShaderCode,
-		},
-	})
-	if err != nil {
-		return s, err
+		return err
 	}
-	defer challengeShader.Drop()
 
-	s.challengeRenderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "Render Pipeline",
-		Layout: renderPipelineLayout,
-		Vertex: wgpu.VertexState{
-			Module:     challengeShader,
-			EntryPoint: "vs_main",
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     challengeShader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{{
-				Format:    s.config.Format,
-				Blend:     &wgpu.BlendState_Replace,
-				WriteMask: wgpu.ColorWriteMask_All,
-			}},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
-	})
+	s.challengeShader, err = ctx.Shaders.ShaderModule("challenge.wgsl", challengeShaderCode)
 	if err != nil {
-		return s, err
+		return err
 	}
-
-	return s, nil
+	s.challengeRenderPipeline, err = createPipeline(ctx, s.challengeShader)
+	return err
 }
 
-func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
-	if newSize.Width > 0 && newSize.Height > 0 {
-		s.size = newSize
-		s.config.Width = newSize.Width
-		s.config.Height = newSize.Height
-
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
+// createPipeline creates a pipeline drawing with shader through
+// ctx.Resources.
+func createPipeline(ctx *framework.Context, shader *resource.Handle[*wgpu.ShaderModule]) (*resource.Handle[*wgpu.RenderPipeline], error) {
+	format := ctx.Config.Format
+	return resource.Track(ctx.Resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		renderPipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
+			Label: "Render Pipeline Layout",
+		})
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-	}
+		defer leak.Track(ctx.Resources.Leaks, renderPipelineLayout).Drop()
+
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label:  "Render Pipeline",
+			Layout: renderPipelineLayout,
+			Vertex: wgpu.VertexState{
+				Module:     shader.Get(),
+				EntryPoint: "vs_main",
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{{
+					Format:    format,
+					Blend:     &wgpu.BlendState_Replace,
+					WriteMask: wgpu.ColorWriteMask_All,
+				}},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_Back,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
+	})
 }
 
-func (s *State) Render() error {
-	view, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
+func (s *State) Event(e windowing.Event) {
+	if e, ok := e.(windowing.KeyEvent); ok && e.Key == windowing.KeySpace {
+		s.useColor = !e.Pressed
 	}
-	defer view.Drop()
+}
 
-	encoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
-	}
+func (s *State) Update(dt time.Duration) {}
+
+func (s *State) Resize(width, height uint32) {}
 
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
 			View:   view,
@@ -210,103 +112,27 @@ func (s *State) Render() error {
 		}},
 	})
 	if s.useColor {
-		renderPass.SetPipeline(s.renderPipeline)
+		renderPass.SetPipeline(s.renderPipeline.Get())
 	} else {
-		renderPass.SetPipeline(s.challengeRenderPipeline)
+		renderPass.SetPipeline(s.challengeRenderPipeline.Get())
 	}
 	renderPass.Draw(3, 1, 0, 0)
 	renderPass.End()
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
 func (s *State) Destroy() {
-	if s.challengeRenderPipeline != nil {
-		s.challengeRenderPipeline.Drop()
-		s.challengeRenderPipeline = nil
-	}
-	if s.renderPipeline != nil {
-		s.renderPipeline.Drop()
-		s.renderPipeline = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
+	s.challengeRenderPipeline.Drop()
+	s.challengeShader.Drop()
+	s.renderPipeline.Drop()
+	s.shader.Drop()
 }
 
 func main() {
-	d, err := display.NewDisplay()
-	if err != nil {
-		panic(err)
-	}
-	defer d.Destroy()
-
-	w, err := display.NewWindow(d)
-	if err != nil {
-		panic(err)
-	}
-	defer w.Destroy()
-
-	s, err := InitState(w)
+	err := framework.Run(&State{}, framework.Options{
+		Title:  "tutorial3-challenge",
+		Window: "gamen",
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer s.Destroy()
-
-	w.SetKeyboardInputCallback(func(state events.ButtonState, scanCode events.ScanCode, virtualKeyCode events.VirtualKey) {
-		if virtualKeyCode == events.VirtualKeySpace {
-			s.useColor = state == events.ButtonStateReleased
-		}
-	})
-
-	w.SetResizedCallback(func(physicalWidth, physicalHeight uint32, scaleFactor float64) {
-		s.Resize(dpi.PhysicalSize[uint32]{
-			Width:  physicalWidth,
-			Height: physicalHeight,
-		})
-	})
-
-	w.SetCloseRequestedCallback(func() {
-		d.Destroy()
-	})
-
-	for {
-		if !d.Poll() {
-			break
-		}
-
-		err := s.Render()
-		if err != nil {
-			errstr := err.Error()
-			fmt.Println(errstr)
-
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }
@@ -2,12 +2,12 @@ package main
 
 import (
 	_ "embed"
-	"fmt"
-	"strings"
+	"time"
 	"unsafe"
 
-	"github.com/rajveermalviya/gamen/display"
-	"github.com/rajveermalviya/gamen/dpi"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -65,69 +65,23 @@ var VERTICES = [...]Vertex{
 var INDICES = [...]uint16{0, 1, 4, 1, 2, 4, 2, 3, 4}
 
 type State struct {
-	surface        *wgpu.Surface
-	swapChain      *wgpu.SwapChain
-	device         *wgpu.Device
-	queue          *wgpu.Queue
-	config         *wgpu.SwapChainDescriptor
-	size           dpi.PhysicalSize[uint32]
-	renderPipeline *wgpu.RenderPipeline
-	vertexBuffer   *wgpu.Buffer
-	indexBuffer    *wgpu.Buffer
-	numIndices     uint32
-
-	diffuseTexture   *Texture
-	diffuseBindGroup *wgpu.BindGroup
+	shader                 *resource.Handle[*wgpu.ShaderModule]
+	textureBindGroupLayout *resource.Handle[*wgpu.BindGroupLayout]
+	renderPipeline         *resource.Handle[*wgpu.RenderPipeline]
+	vertexBuffer           *resource.Handle[*wgpu.Buffer]
+	indexBuffer            *resource.Handle[*wgpu.Buffer]
+	numIndices             uint32
+	diffuseTexture         *Texture
+	diffuseBindGroup       *resource.Handle[*wgpu.BindGroup]
 }
 
-func InitState(window display.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	s.size = window.InnerSize()
-
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
-
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adaper, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		CompatibleSurface: s.surface,
-	})
-	if err != nil {
-		return s, err
-	}
-	defer adaper.Drop()
-
-	s.device, err = adaper.RequestDevice(nil)
-	if err != nil {
-		return s, err
-	}
-	s.queue = s.device.GetQueue()
-
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adaper),
-		Width:       s.size.Width,
-		Height:      s.size.Height,
-		PresentMode: wgpu.PresentMode_Fifo,
-	}
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.diffuseTexture, err = TextureFromPNGBytes(ctx.Resources, happyTreePng, "happy-tree.png")
 	if err != nil {
-		return s, err
-	}
-
-	s.diffuseTexture, err = TextureFromPNGBytes(s.device, s.queue, happyTreePng, "happy-tree.png")
-	if err != nil {
-		return s, err
+		return err
 	}
 
-	textureBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+	s.textureBindGroupLayout, err = ctx.Resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
 		Entries: []wgpu.BindGroupLayoutEntry{
 			{
 				Binding:    0,
@@ -149,133 +103,107 @@ func InitState(window display.Window) (s *State, err error) {
 		Label: "TextureBindGroupLayout",
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer textureBindGroupLayout.Drop()
 
-	s.diffuseBindGroup, err = s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Layout: textureBindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding:     0,
-				TextureView: s.diffuseTexture.view,
-			},
-			{
-				Binding: 1,
-				Sampler: s.diffuseTexture.sampler,
+	s.diffuseBindGroup, err = resource.Track(ctx.Resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout: s.textureBindGroupLayout.Get(),
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding:     0,
+					TextureView: s.diffuseTexture.view.Get(),
+				},
+				{
+					Binding: 1,
+					Sampler: s.diffuseTexture.sampler.Get(),
+				},
 			},
-		},
-		Label: "DiffuseBindGroup",
+			Label: "DiffuseBindGroup",
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: shaderCode,
-		},
-	})
+	s.shader, err = ctx.Shaders.ShaderModule("shader.wgsl", shaderCode)
 	if err != nil {
-		return s, err
-	}
-	defer shader.Drop()
-
-	renderPipelineLayout, err := s.device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
-		Label: "Render Pipeline Layout",
-		BindGroupLayouts: []*wgpu.BindGroupLayout{
-			textureBindGroupLayout,
-		},
-	})
-	if err != nil {
-		return s, err
+		return err
 	}
-	defer renderPipelineLayout.Drop()
 
-	s.renderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "Render Pipeline",
-		Layout: renderPipelineLayout,
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-			Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout},
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{{
-				Format:    s.config.Format,
-				Blend:     &wgpu.BlendState_Replace,
-				WriteMask: wgpu.ColorWriteMask_All,
-			}},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
+	format := ctx.Config.Format
+	s.renderPipeline, err = resource.Track(ctx.Resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		renderPipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
+			Label: "Render Pipeline Layout",
+			BindGroupLayouts: []*wgpu.BindGroupLayout{
+				s.textureBindGroupLayout.Get(),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer leak.Track(ctx.Resources.Leaks, renderPipelineLayout).Drop()
+
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label:  "Render Pipeline",
+			Layout: renderPipelineLayout,
+			Vertex: wgpu.VertexState{
+				Module:     s.shader.Get(),
+				EntryPoint: "vs_main",
+				Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout},
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     s.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{{
+					Format:    format,
+					Blend:     &wgpu.BlendState_Replace,
+					WriteMask: wgpu.ColorWriteMask_All,
+				}},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_Back,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.vertexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.vertexBuffer, err = ctx.Resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
 		Contents: wgpu.ToBytes(VERTICES[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.indexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.indexBuffer, err = ctx.Resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Index Buffer",
 		Contents: wgpu.ToBytes(INDICES[:]),
 		Usage:    wgpu.BufferUsage_Index,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 	s.numIndices = uint32(len(INDICES))
 
-	return s, nil
+	return nil
 }
 
-func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
-	if newSize.Width > 0 && newSize.Height > 0 {
-		s.size = newSize
-		s.config.Width = newSize.Width
-		s.config.Height = newSize.Height
+func (s *State) Update(dt time.Duration) {}
 
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-	}
-}
-
-func (s *State) Render() error {
-	view, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer view.Drop()
-
-	encoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
-	}
+func (s *State) Resize(width, height uint32) {}
 
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
 			View:   view,
@@ -289,111 +217,30 @@ func (s *State) Render() error {
 			StoreOp: wgpu.StoreOp_Store,
 		}},
 	})
-	renderPass.SetPipeline(s.renderPipeline)
-	renderPass.SetBindGroup(0, s.diffuseBindGroup, nil)
-	renderPass.SetVertexBuffer(0, s.vertexBuffer, 0, wgpu.WholeSize)
-	renderPass.SetIndexBuffer(s.indexBuffer, wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
+	renderPass.SetPipeline(s.renderPipeline.Get())
+	renderPass.SetBindGroup(0, s.diffuseBindGroup.Get(), nil)
+	renderPass.SetVertexBuffer(0, s.vertexBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetIndexBuffer(s.indexBuffer.Get(), wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
 	renderPass.DrawIndexed(s.numIndices, 1, 0, 0, 0)
 	renderPass.End()
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
 func (s *State) Destroy() {
-	if s.indexBuffer != nil {
-		s.indexBuffer.Drop()
-		s.indexBuffer = nil
-	}
-	if s.vertexBuffer != nil {
-		s.vertexBuffer.Drop()
-		s.vertexBuffer = nil
-	}
-	if s.renderPipeline != nil {
-		s.renderPipeline.Drop()
-		s.renderPipeline = nil
-	}
-	if s.diffuseBindGroup != nil {
-		s.diffuseBindGroup.Drop()
-		s.diffuseBindGroup = nil
-	}
-	if s.diffuseTexture != nil {
-		s.diffuseTexture.Destroy()
-		s.diffuseTexture = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
+	s.diffuseBindGroup.Drop()
+	s.diffuseTexture.Destroy()
+	s.indexBuffer.Drop()
+	s.vertexBuffer.Drop()
+	s.renderPipeline.Drop()
+	s.shader.Drop()
+	s.textureBindGroupLayout.Drop()
 }
 
 func main() {
-	wgpu.SetLogLevel(wgpu.LogLevel_Trace)
-
-	d, err := display.NewDisplay()
-	if err != nil {
-		panic(err)
-	}
-	defer d.Destroy()
-
-	w, err := display.NewWindow(d)
-	if err != nil {
-		panic(err)
-	}
-	defer w.Destroy()
-
-	s, err := InitState(w)
+	err := framework.Run(&State{}, framework.Options{
+		Title:  "tutorial5-textures",
+		Window: "gamen",
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer s.Destroy()
-
-	w.SetResizedCallback(func(physicalWidth, physicalHeight uint32, scaleFactor float64) {
-		s.Resize(dpi.PhysicalSize[uint32]{
-			Width:  physicalWidth,
-			Height: physicalHeight,
-		})
-	})
-
-	w.SetCloseRequestedCallback(func() {
-		d.Destroy()
-	})
-
-	for {
-		if !d.Poll() {
-			break
-		}
-
-		err := s.Render()
-		if err != nil {
-			errstr := err.Error()
-			fmt.Println(errstr)
-
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }
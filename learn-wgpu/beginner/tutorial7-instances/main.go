@@ -2,14 +2,16 @@ package main
 
 import (
 	_ "embed"
-	"fmt"
-	"strings"
+	"time"
 	"unsafe"
 
-	"github.com/rajveermalviya/gamen/display"
-	"github.com/rajveermalviya/gamen/dpi"
-	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/uniform"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -117,6 +119,7 @@ func (c *CameraUniform) UpdateViewProj(camera *Camera) {
 }
 
 type CameraController struct {
+	// speed is in units per second
 	speed             float32
 	isUpPressed       bool
 	isDownPressed     bool
@@ -130,23 +133,25 @@ func NewCameraController(speed float32) *CameraController {
 	return &CameraController{speed: speed}
 }
 
-func (c *CameraController) UpdateCamera(camera *Camera) {
+func (c *CameraController) UpdateCamera(camera *Camera, dt time.Duration) {
+	step := c.speed * float32(dt.Seconds())
+
 	forward := camera.target.Sub(camera.eye).Normalize()
 
 	if c.isForwardPressed {
-		camera.eye = camera.eye.Add(forward.MulScalar(c.speed))
+		camera.eye = camera.eye.Add(forward.MulScalar(step))
 	}
 	if c.isBackwardPressed {
-		camera.eye = camera.eye.Sub(forward.MulScalar(c.speed))
+		camera.eye = camera.eye.Sub(forward.MulScalar(step))
 	}
 
 	right := forward.Cross(camera.up)
 
 	if c.isRightPressed {
-		camera.eye = camera.eye.Add(right.MulScalar(c.speed))
+		camera.eye = camera.eye.Add(right.MulScalar(step))
 	}
 	if c.isLeftPressed {
-		camera.eye = camera.eye.Sub(right.MulScalar(c.speed))
+		camera.eye = camera.eye.Sub(right.MulScalar(step))
 	}
 }
 
@@ -193,139 +198,97 @@ var InstanceBufferLayout = wgpu.VertexBufferLayout{
 }
 
 type State struct {
-	surface          *wgpu.Surface
-	swapChain        *wgpu.SwapChain
-	device           *wgpu.Device
-	queue            *wgpu.Queue
-	config           *wgpu.SwapChainDescriptor
-	size             dpi.PhysicalSize[uint32]
-	renderPipeline   *wgpu.RenderPipeline
-	vertexBuffer     *wgpu.Buffer
-	indexBuffer      *wgpu.Buffer
-	numIndices       uint32
-	diffuseTexture   *Texture
-	diffuseBindGroup *wgpu.BindGroup
+	shader                 *resource.Handle[*wgpu.ShaderModule]
+	textureBindGroupLayout *resource.Handle[*wgpu.BindGroupLayout]
+	cameraBindGroupLayout  *resource.Handle[*wgpu.BindGroupLayout]
+	resources              *resource.Registry
+	renderPipeline         *resource.Handle[*wgpu.RenderPipeline]
+	vertexBuffer           *resource.Handle[*wgpu.Buffer]
+	indexBuffer            *resource.Handle[*wgpu.Buffer]
+	numIndices             uint32
+	diffuseTexture         *Texture
+	diffuseBindGroup       *resource.Handle[*wgpu.BindGroup]
+
 	camera           *Camera
 	cameraController *CameraController
-	cameraUniform    *CameraUniform
-	cameraBuffer     *wgpu.Buffer
-	cameraBindGroup  *wgpu.BindGroup
+	cameraUniform    *uniform.Buffer[CameraUniform]
+	cameraBindGroup  *resource.Handle[*wgpu.BindGroup]
 
 	numInstances   uint32
-	instanceBuffer *wgpu.Buffer
+	instanceBuffer *resource.Handle[*wgpu.Buffer]
 }
 
-func InitState(window display.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	s.size = window.InnerSize()
-
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.resources = ctx.Resources
 
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adaper, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		CompatibleSurface: s.surface,
-	})
+	s.diffuseTexture, err = TextureFromPNGBytes(s.resources, happyTreePng, "happy-tree.png")
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer adaper.Drop()
 
-	s.device, err = adaper.RequestDevice(nil)
+	// the bind group layouts are derived from the shader, and the vertex
+	// buffer layouts checked against it
+	shaderModule, err := wgsl.Reflect(shaderCode)
 	if err != nil {
-		return s, err
+		return err
 	}
-	s.queue = s.device.GetQueue()
-
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adaper),
-		Width:       s.size.Width,
-		Height:      s.size.Height,
-		PresentMode: wgpu.PresentMode_Fifo,
+	err = shaderModule.ValidateVertexBuffers("vs_main", []wgpu.VertexBufferLayout{VertexBufferLayout, InstanceBufferLayout})
+	if err != nil {
+		return err
 	}
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
+	textureLayoutEntries, err := shaderModule.BindGroupLayoutEntries(0)
 	if err != nil {
-		return s, err
+		return err
 	}
-
-	s.diffuseTexture, err = TextureFromPNGBytes(s.device, s.queue, happyTreePng, "happy-tree.png")
+	cameraLayoutEntries, err := shaderModule.BindGroupLayoutEntries(1)
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	textureBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
-		Entries: []wgpu.BindGroupLayoutEntry{
-			{
-				Binding:    0,
-				Visibility: wgpu.ShaderStage_Fragment,
-				Texture: wgpu.TextureBindingLayout{
-					Multisampled:  false,
-					ViewDimension: wgpu.TextureViewDimension_2D,
-					SampleType:    wgpu.TextureSampleType_Float,
-				},
-			},
-			{
-				Binding:    1,
-				Visibility: wgpu.ShaderStage_Fragment,
-				Sampler: wgpu.SamplerBindingLayout{
-					Type: wgpu.SamplerBindingType_Filtering,
-				},
-			},
-		},
-		Label: "TextureBindGroupLayout",
+	s.textureBindGroupLayout, err = s.resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Entries: textureLayoutEntries,
+		Label:   "TextureBindGroupLayout",
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer textureBindGroupLayout.Drop()
-
-	s.diffuseBindGroup, err = s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Layout: textureBindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding:     0,
-				TextureView: s.diffuseTexture.view,
-			},
-			{
-				Binding: 1,
-				Sampler: s.diffuseTexture.sampler,
+
+	s.diffuseBindGroup, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout: s.textureBindGroupLayout.Get(),
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding:     0,
+					TextureView: s.diffuseTexture.view.Get(),
+				},
+				{
+					Binding: 1,
+					Sampler: s.diffuseTexture.sampler.Get(),
+				},
 			},
-		},
-		Label: "DiffuseBindGroup",
+			Label: "DiffuseBindGroup",
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	s.camera = &Camera{
 		eye:     glm.Vec3[float32]{0, 5, 10},
 		target:  glm.Vec3[float32]{0, 0, 0},
 		up:      glm.Vec3[float32]{0, 1, 0},
-		aspect:  float32(s.size.Width) / float32(s.size.Height),
+		aspect:  float32(ctx.Config.Width) / float32(ctx.Config.Height),
 		fovYRad: glm.DegToRad[float32](45),
 		znear:   0.1,
 		zfar:    100.0,
 	}
-	s.cameraController = NewCameraController(0.2)
-	s.cameraUniform = NewCameraUnifrom()
-	s.cameraUniform.UpdateViewProj(s.camera)
-
-	s.cameraBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
-		Label:    "Camera Buffer",
-		Contents: wgpu.ToBytes(s.cameraUniform.viewProj[:]),
-		Usage:    wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
-	})
+	s.cameraController = NewCameraController(12)
+	cameraUniform := NewCameraUnifrom()
+	cameraUniform.UpdateViewProj(s.camera)
+
+	s.cameraUniform, err = uniform.New(s.resources, "Camera Buffer", *cameraUniform)
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	var instances [NumInstancesPerRow * NumInstancesPerRow]Instance
@@ -356,158 +319,146 @@ func InitState(window display.Window) (s *State, err error) {
 	for i, v := range instances {
 		instanceData[i] = v.ToRaw()
 	}
-	s.instanceBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.instanceBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Instance Buffer",
 		Contents: wgpu.ToBytes(instanceData[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	cameraBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
-		Label: "CameraBindGroupLayout",
-		Entries: []wgpu.BindGroupLayoutEntry{{
-			Binding:    0,
-			Visibility: wgpu.ShaderStage_Vertex,
-			Buffer: wgpu.BufferBindingLayout{
-				Type:             wgpu.BufferBindingType_Uniform,
-				HasDynamicOffset: false,
-				MinBindingSize:   wgpu.WholeSize,
-			},
-		}},
-	})
-	if err != nil {
-		return s, err
-	}
-	defer cameraBindGroupLayout.Drop()
-
-	s.cameraBindGroup, err = s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Label:  "CameraBindGroup",
-		Layout: cameraBindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{{
-			Binding: 0,
-			Buffer:  s.cameraBuffer,
-			Size:    wgpu.WholeSize,
-		}},
+	s.cameraBindGroupLayout, err = s.resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Label:   "CameraBindGroupLayout",
+		Entries: cameraLayoutEntries,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: shaderCode,
-		},
+	s.cameraBindGroup, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Label:  "CameraBindGroup",
+			Layout: s.cameraBindGroupLayout.Get(),
+			Entries: []wgpu.BindGroupEntry{{
+				Binding: 0,
+				Buffer:  s.cameraUniform.Buffer(),
+				Size:    wgpu.WholeSize,
+			}},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer shader.Drop()
 
-	renderPipelineLayout, err := s.device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
-		Label: "Render Pipeline Layout",
-		BindGroupLayouts: []*wgpu.BindGroupLayout{
-			textureBindGroupLayout, cameraBindGroupLayout,
-		},
-	})
+	s.shader, err = ctx.Shaders.ShaderModule("shader.wgsl", shaderCode)
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer renderPipelineLayout.Drop()
-
-	s.renderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "Render Pipeline",
-		Layout: renderPipelineLayout,
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-			Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout, InstanceBufferLayout},
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{{
-				Format:    s.config.Format,
-				Blend:     &wgpu.BlendState_Replace,
-				WriteMask: wgpu.ColorWriteMask_All,
-			}},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
+
+	format := ctx.Config.Format
+	s.renderPipeline, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		renderPipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
+			Label: "Render Pipeline Layout",
+			BindGroupLayouts: []*wgpu.BindGroupLayout{
+				s.textureBindGroupLayout.Get(), s.cameraBindGroupLayout.Get(),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer leak.Track(s.resources.Leaks, renderPipelineLayout).Drop()
+
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label:  "Render Pipeline",
+			Layout: renderPipelineLayout,
+			Vertex: wgpu.VertexState{
+				Module:     s.shader.Get(),
+				EntryPoint: "vs_main",
+				Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout, InstanceBufferLayout},
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     s.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{{
+					Format:    format,
+					Blend:     &wgpu.BlendState_Replace,
+					WriteMask: wgpu.ColorWriteMask_All,
+				}},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_Back,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.vertexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.vertexBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
 		Contents: wgpu.ToBytes(VERTICES[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.indexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.indexBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Index Buffer",
 		Contents: wgpu.ToBytes(INDICES[:]),
 		Usage:    wgpu.BufferUsage_Index,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 	s.numIndices = uint32(len(INDICES))
 
-	return s, nil
-}
-
-func (s *State) Update() {
-	s.cameraController.UpdateCamera(s.camera)
-	s.cameraUniform.UpdateViewProj(s.camera)
-	s.queue.WriteBuffer(s.cameraBuffer, 0, wgpu.ToBytes(s.cameraUniform.viewProj[:]))
+	return nil
 }
 
-func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
-	if newSize.Width > 0 && newSize.Height > 0 {
-		s.size = newSize
-		s.config.Width = newSize.Width
-		s.config.Height = newSize.Height
-
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-
-		s.camera.aspect = float32(newSize.Width) / float32(newSize.Height)
+func (s *State) Event(e windowing.Event) {
+	key, ok := e.(windowing.KeyEvent)
+	if !ok {
+		return
+	}
+
+	switch key.Key {
+	case windowing.KeySpace:
+		s.cameraController.isUpPressed = key.Pressed
+	case windowing.KeyLeftShift:
+		s.cameraController.isDownPressed = key.Pressed
+	case windowing.KeyW, windowing.KeyUp:
+		s.cameraController.isForwardPressed = key.Pressed
+	case windowing.KeyA, windowing.KeyLeft:
+		s.cameraController.isLeftPressed = key.Pressed
+	case windowing.KeyS, windowing.KeyDown:
+		s.cameraController.isBackwardPressed = key.Pressed
+	case windowing.KeyD, windowing.KeyRight:
+		s.cameraController.isRightPressed = key.Pressed
 	}
 }
 
-func (s *State) Render() error {
-	view, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer view.Drop()
+func (s *State) Update(dt time.Duration) {
+	s.cameraController.UpdateCamera(s.camera, dt)
+	cameraUniform := s.cameraUniform.Get()
+	cameraUniform.UpdateViewProj(s.camera)
+	s.cameraUniform.Set(cameraUniform)
+	s.cameraUniform.Upload(s.resources.Queue())
+}
 
-	encoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
-	}
+func (s *State) Resize(width, height uint32) {
+	s.camera.aspect = float32(width) / float32(height)
+}
 
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
 			View:   view,
@@ -521,152 +472,36 @@ func (s *State) Render() error {
 			StoreOp: wgpu.StoreOp_Store,
 		}},
 	})
-	renderPass.SetPipeline(s.renderPipeline)
-	renderPass.SetBindGroup(0, s.diffuseBindGroup, nil)
-	renderPass.SetBindGroup(1, s.cameraBindGroup, nil)
-	renderPass.SetVertexBuffer(0, s.vertexBuffer, 0, wgpu.WholeSize)
-	renderPass.SetVertexBuffer(1, s.instanceBuffer, 0, wgpu.WholeSize)
-	renderPass.SetIndexBuffer(s.indexBuffer, wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
+	renderPass.SetPipeline(s.renderPipeline.Get())
+	renderPass.SetBindGroup(0, s.diffuseBindGroup.Get(), nil)
+	renderPass.SetBindGroup(1, s.cameraBindGroup.Get(), nil)
+	renderPass.SetVertexBuffer(0, s.vertexBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetVertexBuffer(1, s.instanceBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetIndexBuffer(s.indexBuffer.Get(), wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
 	renderPass.DrawIndexed(s.numIndices, s.numInstances, 0, 0, 0)
 	renderPass.End()
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
 func (s *State) Destroy() {
-	if s.indexBuffer != nil {
-		s.indexBuffer.Drop()
-		s.indexBuffer = nil
-	}
-	if s.vertexBuffer != nil {
-		s.vertexBuffer.Drop()
-		s.vertexBuffer = nil
-	}
-	if s.renderPipeline != nil {
-		s.renderPipeline.Drop()
-		s.renderPipeline = nil
-	}
-	if s.cameraBindGroup != nil {
-		s.cameraBindGroup.Drop()
-		s.cameraBindGroup = nil
-	}
-	if s.instanceBuffer != nil {
-		s.instanceBuffer.Drop()
-		s.instanceBuffer = nil
-	}
-	if s.cameraBuffer != nil {
-		s.cameraBuffer.Drop()
-		s.cameraBuffer = nil
-	}
-	if s.cameraUniform != nil {
-		s.cameraUniform = nil
-	}
-	if s.cameraController != nil {
-		s.cameraController = nil
-	}
-	if s.camera != nil {
-		s.camera = nil
-	}
-	if s.diffuseBindGroup != nil {
-		s.diffuseBindGroup.Drop()
-		s.diffuseBindGroup = nil
-	}
-	if s.diffuseTexture != nil {
-		s.diffuseTexture.Destroy()
-		s.diffuseTexture = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
+	s.instanceBuffer.Drop()
+	s.cameraBindGroup.Drop()
+	s.cameraUniform.Drop()
+	s.diffuseBindGroup.Drop()
+	s.diffuseTexture.Destroy()
+	s.indexBuffer.Drop()
+	s.vertexBuffer.Drop()
+	s.renderPipeline.Drop()
+	s.shader.Drop()
+	s.cameraBindGroupLayout.Drop()
+	s.textureBindGroupLayout.Drop()
 }
 
 func main() {
-	d, err := display.NewDisplay()
-	if err != nil {
-		panic(err)
-	}
-	defer d.Destroy()
-
-	w, err := display.NewWindow(d)
-	if err != nil {
-		panic(err)
-	}
-	defer w.Destroy()
-
-	s, err := InitState(w)
+	err := framework.Run(&State{}, framework.Options{
+		Title:  "tutorial7-instances",
+		Window: "gamen",
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer s.Destroy()
-
-	w.SetResizedCallback(func(physicalWidth, physicalHeight uint32, scaleFactor float64) {
-		s.Resize(dpi.PhysicalSize[uint32]{
-			Width:  physicalWidth,
-			Height: physicalHeight,
-		})
-	})
-
-	w.SetKeyboardInputCallback(func(state events.ButtonState, scanCode events.ScanCode, virtualKeyCode events.VirtualKey) {
-		isPressed := state == events.ButtonStatePressed
-
-		switch virtualKeyCode {
-		case events.VirtualKeySpace:
-			s.cameraController.isUpPressed = isPressed
-		case events.VirtualKeyLShift:
-			s.cameraController.isDownPressed = isPressed
-		case events.VirtualKeyW, events.VirtualKeyUp:
-			s.cameraController.isForwardPressed = isPressed
-		case events.VirtualKeyA, events.VirtualKeyLeft:
-			s.cameraController.isLeftPressed = isPressed
-		case events.VirtualKeyS, events.VirtualKeyDown:
-			s.cameraController.isBackwardPressed = isPressed
-		case events.VirtualKeyD, events.VirtualKeyRight:
-			s.cameraController.isRightPressed = isPressed
-		}
-	})
-
-	w.SetCloseRequestedCallback(func() {
-		d.Destroy()
-	})
-
-	for {
-		if !d.Poll() {
-			break
-		}
-
-		s.Update()
-		err := s.Render()
-		if err != nil {
-			errstr := err.Error()
-			fmt.Println(errstr)
-
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }
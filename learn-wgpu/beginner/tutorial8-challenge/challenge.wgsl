@@ -1,18 +1,10 @@
 // Vertex shader
 
-struct VertexInput {
-    @location(0) position: vec3<f32>,
-    @location(1) tex_coords: vec2<f32>,
-}
-
-struct VertexOutput {
-    @builtin(position) clip_position: vec4<f32>,
-    @location(0) tex_coords: vec2<f32>,
-}
+#include "vertex.wgsl"
 
 @vertex
 fn vs_main(
-    model: VertexInput,
+    model: Vertex,
 ) -> VertexOutput {
     var out: VertexOutput;
     out.tex_coords = model.tex_coords;
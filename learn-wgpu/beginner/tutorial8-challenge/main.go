@@ -1,23 +1,26 @@
 package main
 
 import (
-	_ "embed"
-	"fmt"
-	"strings"
+	"embed"
+	"time"
 	"unsafe"
 
-	"github.com/rajveermalviya/gamen/display"
-	"github.com/rajveermalviya/gamen/dpi"
-	"github.com/rajveermalviya/gamen/events"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
 	"github.com/rajveermalviya/go-webgpu-examples/internal/glm"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/hotreload"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/leak"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/uniform"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgsl"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/windowing"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
-//go:embed shader.wgsl
-var shaderCode string
-
-//go:embed challenge.wgsl
-var challengeShaderCode string
+// shaders holds shader.wgsl and challenge.wgsl, which #include the
+// vertex types from vertex.wgsl.
+//
+//go:embed *.wgsl
+var shaders embed.FS
 
 //go:embed happy-tree.png
 var happyTreePng []byte
@@ -30,6 +33,10 @@ var InstanceDisplacement = glm.Vec3[float32]{
 	NumInstancesPerRow * 0.5,
 }
 
+// Vertex and InstanceRaw are declared for the shaders in vertex_gen.wgsl.
+//
+//go:generate go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgslgen -type Vertex,InstanceRaw -space vertex -o vertex_gen.wgsl
+
 type Vertex struct {
 	position  [3]float32
 	texCoords [2]float32
@@ -121,6 +128,10 @@ func (c *Camera) buildViewProjectionMatrix() glm.Mat4[float32] {
 	return proj.Mul4(view)
 }
 
+// CameraUniform is declared for shader.wgsl in camera_gen.wgsl.
+//
+//go:generate go run github.com/rajveermalviya/go-webgpu-examples/cmd/wgslgen -type CameraUniform -space uniform -o camera_gen.wgsl
+
 type CameraUniform struct {
 	viewProj glm.Mat4[float32]
 }
@@ -141,6 +152,7 @@ func (c *CameraUniform) UpdateViewProj(camera *Camera) {
 }
 
 type CameraController struct {
+	// speed is in units per second
 	speed             float32
 	isForwardPressed  bool
 	isBackwardPressed bool
@@ -152,16 +164,18 @@ func NewCameraController(speed float32) *CameraController {
 	return &CameraController{speed: speed}
 }
 
-func (c *CameraController) UpdateCamera(camera *Camera) {
+func (c *CameraController) UpdateCamera(camera *Camera, dt time.Duration) {
+	step := c.speed * float32(dt.Seconds())
+
 	forward := camera.target.Sub(camera.eye)
 	forwardNorm := forward.Normalize()
 	forwardMag := forward.Magnitude()
 
-	if c.isForwardPressed && forwardMag > c.speed {
-		camera.eye = camera.eye.Add(forwardNorm.MulScalar(c.speed))
+	if c.isForwardPressed && forwardMag > step {
+		camera.eye = camera.eye.Add(forwardNorm.MulScalar(step))
 	}
 	if c.isBackwardPressed {
-		camera.eye = camera.eye.Sub(forwardNorm.MulScalar(c.speed))
+		camera.eye = camera.eye.Sub(forwardNorm.MulScalar(step))
 	}
 
 	right := forwardNorm.Cross(camera.up)
@@ -170,10 +184,10 @@ func (c *CameraController) UpdateCamera(camera *Camera) {
 	forwardMag = forward.Magnitude()
 
 	if c.isRightPressed {
-		camera.eye = camera.target.Sub(forward.Add(right.MulScalar(c.speed)).Normalize().MulScalar(forwardMag))
+		camera.eye = camera.target.Sub(forward.Add(right.MulScalar(step)).Normalize().MulScalar(forwardMag))
 	}
 	if c.isLeftPressed {
-		camera.eye = camera.target.Sub(forward.Sub(right.MulScalar(c.speed)).Normalize().MulScalar(forwardMag))
+		camera.eye = camera.target.Sub(forward.Sub(right.MulScalar(step)).Normalize().MulScalar(forwardMag))
 	}
 }
 
@@ -189,7 +203,7 @@ func (i Instance) ToRaw() InstanceRaw {
 }
 
 type InstanceRaw struct {
-	model glm.Mat4[float32]
+	model glm.Mat4[float32] `wgsl:"model_matrix,location=5"`
 }
 
 var InstanceBufferLayout = wgpu.VertexBufferLayout{
@@ -220,180 +234,167 @@ var InstanceBufferLayout = wgpu.VertexBufferLayout{
 }
 
 type DepthPass struct {
+	resources       *resource.Registry
+	config          *wgpu.SwapChainDescriptor
 	texture         *Texture
-	layout          *wgpu.BindGroupLayout
-	bindGroup       *wgpu.BindGroup
-	vertexBuffer    *wgpu.Buffer
-	indexBuffer     *wgpu.Buffer
+	layout          *resource.Handle[*wgpu.BindGroupLayout]
+	bindGroup       *resource.Handle[*wgpu.BindGroup]
+	vertexBuffer    *resource.Handle[*wgpu.Buffer]
+	indexBuffer     *resource.Handle[*wgpu.Buffer]
 	numDepthIndices uint32
-	renderPipeline  *wgpu.RenderPipeline
+	shader          *resource.Handle[*wgpu.ShaderModule]
+	renderPipeline  *resource.Handle[*wgpu.RenderPipeline]
 }
 
-func NewDepthPass(device *wgpu.Device, config *wgpu.SwapChainDescriptor) (*DepthPass, error) {
-	depthPass := &DepthPass{}
+func NewDepthPass(resources *resource.Registry, reloader *hotreload.Reloader, config *wgpu.SwapChainDescriptor) (*DepthPass, error) {
+	depthPass := &DepthPass{resources: resources, config: config}
 
 	var err error
-	depthPass.texture, err = CreateDepthTexture(device, config, "DepthTexture")
+	depthPass.texture, err = CreateDepthTexture(resources, config, "DepthTexture")
 	if err != nil {
 		return nil, err
 	}
 
-	depthPass.layout, err = device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
-		Label: "DepthPassBindGroupLayout",
-		Entries: []wgpu.BindGroupLayoutEntry{
-			{
-				Binding: 0,
-				Texture: wgpu.TextureBindingLayout{
-					SampleType:    wgpu.TextureSampleType_Depth,
-					Multisampled:  false,
-					ViewDimension: wgpu.TextureViewDimension_2D,
-				},
-				Visibility: wgpu.ShaderStage_Fragment,
-			},
-			{
-				Binding: 1,
-				Sampler: wgpu.SamplerBindingLayout{
-					Type: wgpu.SamplerBindingType_Comparison,
-				},
-				Visibility: wgpu.ShaderStage_Fragment,
-			},
-		},
-	})
+	var challengeSource *wgsl.Source
+	depthPass.shader, challengeSource, err = reloader.ProcessedShaderModule("challenge.wgsl", shaders, nil)
+	if err != nil {
+		return nil, err
+	}
+	challengeModule, err := wgsl.Reflect(challengeSource.Code)
+	if err != nil {
+		return nil, challengeSource.MapError(err)
+	}
+	if err := challengeModule.ValidateVertexBuffers("vs_main", []wgpu.VertexBufferLayout{VertexBufferLayout}); err != nil {
+		return nil, err
+	}
+	layoutEntries, err := challengeModule.BindGroupLayoutEntries(0)
 	if err != nil {
-		depthPass.Destroy()
 		return nil, err
 	}
 
-	depthPass.bindGroup, err = device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Label:  "DepthPassBindGroup",
-		Layout: depthPass.layout,
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding:     0,
-				TextureView: depthPass.texture.view,
-				Size:        wgpu.WholeSize,
-			},
-			{
-				Binding: 1,
-				Sampler: depthPass.texture.sampler,
-				Size:    wgpu.WholeSize,
-			},
-		},
+	depthPass.layout, err = resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Label:   "DepthPassBindGroupLayout",
+		Entries: layoutEntries,
 	})
 	if err != nil {
-		depthPass.Destroy()
 		return nil, err
 	}
 
-	depthPass.vertexBuffer, err = device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	depthPass.bindGroup, err = depthPass.createBindGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	depthPass.vertexBuffer, err = resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "DepthPassVertexBuffer",
 		Contents: wgpu.ToBytes(DepthVertices[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		depthPass.Destroy()
 		return nil, err
 	}
 
 	depthPass.numDepthIndices = uint32(len(DEPTH_INDICES))
-	depthPass.indexBuffer, err = device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	depthPass.indexBuffer, err = resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "DepthPassIndexBuffer",
 		Contents: wgpu.ToBytes(DEPTH_INDICES[:]),
 		Usage:    wgpu.BufferUsage_Index,
 	})
 	if err != nil {
-		depthPass.Destroy()
 		return nil, err
 	}
 
-	pipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
-		Label:            "DepthPassPipelineLayout",
-		BindGroupLayouts: []*wgpu.BindGroupLayout{depthPass.layout},
+	format := config.Format
+	depthPass.renderPipeline, err = resource.Track(resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		pipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
+			Label:            "DepthPassPipelineLayout",
+			BindGroupLayouts: []*wgpu.BindGroupLayout{depthPass.layout.Get()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer leak.Track(resources.Leaks, pipelineLayout).Drop()
+
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label:  "DepthPassRenderPipeline",
+			Layout: pipelineLayout,
+			Vertex: wgpu.VertexState{
+				Module:     depthPass.shader.Get(),
+				EntryPoint: "vs_main",
+				Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout},
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     depthPass.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{{
+					Format:    format,
+					Blend:     &wgpu.BlendState_Replace,
+					WriteMask: wgpu.ColorWriteMask_All,
+				}},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_Back,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
 	})
 	if err != nil {
-		depthPass.Destroy()
 		return nil, err
 	}
-	defer pipelineLayout.Drop()
 
-	shader, err := device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "ShadowDisplayShader",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: challengeShaderCode,
-		},
-	})
-	if err != nil {
-		depthPass.Destroy()
-		return nil, err
-	}
-	defer shader.Drop()
+	return depthPass, nil
+}
 
-	depthPass.renderPipeline, err = device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "DepthPassRenderPipeline",
-		Layout: pipelineLayout,
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-			Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout},
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{{
-				Format:    config.Format,
-				Blend:     &wgpu.BlendState_Replace,
-				WriteMask: wgpu.ColorWriteMask_All,
-			}},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
+func (depthPass *DepthPass) createBindGroup() (*resource.Handle[*wgpu.BindGroup], error) {
+	return resource.Track(depthPass.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Label:  "DepthPassBindGroup",
+			Layout: depthPass.layout.Get(),
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding:     0,
+					TextureView: depthPass.texture.view.Get(),
+				},
+				{
+					Binding: 1,
+					Sampler: depthPass.texture.sampler.Get(),
+				},
+			},
+		})
 	})
-	if err != nil {
-		depthPass.Destroy()
-		return nil, err
-	}
-
-	return depthPass, nil
 }
 
-func (depthPass *DepthPass) Resize(device *wgpu.Device, config *wgpu.SwapChainDescriptor) {
-	var err error
+func (depthPass *DepthPass) Resize() {
+	depthPass.bindGroup.Drop()
 	depthPass.texture.Destroy()
-	depthPass.texture = nil
-	depthPass.texture, err = CreateDepthTexture(device, config, "DepthTexture")
+
+	var err error
+	depthPass.texture, err = CreateDepthTexture(depthPass.resources, depthPass.config, "DepthTexture")
 	if err != nil {
 		panic(err)
 	}
-
-	depthPass.bindGroup.Drop()
-	depthPass.bindGroup = nil
-	depthPass.bindGroup, err = device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Label:  "DepthPassBindGroup",
-		Layout: depthPass.layout,
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding:     0,
-				TextureView: depthPass.texture.view,
-			},
-			{
-				Binding: 1,
-				Sampler: depthPass.texture.sampler,
-			},
-		},
-	})
+	depthPass.bindGroup, err = depthPass.createBindGroup()
 	if err != nil {
 		panic(err)
 	}
 }
 
+func (depthPass *DepthPass) Destroy() {
+	depthPass.renderPipeline.Drop()
+	depthPass.shader.Drop()
+	depthPass.indexBuffer.Drop()
+	depthPass.vertexBuffer.Drop()
+	depthPass.bindGroup.Drop()
+	depthPass.layout.Drop()
+	depthPass.texture.Destroy()
+}
+
 func (depthPass *DepthPass) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		Label: "DepthVisualRenderPass",
@@ -403,176 +404,112 @@ func (depthPass *DepthPass) Render(view *wgpu.TextureView, encoder *wgpu.Command
 			StoreOp: wgpu.StoreOp_Store,
 		}},
 	})
-	renderPass.SetPipeline(depthPass.renderPipeline)
-	renderPass.SetBindGroup(0, depthPass.bindGroup, nil)
-	renderPass.SetVertexBuffer(0, depthPass.vertexBuffer, 0, wgpu.WholeSize)
-	renderPass.SetIndexBuffer(depthPass.indexBuffer, wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
+	renderPass.SetPipeline(depthPass.renderPipeline.Get())
+	renderPass.SetBindGroup(0, depthPass.bindGroup.Get(), nil)
+	renderPass.SetVertexBuffer(0, depthPass.vertexBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetIndexBuffer(depthPass.indexBuffer.Get(), wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
 	renderPass.DrawIndexed(depthPass.numDepthIndices, 1, 0, 0, 0)
 	renderPass.End()
 }
 
-func (depthPass *DepthPass) Destroy() {
-	if depthPass.renderPipeline != nil {
-		depthPass.renderPipeline.Drop()
-		depthPass.renderPipeline = nil
-	}
-	if depthPass.indexBuffer != nil {
-		depthPass.indexBuffer.Drop()
-		depthPass.indexBuffer = nil
-	}
-	if depthPass.vertexBuffer != nil {
-		depthPass.vertexBuffer.Drop()
-		depthPass.vertexBuffer = nil
-	}
-	if depthPass.bindGroup != nil {
-		depthPass.bindGroup.Drop()
-		depthPass.bindGroup = nil
-	}
-	if depthPass.layout != nil {
-		depthPass.layout.Drop()
-		depthPass.layout = nil
-	}
-	if depthPass.texture != nil {
-		depthPass.texture.Destroy()
-		depthPass.texture = nil
-	}
-}
-
 type State struct {
-	surface          *wgpu.Surface
-	swapChain        *wgpu.SwapChain
-	device           *wgpu.Device
-	queue            *wgpu.Queue
-	config           *wgpu.SwapChainDescriptor
-	size             dpi.PhysicalSize[uint32]
-	renderPipeline   *wgpu.RenderPipeline
-	vertexBuffer     *wgpu.Buffer
-	indexBuffer      *wgpu.Buffer
-	numIndices       uint32
-	diffuseTexture   *Texture
-	diffuseBindGroup *wgpu.BindGroup
+	shader                 *resource.Handle[*wgpu.ShaderModule]
+	textureBindGroupLayout *resource.Handle[*wgpu.BindGroupLayout]
+	cameraBindGroupLayout  *resource.Handle[*wgpu.BindGroupLayout]
+	resources              *resource.Registry
+	renderPipeline         *resource.Handle[*wgpu.RenderPipeline]
+	vertexBuffer           *resource.Handle[*wgpu.Buffer]
+	indexBuffer            *resource.Handle[*wgpu.Buffer]
+	numIndices             uint32
+	diffuseTexture         *Texture
+	diffuseBindGroup       *resource.Handle[*wgpu.BindGroup]
+
 	camera           *Camera
 	cameraController *CameraController
-	cameraUniform    *CameraUniform
-	cameraBuffer     *wgpu.Buffer
-	cameraBindGroup  *wgpu.BindGroup
+	cameraUniform    *uniform.Buffer[CameraUniform]
+	cameraBindGroup  *resource.Handle[*wgpu.BindGroup]
 	instances        [NumInstancesPerRow * NumInstancesPerRow]Instance
-	instanceBuffer   *wgpu.Buffer
+	instanceBuffer   *resource.Handle[*wgpu.Buffer]
 
 	depthPass *DepthPass
 }
 
-func InitState(window display.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	s.size = window.InnerSize()
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.resources = ctx.Resources
 
-	instance := wgpu.CreateInstance(nil)
-	defer instance.Drop()
-
-	s.surface = instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adaper, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		CompatibleSurface: s.surface,
-	})
+	s.diffuseTexture, err = TextureFromPNGBytes(s.resources, happyTreePng, "happy-tree.png")
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer adaper.Drop()
 
-	s.device, err = adaper.RequestDevice(nil)
+	// the bind group and vertex buffer layouts are checked against, or
+	// derived from, the shader
+	var shaderSource *wgsl.Source
+	s.shader, shaderSource, err = ctx.Shaders.ProcessedShaderModule("shader.wgsl", shaders, nil)
 	if err != nil {
-		return s, err
+		return err
 	}
-	s.queue = s.device.GetQueue()
-
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adaper),
-		Width:       s.size.Width,
-		Height:      s.size.Height,
-		PresentMode: wgpu.PresentMode_Fifo,
+	shaderModule, err := wgsl.Reflect(shaderSource.Code)
+	if err != nil {
+		return shaderSource.MapError(err)
 	}
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
+	err = shaderModule.ValidateVertexBuffers("vs_main", []wgpu.VertexBufferLayout{VertexBufferLayout, InstanceBufferLayout})
 	if err != nil {
-		return s, err
+		return err
 	}
-
-	s.diffuseTexture, err = TextureFromPNGBytes(s.device, s.queue, happyTreePng, "happy-tree.png")
+	textureLayoutEntries, err := shaderModule.BindGroupLayoutEntries(0)
 	if err != nil {
-		return s, err
-	}
-
-	textureBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
-		Entries: []wgpu.BindGroupLayoutEntry{
-			{
-				Binding:    0,
-				Visibility: wgpu.ShaderStage_Fragment,
-				Texture: wgpu.TextureBindingLayout{
-					Multisampled:  false,
-					ViewDimension: wgpu.TextureViewDimension_2D,
-					SampleType:    wgpu.TextureSampleType_Float,
-				},
-			},
-			{
-				Binding:    1,
-				Visibility: wgpu.ShaderStage_Fragment,
-				Sampler: wgpu.SamplerBindingLayout{
-					Type: wgpu.SamplerBindingType_Filtering,
-				},
-			},
-		},
-		Label: "TextureBindGroupLayout",
+		return err
+	}
+	cameraLayoutEntries, err := shaderModule.BindGroupLayoutEntries(1)
+	if err != nil {
+		return err
+	}
+
+	s.textureBindGroupLayout, err = s.resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Entries: textureLayoutEntries,
+		Label:   "TextureBindGroupLayout",
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer textureBindGroupLayout.Drop()
 
-	s.diffuseBindGroup, err = s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Layout: textureBindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{
-			{
-				Binding:     0,
-				TextureView: s.diffuseTexture.view,
-			},
-			{
-				Binding: 1,
-				Sampler: s.diffuseTexture.sampler,
+	s.diffuseBindGroup, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Layout: s.textureBindGroupLayout.Get(),
+			Entries: []wgpu.BindGroupEntry{
+				{
+					Binding:     0,
+					TextureView: s.diffuseTexture.view.Get(),
+				},
+				{
+					Binding: 1,
+					Sampler: s.diffuseTexture.sampler.Get(),
+				},
 			},
-		},
-		Label: "DiffuseBindGroup",
+			Label: "DiffuseBindGroup",
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	s.camera = &Camera{
 		eye:     glm.Vec3[float32]{0, 5, -10},
 		target:  glm.Vec3[float32]{0, 0, 0},
 		up:      glm.Vec3[float32]{0, 1, 0},
-		aspect:  float32(s.size.Width) / float32(s.size.Height),
+		aspect:  float32(ctx.Config.Width) / float32(ctx.Config.Height),
 		fovYRad: glm.DegToRad[float32](45),
 		znear:   0.1,
 		zfar:    100.0,
 	}
-	s.cameraController = NewCameraController(0.2)
-	s.cameraUniform = NewCameraUnifrom()
-	s.cameraUniform.UpdateViewProj(s.camera)
+	s.cameraController = NewCameraController(12)
+	cameraUniform := NewCameraUnifrom()
+	cameraUniform.UpdateViewProj(s.camera)
 
-	s.cameraBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
-		Label:    "Camera Buffer",
-		Contents: wgpu.ToBytes(s.cameraUniform.viewProj[:]),
-		Usage:    wgpu.BufferUsage_Uniform | wgpu.BufferUsage_CopyDst,
-	})
+	s.cameraUniform, err = uniform.New(s.resources, "Camera Buffer", *cameraUniform)
 	if err != nil {
-		return s, err
+		return err
 	}
 
 	s.instances = [NumInstancesPerRow * NumInstancesPerRow]Instance{}
@@ -602,179 +539,155 @@ func InitState(window display.Window) (s *State, err error) {
 	for i, v := range s.instances {
 		instanceData[i] = v.ToRaw()
 	}
-	s.instanceBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.instanceBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Instance Buffer",
 		Contents: wgpu.ToBytes(instanceData[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		return s, err
-	}
-
-	cameraBindGroupLayout, err := s.device.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
-		Label: "CameraBindGroupLayout",
-		Entries: []wgpu.BindGroupLayoutEntry{{
-			Binding:    0,
-			Visibility: wgpu.ShaderStage_Vertex,
-			Buffer: wgpu.BufferBindingLayout{
-				Type:             wgpu.BufferBindingType_Uniform,
-				HasDynamicOffset: false,
-				MinBindingSize:   wgpu.WholeSize,
-			},
-		}},
-	})
-	if err != nil {
-		return s, err
-	}
-	defer cameraBindGroupLayout.Drop()
-
-	s.cameraBindGroup, err = s.device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-		Label:  "CameraBindGroup",
-		Layout: cameraBindGroupLayout,
-		Entries: []wgpu.BindGroupEntry{{
-			Binding: 0,
-			Buffer:  s.cameraBuffer,
-			Size:    wgpu.WholeSize,
-		}},
-	})
-	if err != nil {
-		return s, err
+		return err
 	}
 
-	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label: "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{
-			Code: shaderCode,
-		},
+	s.cameraBindGroupLayout, err = s.resources.CreateBindGroupLayout(&wgpu.BindGroupLayoutDescriptor{
+		Label:   "CameraBindGroupLayout",
+		Entries: cameraLayoutEntries,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer shader.Drop()
 
-	renderPipelineLayout, err := s.device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
-		Label: "Render Pipeline Layout",
-		BindGroupLayouts: []*wgpu.BindGroupLayout{
-			textureBindGroupLayout, cameraBindGroupLayout,
-		},
+	s.cameraBindGroup, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+		return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+			Label:  "CameraBindGroup",
+			Layout: s.cameraBindGroupLayout.Get(),
+			Entries: []wgpu.BindGroupEntry{{
+				Binding: 0,
+				Buffer:  s.cameraUniform.Buffer(),
+				Size:    wgpu.WholeSize,
+			}},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
-	defer renderPipelineLayout.Drop()
 
-	s.renderPipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label:  "Render Pipeline",
-		Layout: renderPipelineLayout,
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-			Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout, InstanceBufferLayout},
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{{
-				Format:    s.config.Format,
-				Blend:     &wgpu.BlendState_Replace,
-				WriteMask: wgpu.ColorWriteMask_All,
-			}},
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:  wgpu.PrimitiveTopology_TriangleList,
-			FrontFace: wgpu.FrontFace_CCW,
-			CullMode:  wgpu.CullMode_Back,
-		},
-		DepthStencil: &wgpu.DepthStencilState{
-			Format:            DepthTextureFormat,
-			DepthWriteEnabled: true,
-			DepthCompare:      wgpu.CompareFunction_Less,
-			StencilFront: wgpu.StencilFaceState{
-				Compare: wgpu.CompareFunction_Always,
+	format := ctx.Config.Format
+	s.renderPipeline, err = resource.Track(s.resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		renderPipelineLayout, err := device.CreatePipelineLayout(&wgpu.PipelineLayoutDescriptor{
+			Label: "Render Pipeline Layout",
+			BindGroupLayouts: []*wgpu.BindGroupLayout{
+				s.textureBindGroupLayout.Get(), s.cameraBindGroupLayout.Get(),
 			},
-			StencilBack: wgpu.StencilFaceState{
-				Compare: wgpu.CompareFunction_Always,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer leak.Track(s.resources.Leaks, renderPipelineLayout).Drop()
+
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label:  "Render Pipeline",
+			Layout: renderPipelineLayout,
+			Vertex: wgpu.VertexState{
+				Module:     s.shader.Get(),
+				EntryPoint: "vs_main",
+				Buffers:    []wgpu.VertexBufferLayout{VertexBufferLayout, InstanceBufferLayout},
 			},
-			DepthBias:           2,
-			DepthBiasSlopeScale: 2.0,
-			DepthBiasClamp:      0,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
+			Fragment: &wgpu.FragmentState{
+				Module:     s.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{{
+					Format:    format,
+					Blend:     &wgpu.BlendState_Replace,
+					WriteMask: wgpu.ColorWriteMask_All,
+				}},
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:  wgpu.PrimitiveTopology_TriangleList,
+				FrontFace: wgpu.FrontFace_CCW,
+				CullMode:  wgpu.CullMode_Back,
+			},
+			DepthStencil: &wgpu.DepthStencilState{
+				Format:            DepthTextureFormat,
+				DepthWriteEnabled: true,
+				DepthCompare:      wgpu.CompareFunction_Less,
+				StencilFront: wgpu.StencilFaceState{
+					Compare: wgpu.CompareFunction_Always,
+				},
+				StencilBack: wgpu.StencilFaceState{
+					Compare: wgpu.CompareFunction_Always,
+				},
+				DepthBias:           2,
+				DepthBiasSlopeScale: 2.0,
+				DepthBiasClamp:      0,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+		})
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.vertexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.vertexBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Vertex Buffer",
 		Contents: wgpu.ToBytes(VERTICES[:]),
 		Usage:    wgpu.BufferUsage_Vertex,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.indexBuffer, err = s.device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+	s.indexBuffer, err = s.resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 		Label:    "Index Buffer",
 		Contents: wgpu.ToBytes(INDICES[:]),
 		Usage:    wgpu.BufferUsage_Index,
 	})
 	if err != nil {
-		return s, err
+		return err
 	}
 	s.numIndices = uint32(len(INDICES))
 
-	s.depthPass, err = NewDepthPass(s.device, s.config)
-	if err != nil {
-		return s, err
+	s.depthPass, err = NewDepthPass(s.resources, ctx.Shaders, ctx.Config)
+	return err
+}
+
+func (s *State) Event(e windowing.Event) {
+	key, ok := e.(windowing.KeyEvent)
+	if !ok {
+		return
 	}
 
-	return s, nil
+	switch key.Key {
+	case windowing.KeyW, windowing.KeyUp:
+		s.cameraController.isForwardPressed = key.Pressed
+	case windowing.KeyA, windowing.KeyLeft:
+		s.cameraController.isLeftPressed = key.Pressed
+	case windowing.KeyS, windowing.KeyDown:
+		s.cameraController.isBackwardPressed = key.Pressed
+	case windowing.KeyD, windowing.KeyRight:
+		s.cameraController.isRightPressed = key.Pressed
+	}
 }
 
-func (s *State) Update() {
-	s.cameraController.UpdateCamera(s.camera)
-	s.cameraUniform.UpdateViewProj(s.camera)
-	s.queue.WriteBuffer(s.cameraBuffer, 0, wgpu.ToBytes(s.cameraUniform.viewProj[:]))
+func (s *State) Update(dt time.Duration) {
+	s.cameraController.UpdateCamera(s.camera, dt)
+	// only uploaded when the camera moved
+	cameraUniform := s.cameraUniform.Get()
+	cameraUniform.UpdateViewProj(s.camera)
+	s.cameraUniform.Set(cameraUniform)
+	s.cameraUniform.Upload(s.resources.Queue())
 }
 
-func (s *State) Resize(newSize dpi.PhysicalSize[uint32]) {
-	if newSize.Width > 0 && newSize.Height > 0 {
-		s.size = newSize
-		s.config.Width = newSize.Width
-		s.config.Height = newSize.Height
-
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-
-		s.depthPass.Resize(s.device, s.config)
+func (s *State) Resize(width, height uint32) {
+	s.depthPass.Resize()
 
-		s.camera.aspect = float32(newSize.Width) / float32(newSize.Height)
-	}
+	s.camera.aspect = float32(width) / float32(height)
 }
 
-func (s *State) Render() error {
-	view, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer view.Drop()
-
-	encoder, err := s.device.CreateCommandEncoder(nil)
-	if err != nil {
-		return err
-	}
-
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{{
 			View:   view,
@@ -788,7 +701,7 @@ func (s *State) Render() error {
 			StoreOp: wgpu.StoreOp_Store,
 		}},
 		DepthStencilAttachment: &wgpu.RenderPassDepthStencilAttachment{
-			View:              s.depthPass.texture.view,
+			View:              s.depthPass.texture.view.Get(),
 			DepthClearValue:   1,
 			DepthLoadOp:       wgpu.LoadOp_Clear,
 			DepthStoreOp:      wgpu.StoreOp_Store,
@@ -799,154 +712,39 @@ func (s *State) Render() error {
 			StencilReadOnly:   true,
 		},
 	})
-	renderPass.SetPipeline(s.renderPipeline)
-	renderPass.SetBindGroup(0, s.diffuseBindGroup, nil)
-	renderPass.SetBindGroup(1, s.cameraBindGroup, nil)
-	renderPass.SetVertexBuffer(0, s.vertexBuffer, 0, wgpu.WholeSize)
-	renderPass.SetVertexBuffer(1, s.instanceBuffer, 0, wgpu.WholeSize)
-	renderPass.SetIndexBuffer(s.indexBuffer, wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
+	renderPass.SetPipeline(s.renderPipeline.Get())
+	renderPass.SetBindGroup(0, s.diffuseBindGroup.Get(), nil)
+	renderPass.SetBindGroup(1, s.cameraBindGroup.Get(), nil)
+	renderPass.SetVertexBuffer(0, s.vertexBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetVertexBuffer(1, s.instanceBuffer.Get(), 0, wgpu.WholeSize)
+	renderPass.SetIndexBuffer(s.indexBuffer.Get(), wgpu.IndexFormat_Uint16, 0, wgpu.WholeSize)
 	renderPass.DrawIndexed(s.numIndices, uint32(len(s.instances)), 0, 0, 0)
 	renderPass.End()
 
 	s.depthPass.Render(view, encoder)
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
 func (s *State) Destroy() {
-	if s.depthPass != nil {
-		s.depthPass.Destroy()
-		s.depthPass = nil
-	}
-	if s.indexBuffer != nil {
-		s.indexBuffer.Drop()
-		s.indexBuffer = nil
-	}
-	if s.vertexBuffer != nil {
-		s.vertexBuffer.Drop()
-		s.vertexBuffer = nil
-	}
-	if s.renderPipeline != nil {
-		s.renderPipeline.Drop()
-		s.renderPipeline = nil
-	}
-	if s.cameraBindGroup != nil {
-		s.cameraBindGroup.Drop()
-		s.cameraBindGroup = nil
-	}
-	if s.instanceBuffer != nil {
-		s.instanceBuffer.Drop()
-		s.instanceBuffer = nil
-	}
-	if s.cameraBuffer != nil {
-		s.cameraBuffer.Drop()
-		s.cameraBuffer = nil
-	}
-	if s.cameraUniform != nil {
-		s.cameraUniform = nil
-	}
-	if s.cameraController != nil {
-		s.cameraController = nil
-	}
-	if s.camera != nil {
-		s.camera = nil
-	}
-	if s.diffuseBindGroup != nil {
-		s.diffuseBindGroup.Drop()
-		s.diffuseBindGroup = nil
-	}
-	if s.diffuseTexture != nil {
-		s.diffuseTexture.Destroy()
-		s.diffuseTexture = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
+	s.depthPass.Destroy()
+	s.instanceBuffer.Drop()
+	s.cameraBindGroup.Drop()
+	s.cameraUniform.Drop()
+	s.diffuseBindGroup.Drop()
+	s.diffuseTexture.Destroy()
+	s.indexBuffer.Drop()
+	s.vertexBuffer.Drop()
+	s.renderPipeline.Drop()
+	s.shader.Drop()
+	s.cameraBindGroupLayout.Drop()
+	s.textureBindGroupLayout.Drop()
 }
 
 func main() {
-	d, err := display.NewDisplay()
-	if err != nil {
-		panic(err)
-	}
-	defer d.Destroy()
-
-	w, err := display.NewWindow(d)
-	if err != nil {
-		panic(err)
-	}
-	defer w.Destroy()
-
-	s, err := InitState(w)
+	err := framework.Run(&State{}, framework.Options{
+		Title:  "tutorial8-challenge",
+		Window: "gamen",
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer s.Destroy()
-
-	w.SetResizedCallback(func(physicalWidth, physicalHeight uint32, scaleFactor float64) {
-		s.Resize(dpi.PhysicalSize[uint32]{
-			Width:  physicalWidth,
-			Height: physicalHeight,
-		})
-	})
-
-	w.SetKeyboardInputCallback(func(state events.ButtonState, scanCode events.ScanCode, virtualKeyCode events.VirtualKey) {
-		isPressed := state == events.ButtonStatePressed
-
-		switch virtualKeyCode {
-		case events.VirtualKeyW, events.VirtualKeyUp:
-			s.cameraController.isForwardPressed = isPressed
-		case events.VirtualKeyA, events.VirtualKeyLeft:
-			s.cameraController.isLeftPressed = isPressed
-		case events.VirtualKeyS, events.VirtualKeyDown:
-			s.cameraController.isBackwardPressed = isPressed
-		case events.VirtualKeyD, events.VirtualKeyRight:
-			s.cameraController.isRightPressed = isPressed
-		}
-	})
-
-	w.SetCloseRequestedCallback(func() {
-		d.Destroy()
-	})
-
-	for {
-		if !d.Poll() {
-			break
-		}
-
-		s.Update()
-		err := s.Render()
-		if err != nil {
-			errstr := err.Error()
-			fmt.Println(errstr)
-
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(s.size)
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }
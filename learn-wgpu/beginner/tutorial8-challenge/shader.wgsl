@@ -1,31 +1,16 @@
 // Vertex shader
 
-struct CameraUniform {
-    view_proj: mat4x4<f32>,
-}
+#include "camera_gen.wgsl"
+
 @group(1) @binding(0)
 var<uniform> camera: CameraUniform;
 
-struct VertexInput {
-    @location(0) position: vec3<f32>,
-    @location(1) tex_coords: vec2<f32>,
-}
-struct InstanceInput {
-    @location(5) model_matrix_0: vec4<f32>,
-    @location(6) model_matrix_1: vec4<f32>,
-    @location(7) model_matrix_2: vec4<f32>,
-    @location(8) model_matrix_3: vec4<f32>,
-}
-
-struct VertexOutput {
-    @builtin(position) clip_position: vec4<f32>,
-    @location(0) tex_coords: vec2<f32>,
-}
+#include "vertex.wgsl"
 
 @vertex
 fn vs_main(
-    model: VertexInput,
-    instance: InstanceInput,
+    model: Vertex,
+    instance: InstanceRaw,
 ) -> VertexOutput {
     let model_matrix = mat4x4<f32>(
         instance.model_matrix_0,
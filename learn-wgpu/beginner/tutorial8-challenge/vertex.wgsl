@@ -0,0 +1,8 @@
+// Vertex types shared by shader.wgsl and challenge.wgsl
+
+#include "vertex_gen.wgsl"
+
+struct VertexOutput {
+    @builtin(position) clip_position: vec4<f32>,
+    @location(0) tex_coords: vec2<f32>,
+}
@@ -0,0 +1,13 @@
+// Code generated by "wgslgen -type Vertex,InstanceRaw -space vertex -o vertex_gen.wgsl"; DO NOT EDIT.
+
+struct Vertex {
+    @location(0) position: vec3<f32>,
+    @location(1) tex_coords: vec2<f32>,
+}
+
+struct InstanceRaw {
+    @location(5) model_matrix_0: vec4<f32>,
+    @location(6) model_matrix_1: vec4<f32>,
+    @location(7) model_matrix_2: vec4<f32>,
+    @location(8) model_matrix_3: vec4<f32>,
+}
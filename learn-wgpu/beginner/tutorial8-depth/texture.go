@@ -6,13 +6,16 @@ import (
 	"image/draw"
 	"image/png"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
+// Texture holds handles so that the texture is recreated with its image
+// after a device loss.
 type Texture struct {
-	texture *wgpu.Texture
-	view    *wgpu.TextureView
-	sampler *wgpu.Sampler
+	texture *resource.Handle[*wgpu.Texture]
+	view    *resource.Handle[*wgpu.TextureView]
+	sampler *resource.Handle[*wgpu.Sampler]
 }
 
 func (t *Texture) Destroy() {
@@ -30,16 +33,16 @@ func (t *Texture) Destroy() {
 	}
 }
 
-func TextureFromPNGBytes(device *wgpu.Device, queue *wgpu.Queue, buf []byte, label string) (*Texture, error) {
+func TextureFromPNGBytes(resources *resource.Registry, buf []byte, label string) (*Texture, error) {
 	img, err := png.Decode(bytes.NewReader(buf))
 	if err != nil {
 		return nil, err
 	}
 
-	return TextureFromImage(device, queue, img, label)
+	return TextureFromImage(resources, img, label)
 }
 
-func TextureFromImage(device *wgpu.Device, queue *wgpu.Queue, img image.Image, label string) (t *Texture, err error) {
+func TextureFromImage(resources *resource.Registry, img image.Image, label string) (t *Texture, err error) {
 	defer func() {
 		if err != nil {
 			t.Destroy()
@@ -59,42 +62,39 @@ func TextureFromImage(device *wgpu.Device, queue *wgpu.Queue, img image.Image, l
 		draw.Draw(rgbaImg, r, img, image.Point{}, draw.Over)
 	}
 
-	size := wgpu.Extent3D{
-		Width:              uint32(width),
-		Height:             uint32(height),
-		DepthOrArrayLayers: 1,
-	}
-	t.texture, err = device.CreateTexture(&wgpu.TextureDescriptor{
-		Label:         label,
-		Size:          size,
-		MipLevelCount: 1,
-		SampleCount:   1,
-		Dimension:     wgpu.TextureDimension_2D,
-		Format:        wgpu.TextureFormat_RGBA8UnormSrgb,
-		Usage:         wgpu.TextureUsage_TextureBinding | wgpu.TextureUsage_CopyDst,
-	})
-	if err != nil {
-		return t, err
-	}
-
-	queue.WriteTexture(
-		&wgpu.ImageCopyTexture{
-			Aspect:   wgpu.TextureAspect_All,
-			Texture:  t.texture,
-			MipLevel: 0,
-			Origin:   wgpu.Origin3D{X: 0, Y: 0, Z: 0},
+	t.texture, err = resources.CreateTextureInit(
+		&wgpu.TextureDescriptor{
+			Label: label,
+			Size: wgpu.Extent3D{
+				Width:              uint32(width),
+				Height:             uint32(height),
+				DepthOrArrayLayers: 1,
+			},
+			MipLevelCount: 1,
+			SampleCount:   1,
+			Dimension:     wgpu.TextureDimension_2D,
+			Format:        wgpu.TextureFormat_RGBA8UnormSrgb,
+			Usage:         wgpu.TextureUsage_TextureBinding | wgpu.TextureUsage_CopyDst,
 		},
 		rgbaImg.Pix,
-		&wgpu.TextureDataLayout{
+		wgpu.TextureDataLayout{
 			Offset:       0,
 			BytesPerRow:  4 * uint32(width),
 			RowsPerImage: uint32(height),
 		},
-		&size,
 	)
+	if err != nil {
+		return t, err
+	}
+
+	t.view, err = resource.Track(resources, func(*wgpu.Device, *wgpu.Queue) (*wgpu.TextureView, error) {
+		return t.texture.Get().CreateView(nil), nil
+	})
+	if err != nil {
+		return t, err
+	}
 
-	t.view = t.texture.CreateView(nil)
-	t.sampler, err = device.CreateSampler(&wgpu.SamplerDescriptor{
+	t.sampler, err = resources.CreateSampler(&wgpu.SamplerDescriptor{
 		AddressModeU: wgpu.AddressMode_ClampToEdge,
 		AddressModeV: wgpu.AddressMode_ClampToEdge,
 		AddressModeW: wgpu.AddressMode_ClampToEdge,
@@ -111,7 +111,7 @@ func TextureFromImage(device *wgpu.Device, queue *wgpu.Queue, img image.Image, l
 
 const DepthTextureFormat = wgpu.TextureFormat_Depth32Float
 
-func CreateDepthTexture(device *wgpu.Device, config *wgpu.SwapChainDescriptor, label string) (t *Texture, err error) {
+func CreateDepthTexture(resources *resource.Registry, config *wgpu.SwapChainDescriptor, label string) (t *Texture, err error) {
 	defer func() {
 		if err != nil {
 			t.Destroy()
@@ -120,15 +120,13 @@ func CreateDepthTexture(device *wgpu.Device, config *wgpu.SwapChainDescriptor, l
 	}()
 	t = &Texture{}
 
-	size := wgpu.Extent3D{
-		Width:              config.Width,
-		Height:             config.Height,
-		DepthOrArrayLayers: 1,
-	}
-
-	t.texture, err = device.CreateTexture(&wgpu.TextureDescriptor{
-		Label:         label,
-		Size:          size,
+	t.texture, err = resources.CreateTexture(&wgpu.TextureDescriptor{
+		Label: label,
+		Size: wgpu.Extent3D{
+			Width:              config.Width,
+			Height:             config.Height,
+			DepthOrArrayLayers: 1,
+		},
 		MipLevelCount: 1,
 		SampleCount:   1,
 		Dimension:     wgpu.TextureDimension_2D,
@@ -138,8 +136,15 @@ func CreateDepthTexture(device *wgpu.Device, config *wgpu.SwapChainDescriptor, l
 	if err != nil {
 		return t, err
 	}
-	t.view = t.texture.CreateView(nil)
-	t.sampler, err = device.CreateSampler(&wgpu.SamplerDescriptor{
+
+	t.view, err = resource.Track(resources, func(*wgpu.Device, *wgpu.Queue) (*wgpu.TextureView, error) {
+		return t.texture.Get().CreateView(nil), nil
+	})
+	if err != nil {
+		return t, err
+	}
+
+	t.sampler, err = resources.CreateSampler(&wgpu.SamplerDescriptor{
 		AddressModeU: wgpu.AddressMode_ClampToEdge,
 		AddressModeV: wgpu.AddressMode_ClampToEdge,
 		AddressModeW: wgpu.AddressMode_ClampToEdge,
@@ -3,6 +3,7 @@ package main
 import (
 	"unsafe"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 )
 
@@ -37,13 +38,13 @@ var ModelVertexLayout = wgpu.VertexBufferLayout{
 type Material struct {
 	Name           string
 	DiffuseTexture *Texture
-	BindGroup      *wgpu.BindGroup
+	BindGroup      *resource.Handle[*wgpu.BindGroup]
 }
 
 type Mesh struct {
 	Name         string
-	VertexBuffer *wgpu.Buffer
-	IndexBuffer  *wgpu.Buffer
+	VertexBuffer *resource.Handle[*wgpu.Buffer]
+	IndexBuffer  *resource.Handle[*wgpu.Buffer]
 	NumElements  uint32
 	MaterialIdx  int
 }
@@ -53,27 +54,26 @@ type Model struct {
 	Materials []Material
 }
 
-func (m *Model) Destroy() {
-	for _, mesh := range m.Meshes {
-		mesh.VertexBuffer.Drop()
+func (model *Model) Destroy() {
+	for _, mesh := range model.Meshes {
 		mesh.IndexBuffer.Drop()
+		mesh.VertexBuffer.Drop()
 	}
-	m.Meshes = nil
-
-	for _, mtl := range m.Materials {
-		mtl.DiffuseTexture.Destroy()
-		mtl.BindGroup.Drop()
+	for _, material := range model.Materials {
+		material.BindGroup.Drop()
+		material.DiffuseTexture.Destroy()
 	}
-	m.Materials = nil
+	model.Meshes = nil
+	model.Materials = nil
 }
 
 func drawModelInstanced(renderPass *wgpu.RenderPassEncoder, model *Model, cameraBindGroup *wgpu.BindGroup, instanceCount uint32) {
 	for _, mesh := range model.Meshes {
 		material := model.Materials[mesh.MaterialIdx]
 
-		renderPass.SetVertexBuffer(0, mesh.VertexBuffer, 0, wgpu.WholeSize)
-		renderPass.SetIndexBuffer(mesh.IndexBuffer, wgpu.IndexFormat_Uint32, 0, wgpu.WholeSize)
-		renderPass.SetBindGroup(0, material.BindGroup, nil)
+		renderPass.SetVertexBuffer(0, mesh.VertexBuffer.Get(), 0, wgpu.WholeSize)
+		renderPass.SetIndexBuffer(mesh.IndexBuffer.Get(), wgpu.IndexFormat_Uint32, 0, wgpu.WholeSize)
+		renderPass.SetBindGroup(0, material.BindGroup.Get(), nil)
 		renderPass.SetBindGroup(1, cameraBindGroup, nil)
 		renderPass.DrawIndexed(mesh.NumElements, instanceCount, 0, 0, 0)
 	}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
 	"github.com/rajveermalviya/go-webgpu-examples/learn-wgpu/beginner/tutorial9-models/objloader"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 	"golang.org/x/exp/slices"
@@ -13,7 +14,7 @@ import (
 //go:embed res
 var res embed.FS
 
-func loadTexture(name string, device *wgpu.Device, queue *wgpu.Queue) (*Texture, error) {
+func loadTexture(name string, resources *resource.Registry) (*Texture, error) {
 	f, err := res.Open("res/" + name)
 	if err != nil {
 		return nil, err
@@ -25,48 +26,55 @@ func loadTexture(name string, device *wgpu.Device, queue *wgpu.Queue) (*Texture,
 		return nil, err
 	}
 
-	return TextureFromBytes(device, queue, buf, name)
+	return TextureFromBytes(resources, buf, name)
 }
 
-func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLayout) (*Model, error) {
+func LoadModel(resources *resource.Registry, layout *resource.Handle[*wgpu.BindGroupLayout]) (_ *Model, err error) {
 	models, objMaterials, err := objloader.LoadObj(res, "res/cube.obj")
 	if err != nil {
 		return nil, err
 	}
 
-	materials := []Material{}
+	model := &Model{}
+	defer func() {
+		if err != nil {
+			model.Destroy()
+		}
+	}()
+
 	for _, m := range objMaterials {
-		diffuseTexture, err := loadTexture(m.DiffuseTexture, device, queue)
+		diffuseTexture, err := loadTexture(m.DiffuseTexture, resources)
 		if err != nil {
 			return nil, err
 		}
 
-		bindGroup, err := device.CreateBindGroup(&wgpu.BindGroupDescriptor{
-			Layout: layout,
-			Entries: []wgpu.BindGroupEntry{
-				{
-					Binding:     0,
-					TextureView: diffuseTexture.view,
+		bindGroup, err := resource.Track(resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.BindGroup, error) {
+			return device.CreateBindGroup(&wgpu.BindGroupDescriptor{
+				Layout: layout.Get(),
+				Entries: []wgpu.BindGroupEntry{
+					{
+						Binding:     0,
+						TextureView: diffuseTexture.view.Get(),
+					},
+					{
+						Binding: 1,
+						Sampler: diffuseTexture.sampler.Get(),
+					},
 				},
-				{
-					Binding: 1,
-					Sampler: diffuseTexture.sampler,
-				},
-			},
+			})
 		})
 		if err != nil {
+			diffuseTexture.Destroy()
 			return nil, err
 		}
 
-		materials = append(materials, Material{
+		model.Materials = append(model.Materials, Material{
 			Name:           m.Name,
 			DiffuseTexture: diffuseTexture,
 			BindGroup:      bindGroup,
 		})
 	}
 
-	meshes := []Mesh{}
-
 	for _, m := range models {
 		if len(m.Normals) != len(m.TextureCoords) || len(m.TextureCoords) != len(m.Vertices) {
 			return nil, errors.New("got invalid obj")
@@ -85,7 +93,7 @@ func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLay
 			})
 		}
 
-		vertexBuffer, err := device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		vertexBuffer, err := resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 			Label:    m.Name + " vertex buffer",
 			Contents: wgpu.ToBytes(vertices),
 			Usage:    wgpu.BufferUsage_Vertex,
@@ -94,23 +102,24 @@ func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLay
 			return nil, err
 		}
 
-		indexBuffer, err := device.CreateBufferInit(&wgpu.BufferInitDescriptor{
+		indexBuffer, err := resources.CreateBufferInit(&wgpu.BufferInitDescriptor{
 			Label:    m.Name + " index buffer",
 			Contents: wgpu.ToBytes(m.Indices),
 			Usage:    wgpu.BufferUsage_Index,
 		})
 		if err != nil {
+			vertexBuffer.Drop()
 			return nil, err
 		}
 
-		materialIdx := slices.IndexFunc(materials,
+		materialIdx := slices.IndexFunc(model.Materials,
 			func(e Material) bool { return e.Name == m.MaterialName },
 		)
 		if materialIdx == -1 {
 			materialIdx = 0
 		}
 
-		meshes = append(meshes, Mesh{
+		model.Meshes = append(model.Meshes, Mesh{
 			Name:         m.Name,
 			VertexBuffer: vertexBuffer,
 			IndexBuffer:  indexBuffer,
@@ -119,8 +128,5 @@ func LoadModel(device *wgpu.Device, queue *wgpu.Queue, layout *wgpu.BindGroupLay
 		})
 	}
 
-	return &Model{
-		Meshes:    meshes,
-		Materials: materials,
-	}, nil
+	return model, nil
 }
@@ -0,0 +1,155 @@
+// Command primitives runs the GPU primitives of internal/parallel on
+// random data, checks them against their CPU references and prints how
+// long both took. The benchmarks of internal/parallel compare the sorts
+// with sort.Slice too.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rajveermalviya/go-webgpu-examples/internal/config"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/parallel"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/wgpulog"
+	"github.com/rajveermalviya/go-webgpu/wgpu"
+)
+
+var cfg = config.FromEnv()
+
+var (
+	sizeFlag = flag.Int("n", 1<<20, "number of values")
+	binsFlag = flag.Uint("bins", 256, "number of histogram bins")
+	seedFlag = flag.Int64("seed", 1, "random seed")
+)
+
+func init() {
+	cfg.RegisterFlags(flag.CommandLine)
+}
+
+func main() {
+	flag.Parse()
+	cfg.Apply()
+	defer wgpulog.Flush()
+	if cfg.ListAdapters {
+		if err := cfg.PrintAdapters(os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	instance := wgpu.CreateInstance(cfg.InstanceDescriptor())
+	defer instance.Drop()
+
+	adapter, err := instance.RequestAdapter(cfg.RequestAdapterOptions(nil))
+	if err != nil {
+		panic(err)
+	}
+	defer adapter.Drop()
+
+	device, err := adapter.RequestDevice(nil)
+	if err != nil {
+		panic(err)
+	}
+	defer device.Drop()
+
+	primitives, err := parallel.New(device)
+	if err != nil {
+		panic(err)
+	}
+	defer primitives.Drop()
+
+	// a lost device never finishes the readbacks
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	rng := rand.New(rand.NewSource(*seedFlag))
+	values := make([]uint32, *sizeFlag)
+	for i := range values {
+		values[i] = rng.Uint32()
+	}
+	fmt.Printf("%d values\n", len(values))
+
+	ok := true
+	check := func(name string, gpu, cpu time.Duration, equal bool) {
+		result := "ok"
+		if !equal {
+			result = "MISMATCH"
+			ok = false
+		}
+		fmt.Printf("%-16s gpu %10v  cpu %10v  %s\n", name, gpu.Round(time.Microsecond), cpu.Round(time.Microsecond), result)
+	}
+
+	for _, op := range []parallel.Op{parallel.Sum, parallel.Min, parallel.Max} {
+		var got, want uint32
+		gpu := timed(func() { got, err = primitives.Reduce(ctx, op, values) })
+		if err != nil {
+			panic(err)
+		}
+		cpu := timed(func() { want = parallel.ReduceCPU(op, values) })
+		check("reduce "+op.String(), gpu, cpu, got == want)
+	}
+
+	for _, inclusive := range []bool{false, true} {
+		var got, want []uint32
+		gpu := timed(func() { got, err = primitives.Scan(ctx, values, inclusive) })
+		if err != nil {
+			panic(err)
+		}
+		cpu := timed(func() { want = parallel.ScanCPU(values, inclusive) })
+		name := "exclusive scan"
+		if inclusive {
+			name = "inclusive scan"
+		}
+		check(name, gpu, cpu, slices.Equal(got, want))
+	}
+
+	{
+		// the payload is the original index, which also shows stability
+		keys := make([]uint32, len(values))
+		payload := make([]uint32, len(values))
+		for i, v := range values {
+			// few distinct keys, so that many are equal
+			keys[i] = v % 1024
+			payload[i] = uint32(i)
+		}
+		gotKeys, gotPayload := slices.Clone(keys), slices.Clone(payload)
+		gpu := timed(func() { err = primitives.Sort(ctx, gotKeys, gotPayload) })
+		if err != nil {
+			panic(err)
+		}
+		wantKeys, wantPayload := slices.Clone(keys), slices.Clone(payload)
+		cpu := timed(func() { parallel.SortCPU(wantKeys, wantPayload) })
+		check("radix sort", gpu, cpu, slices.Equal(gotKeys, wantKeys) && slices.Equal(gotPayload, wantPayload))
+	}
+
+	{
+		bins := uint32(*binsFlag)
+		binned := make([]uint32, len(values))
+		for i, v := range values {
+			// some values fall outside the bins
+			binned[i] = v % (bins + bins/8 + 1)
+		}
+		var got, want []uint32
+		gpu := timed(func() { got, err = primitives.Histogram(ctx, binned, bins) })
+		if err != nil {
+			panic(err)
+		}
+		cpu := timed(func() { want = parallel.HistogramCPU(binned, bins) })
+		check(fmt.Sprintf("histogram %d", bins), gpu, cpu, slices.Equal(got, want))
+	}
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func timed(f func()) time.Duration {
+	start := time.Now()
+	f()
+	return time.Since(start)
+}
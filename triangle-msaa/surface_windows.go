@@ -1,24 +0,0 @@
-package main
-
-import (
-	"unsafe"
-
-	"github.com/go-gl/glfw/v3.3/glfw"
-	"github.com/rajveermalviya/go-webgpu/wgpu"
-)
-
-/*
-
-#include <windows.h>
-
-*/
-import "C"
-
-func getSurfaceDescriptor(w *glfw.Window) *wgpu.SurfaceDescriptor {
-	return &wgpu.SurfaceDescriptor{
-		WindowsHWND: &wgpu.SurfaceDescriptorFromWindowsHWND{
-			Hwnd:      unsafe.Pointer(w.GetWin32Window()),
-			Hinstance: unsafe.Pointer(C.GetModuleHandle(nil)),
-		},
-	}
-}
@@ -1,172 +1,73 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"runtime"
-	"strings"
+	"time"
 
-	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/framework"
+	"github.com/rajveermalviya/go-webgpu-examples/internal/resource"
 	"github.com/rajveermalviya/go-webgpu/wgpu"
 
 	_ "embed"
 )
 
-var forceFallbackAdapter = os.Getenv("WGPU_FORCE_FALLBACK_ADAPTER") == "1"
-
-func init() {
-	runtime.LockOSThread()
-
-	switch os.Getenv("WGPU_LOG_LEVEL") {
-	case "OFF":
-		wgpu.SetLogLevel(wgpu.LogLevel_Off)
-	case "ERROR":
-		wgpu.SetLogLevel(wgpu.LogLevel_Error)
-	case "WARN":
-		wgpu.SetLogLevel(wgpu.LogLevel_Warn)
-	case "INFO":
-		wgpu.SetLogLevel(wgpu.LogLevel_Info)
-	case "DEBUG":
-		wgpu.SetLogLevel(wgpu.LogLevel_Debug)
-	case "TRACE":
-		wgpu.SetLogLevel(wgpu.LogLevel_Trace)
-	}
-}
-
 //go:embed shader.wgsl
 var shader string
 
 type State struct {
-	instance  *wgpu.Instance
-	surface   *wgpu.Surface
-	swapChain *wgpu.SwapChain
-	device    *wgpu.Device
-	queue     *wgpu.Queue
-	config    *wgpu.SwapChainDescriptor
-	pipeline  *wgpu.RenderPipeline
+	shader   *resource.Handle[*wgpu.ShaderModule]
+	pipeline *resource.Handle[*wgpu.RenderPipeline]
 }
 
-func InitState(window *glfw.Window) (s *State, err error) {
-	defer func() {
-		if err != nil {
-			s.Destroy()
-			s = nil
-		}
-	}()
-	s = &State{}
-
-	s.instance = wgpu.CreateInstance(nil)
-
-	s.surface = s.instance.CreateSurface(getSurfaceDescriptor(window))
-
-	adapter, err := s.instance.RequestAdapter(&wgpu.RequestAdapterOptions{
-		ForceFallbackAdapter: forceFallbackAdapter,
-		CompatibleSurface:    s.surface,
-	})
-	if err != nil {
-		return s, err
-	}
-	defer adapter.Drop()
-
-	s.device, err = adapter.RequestDevice(nil)
-	if err != nil {
-		return s, err
-	}
-	s.queue = s.device.GetQueue()
-
-	shader, err := s.device.CreateShaderModule(&wgpu.ShaderModuleDescriptor{
-		Label:          "shader.wgsl",
-		WGSLDescriptor: &wgpu.ShaderModuleWGSLDescriptor{Code: shader},
-	})
-	if err != nil {
-		return s, err
-	}
-	defer shader.Drop()
-
-	width, height := window.GetSize()
-	s.config = &wgpu.SwapChainDescriptor{
-		Usage:       wgpu.TextureUsage_RenderAttachment,
-		Format:      s.surface.GetPreferredFormat(adapter),
-		Width:       uint32(width),
-		Height:      uint32(height),
-		PresentMode: wgpu.PresentMode_Fifo,
-	}
-	s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
+func (s *State) Init(ctx *framework.Context) (err error) {
+	s.shader, err = ctx.Shaders.ShaderModule("shader.wgsl", shader)
 	if err != nil {
-		return s, err
+		return err
 	}
 
-	s.pipeline, err = s.device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
-		Label: "Render Pipeline",
-		Vertex: wgpu.VertexState{
-			Module:     shader,
-			EntryPoint: "vs_main",
-		},
-		Primitive: wgpu.PrimitiveState{
-			Topology:         wgpu.PrimitiveTopology_TriangleList,
-			StripIndexFormat: wgpu.IndexFormat_Undefined,
-			FrontFace:        wgpu.FrontFace_CCW,
-			CullMode:         wgpu.CullMode_None,
-		},
-		Multisample: wgpu.MultisampleState{
-			Count:                  1,
-			Mask:                   0xFFFFFFFF,
-			AlphaToCoverageEnabled: false,
-		},
-		Fragment: &wgpu.FragmentState{
-			Module:     shader,
-			EntryPoint: "fs_main",
-			Targets: []wgpu.ColorTargetState{
-				{
-					Format:    s.config.Format,
-					Blend:     &wgpu.BlendState_Replace,
-					WriteMask: wgpu.ColorWriteMask_All,
+	format := ctx.Config.Format
+	s.pipeline, err = resource.Track(ctx.Resources, func(device *wgpu.Device, _ *wgpu.Queue) (*wgpu.RenderPipeline, error) {
+		return device.CreateRenderPipeline(&wgpu.RenderPipelineDescriptor{
+			Label: "Render Pipeline",
+			Vertex: wgpu.VertexState{
+				Module:     s.shader.Get(),
+				EntryPoint: "vs_main",
+			},
+			Primitive: wgpu.PrimitiveState{
+				Topology:         wgpu.PrimitiveTopology_TriangleList,
+				StripIndexFormat: wgpu.IndexFormat_Undefined,
+				FrontFace:        wgpu.FrontFace_CCW,
+				CullMode:         wgpu.CullMode_None,
+			},
+			Multisample: wgpu.MultisampleState{
+				Count:                  1,
+				Mask:                   0xFFFFFFFF,
+				AlphaToCoverageEnabled: false,
+			},
+			Fragment: &wgpu.FragmentState{
+				Module:     s.shader.Get(),
+				EntryPoint: "fs_main",
+				Targets: []wgpu.ColorTargetState{
+					{
+						Format:    format,
+						Blend:     &wgpu.BlendState_Replace,
+						WriteMask: wgpu.ColorWriteMask_All,
+					},
 				},
 			},
-		},
+		})
 	})
-	if err != nil {
-		return s, err
-	}
-
-	return s, nil
+	return err
 }
 
-func (s *State) Resize(width, height int) {
-	if width > 0 && height > 0 {
-		s.config.Width = uint32(width)
-		s.config.Height = uint32(height)
+func (s *State) Update(dt time.Duration) {}
 
-		if s.swapChain != nil {
-			s.swapChain.Drop()
-		}
-		var err error
-		s.swapChain, err = s.device.CreateSwapChain(s.surface, s.config)
-		if err != nil {
-			panic(err)
-		}
-	}
-}
-
-func (s *State) Render() error {
-	nextTexture, err := s.swapChain.GetCurrentTextureView()
-	if err != nil {
-		return err
-	}
-	defer nextTexture.Drop()
-
-	encoder, err := s.device.CreateCommandEncoder(&wgpu.CommandEncoderDescriptor{
-		Label: "Command Encoder",
-	})
-	if err != nil {
-		return err
-	}
+func (s *State) Resize(width, height uint32) {}
 
+func (s *State) Render(view *wgpu.TextureView, encoder *wgpu.CommandEncoder) {
 	renderPass := encoder.BeginRenderPass(&wgpu.RenderPassDescriptor{
 		ColorAttachments: []wgpu.RenderPassColorAttachment{
 			{
-				View:       nextTexture,
+				View:       view,
 				LoadOp:     wgpu.LoadOp_Clear,
 				StoreOp:    wgpu.StoreOp_Store,
 				ClearValue: wgpu.Color_Green,
@@ -174,94 +75,18 @@ func (s *State) Render() error {
 		},
 	})
 
-	renderPass.SetPipeline(s.pipeline)
+	renderPass.SetPipeline(s.pipeline.Get())
 	renderPass.Draw(3, 1, 0, 0)
 	renderPass.End()
-
-	s.queue.Submit(encoder.Finish(nil))
-	s.swapChain.Present()
-
-	return nil
 }
 
 func (s *State) Destroy() {
-	if s.pipeline != nil {
-		s.pipeline.Drop()
-		s.pipeline = nil
-	}
-	if s.swapChain != nil {
-		s.swapChain.Drop()
-		s.swapChain = nil
-	}
-	if s.config != nil {
-		s.config = nil
-	}
-	if s.queue != nil {
-		s.queue = nil
-	}
-	if s.device != nil {
-		s.device.Drop()
-		s.device = nil
-	}
-	if s.surface != nil {
-		s.surface.Drop()
-		s.surface = nil
-	}
-	if s.instance != nil {
-		s.instance.Drop()
-		s.instance = nil
-	}
+	s.pipeline.Drop()
+	s.shader.Drop()
 }
 
 func main() {
-	if err := glfw.Init(); err != nil {
+	if err := framework.Run(&State{}, framework.Options{}); err != nil {
 		panic(err)
 	}
-	defer glfw.Terminate()
-
-	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
-	window, err := glfw.CreateWindow(640, 480, "go-webgpu with glfw", nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	defer window.Destroy()
-
-	s, err := InitState(window)
-	if err != nil {
-		panic(err)
-	}
-	defer s.Destroy()
-
-	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-		// Print resource usage on pressing 'R'
-		if key == glfw.KeyR && (action == glfw.Press || action == glfw.Repeat) {
-			report := s.instance.GenerateReport()
-			buf, _ := json.MarshalIndent(report, "", "  ")
-			fmt.Print(string(buf))
-		}
-	})
-
-	window.SetSizeCallback(func(_ *glfw.Window, width, height int) {
-		s.Resize(width, height)
-	})
-
-	for !window.ShouldClose() {
-		glfw.PollEvents()
-
-		err := s.Render()
-		if err != nil {
-			fmt.Println(err)
-
-			errstr := err.Error()
-			switch {
-			case strings.Contains(errstr, "Lost"):
-				s.Resize(window.GetSize())
-			case strings.Contains(errstr, "Outdated"):
-				s.Resize(window.GetSize())
-			case strings.Contains(errstr, "Timeout"):
-			default:
-				panic(err)
-			}
-		}
-	}
 }